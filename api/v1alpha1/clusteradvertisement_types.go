@@ -17,10 +17,17 @@ limitations under the License.
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 )
 
+// ClusterAdvertisementAgentLabelsAnnotation lists, comma-separated, the keys of
+// spec.labels that the cluster's agent reported through the ingestion endpoint.
+// Agents only replace those labels; every other label is left to operators.
+const ClusterAdvertisementAgentLabelsAnnotation = "broker.fluidos.eu/agent-labels"
+
 // ClusterAdvertisementSpec defines the desired state of ClusterAdvertisement
 type ClusterAdvertisementSpec struct {
 	// ClusterID is the unique identifier of the source cluster
@@ -30,6 +37,15 @@ type ClusterAdvertisementSpec struct {
 	// +optional
 	ClusterName string `json:"clusterName,omitempty"`
 
+	// Labels describe the cluster for placement constraints, e.g. region, zone,
+	// provider, compliance tier or hardware
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Taints keep reservations that do not tolerate them off the cluster
+	// +optional
+	Taints []ClusterTaint `json:"taints,omitempty"`
+
 	// Resources available in the cluster
 	Resources ResourceMetrics `json:"resources"`
 
@@ -43,8 +59,59 @@ type ClusterAdvertisementSpec struct {
 	// EndpointURL is the API endpoint of the source cluster
 	// +optional
 	EndpointURL string `json:"endpointURL,omitempty"`
+
+	// Bookings are the future windows Scheduled reservations have booked on the
+	// cluster. Like resources.reserved they are maintained by the broker, and
+	// recording them here serializes booking admission per cluster.
+	// +optional
+	Bookings []ClusterBooking `json:"bookings,omitempty"`
+}
+
+// ClusterBooking is capacity a reservation has booked on the cluster for a future window
+type ClusterBooking struct {
+	// ReservationUID identifies the reservation holding the booking
+	ReservationUID types.UID `json:"reservationUID"`
+
+	// Reservation is the namespace/name of the reservation holding the booking
+	Reservation string `json:"reservation"`
+
+	// StartTime is when the booked window opens
+	StartTime metav1.Time `json:"startTime"`
+
+	// EndTime is when the booked window closes; unset for open-ended bookings
+	// +optional
+	EndTime *metav1.Time `json:"endTime,omitempty"`
+
+	// Resources are the booked quantities
+	Resources RequestedResourceQuantities `json:"resources"`
 }
 
+// ClusterTaint marks a cluster as reserved for reservations that tolerate it
+type ClusterTaint struct {
+	// Key of the taint, e.g. dedicated
+	Key string `json:"key"`
+
+	// Value of the taint, e.g. gpu
+	// +optional
+	Value string `json:"value,omitempty"`
+
+	// Effect on reservations that do not tolerate the taint
+	// +kubebuilder:validation:Enum=NoReserve;PreferNoReserve
+	Effect TaintEffect `json:"effect"`
+}
+
+// TaintEffect is what a taint does to reservations that do not tolerate it
+type TaintEffect string
+
+const (
+	// TaintEffectNoReserve - Reservations that do not tolerate the taint are never placed on the cluster
+	TaintEffectNoReserve TaintEffect = "NoReserve"
+
+	// TaintEffectPreferNoReserve - The cluster is only used for reservations that do not
+	// tolerate the taint when no other cluster fits
+	TaintEffectPreferNoReserve TaintEffect = "PreferNoReserve"
+)
+
 // ResourceMetrics represents available resources with detailed breakdown
 type ResourceMetrics struct {
 	// Capacity - Total physical resources the cluster has
@@ -79,6 +146,11 @@ type ResourceQuantities struct {
 	// Storage (optional)
 	// +optional
 	Storage *resource.Quantity `json:"storage,omitempty"`
+
+	// Extended holds any other named resource, e.g. nvidia.com/mig-1g.5gb,
+	// hugepages-2Mi or ephemeral-storage (optional)
+	// +optional
+	Extended corev1.ResourceList `json:"extended,omitempty"`
 }
 
 // CostInfo represents cost information
@@ -127,6 +199,9 @@ const (
 	ClusterAdvertisementConditionStale = "Stale"
 	// ClusterAdvertisementConditionOvercommitted indicates reserved > available
 	ClusterAdvertisementConditionOvercommitted = "Overcommitted"
+	// ClusterAdvertisementConditionReservedDrift indicates the stored Reserved counter
+	// disagrees with the ledger computed from Reserved/Active Reservation objects
+	ClusterAdvertisementConditionReservedDrift = "ReservedDrift"
 )
 
 // +kubebuilder:object:root=true
@@ -0,0 +1,176 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// RequesterQuotaFairShareHistoryLabel marks the RequesterQuotas the broker
+// creates, without limits, to keep the fair-share history of requesters that
+// have no RequesterQuota of their own
+const RequesterQuotaFairShareHistoryLabel = "broker.fluidos.eu/fair-share-history"
+
+// RequesterQuotaSpec defines the desired state of RequesterQuota
+type RequesterQuotaSpec struct {
+	// RequesterID is the requester the quota applies to, across all namespaces
+	RequesterID string `json:"requesterID"`
+
+	// Hard limits what the requester may hold at the same time across all clusters
+	// +optional
+	Hard QuotaResources `json:"hard,omitempty"`
+
+	// Clusters limits what the requester may hold on individual clusters
+	// +optional
+	Clusters []ClusterQuota `json:"clusters,omitempty"`
+
+	// ExceedPolicy controls what happens to a reservation that would exceed the
+	// quota: Queue waits until usage drops, Reject fails it. Defaults to Queue.
+	// +kubebuilder:validation:Enum=Queue;Reject
+	// +optional
+	ExceedPolicy QuotaExceedPolicy `json:"exceedPolicy,omitempty"`
+
+	// FairShareWeight is the requester's share under the fair-share admission
+	// policy. A requester with weight 2 may use twice as much as one with weight 1
+	// before the other is served first. If several quotas name the same requester
+	// the largest weight applies.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=1
+	// +optional
+	FairShareWeight int32 `json:"fairShareWeight,omitempty"`
+}
+
+// QuotaResources are the amounts a quota limits or a requester uses. Unset
+// limits are unlimited.
+type QuotaResources struct {
+	// CPU cores
+	// +optional
+	CPU *resource.Quantity `json:"cpu,omitempty"`
+
+	// Memory
+	// +optional
+	Memory *resource.Quantity `json:"memory,omitempty"`
+
+	// GPU
+	// +optional
+	GPU *resource.Quantity `json:"gpu,omitempty"`
+
+	// Reservations is the number of reservations, counting every member of a reservation group
+	// +optional
+	Reservations *int32 `json:"reservations,omitempty"`
+}
+
+// ClusterQuota limits what a requester may hold on one cluster
+type ClusterQuota struct {
+	// ClusterID of the cluster
+	ClusterID string `json:"clusterID"`
+
+	// Hard limits on that cluster
+	Hard QuotaResources `json:"hard"`
+}
+
+// ClusterQuotaUsage is what a requester holds on one cluster
+type ClusterQuotaUsage struct {
+	// ClusterID of the cluster
+	ClusterID string `json:"clusterID"`
+
+	// Used on that cluster
+	Used QuotaResources `json:"used"`
+}
+
+// QuotaExceedPolicy describes how a reservation exceeding its quota is handled
+type QuotaExceedPolicy string
+
+const (
+	// QuotaExceedPolicyQueue - The reservation waits in the queue until usage drops
+	QuotaExceedPolicyQueue QuotaExceedPolicy = "Queue"
+
+	// QuotaExceedPolicyReject - The reservation fails
+	QuotaExceedPolicyReject QuotaExceedPolicy = "Reject"
+)
+
+// RequesterQuotaStatus defines the observed state of RequesterQuota
+type RequesterQuotaStatus struct {
+	// Used is what the requester currently holds across all clusters. Reservations
+	// holding resources and Scheduled bookings count.
+	// +optional
+	Used QuotaResources `json:"used,omitempty"`
+
+	// Clusters is what the requester currently holds per cluster
+	// +optional
+	Clusters []ClusterQuotaUsage `json:"clusters,omitempty"`
+
+	// FairShareHistory is the usage of the requester's deleted reservations and
+	// reservation groups, which the fair-share admission policy keeps counting
+	// once the objects are gone
+	// +optional
+	FairShareHistory *FairShareHistory `json:"fairShareHistory,omitempty"`
+
+	// LastUpdateTime
+	// +optional
+	LastUpdateTime metav1.Time `json:"lastUpdateTime,omitempty"`
+}
+
+// FairShareHistory is decayed usage recorded when reservations and reservation groups are deleted
+type FairShareHistory struct {
+	// Usage is the resources held multiplied by the seconds they were held,
+	// decayed to ObservedAt
+	// +optional
+	Usage corev1.ResourceList `json:"usage,omitempty"`
+
+	// ObservedAt is the instant Usage is decayed to
+	ObservedAt metav1.Time `json:"observedAt"`
+
+	// Settled are the UIDs of objects already added to Usage that are still
+	// being deleted, so they are neither counted twice nor added again
+	// +optional
+	Settled []types.UID `json:"settled,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Namespaced
+// +kubebuilder:printcolumn:name="Requester",type=string,JSONPath=`.spec.requesterID`
+// +kubebuilder:printcolumn:name="CPU-Used",type=string,JSONPath=`.status.used.cpu`
+// +kubebuilder:printcolumn:name="CPU-Hard",type=string,JSONPath=`.spec.hard.cpu`
+// +kubebuilder:printcolumn:name="Reservations",type=integer,JSONPath=`.status.used.reservations`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// RequesterQuota is the Schema for the requesterquotas API
+type RequesterQuota struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   RequesterQuotaSpec   `json:"spec,omitempty"`
+	Status RequesterQuotaStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// RequesterQuotaList contains a list of RequesterQuota
+type RequesterQuotaList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []RequesterQuota `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&RequesterQuota{}, &RequesterQuotaList{})
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -24,6 +25,10 @@ import (
 // ReservationFinalizer is the finalizer for reservations
 const ReservationFinalizer = "reservation.broker.fluidos.eu/finalizer"
 
+// ReservationRenewAnnotation extends a Reserved or Active reservation by the
+// duration it holds (e.g. "2h"). The broker removes it once handled.
+const ReservationRenewAnnotation = "broker.fluidos.eu/renew"
+
 // ReservationSpec defines the desired state of Reservation
 type ReservationSpec struct {
 	// TargetClusterID is the cluster where resources should be reserved
@@ -34,10 +39,21 @@ type ReservationSpec struct {
 	// RequestedResources are the resources being requested
 	RequestedResources RequestedResourceQuantities `json:"requestedResources"`
 
-	// Duration is how long the reservation should last (optional)
+	// Duration is how long the reservation should last (optional).
+	// Changing it on a Reserved or Active reservation renews it.
 	// +optional
 	Duration *metav1.Duration `json:"duration,omitempty"`
 
+	// StartTime books the resources for a future window instead of right away.
+	// The broker admits the booking only if the cluster has capacity for the
+	// whole window and locks the resources when the window opens.
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// EndTime is when the reservation ends. Mutually exclusive with Duration.
+	// +optional
+	EndTime *metav1.Time `json:"endTime,omitempty"`
+
 	// Priority of this reservation (higher number = higher priority)
 	// +optional
 	Priority int32 `json:"priority,omitempty"`
@@ -45,8 +61,192 @@ type ReservationSpec struct {
 	// RequesterID identifies who is requesting the reservation
 	// +optional
 	RequesterID string `json:"requesterID,omitempty"`
+
+	// ScoringStrategy selects how candidate clusters are ranked for this reservation
+	// (spread, binpack, cost, balanced). Overrides the broker default when set.
+	// +optional
+	ScoringStrategy string `json:"scoringStrategy,omitempty"`
+
+	// QueueTimeout is how long the reservation may wait in the Queued phase for
+	// capacity before it fails. Without it the reservation waits indefinitely.
+	// +optional
+	QueueTimeout *metav1.Duration `json:"queueTimeout,omitempty"`
+
+	// PreemptionPolicy controls whether this reservation may evict lower-priority
+	// reservations that are Reserved but not yet Active when it cannot fit otherwise.
+	// Defaults to Never.
+	// +kubebuilder:validation:Enum=Never;PreemptLowerPriority
+	// +optional
+	PreemptionPolicy PreemptionPolicy `json:"preemptionPolicy,omitempty"`
+
+	// ActivationTimeout is how long the reservation may stay Reserved without the
+	// requester setting RequesterActive before its resources are released.
+	// Overrides the broker-wide --activation-timeout.
+	// +optional
+	ActivationTimeout *metav1.Duration `json:"activationTimeout,omitempty"`
+
+	// HeartbeatGracePeriod is how long an Active reservation survives without a
+	// requester heartbeat before its resources are released.
+	// Overrides the broker-wide --requester-heartbeat-grace.
+	// +optional
+	HeartbeatGracePeriod *metav1.Duration `json:"heartbeatGracePeriod,omitempty"`
+
+	// FailoverPolicy controls what happens to a Reserved reservation whose target
+	// cluster stays stale longer than the broker's failover grace period.
+	// Defaults to None.
+	// +kubebuilder:validation:Enum=None;Reschedule
+	// +optional
+	FailoverPolicy FailoverPolicy `json:"failoverPolicy,omitempty"`
+
+	// SplitPolicy lets the broker satisfy the reservation with partial allocations
+	// on several clusters when no single cluster can host it. Only reservations
+	// without a targetClusterID that start right away are split.
+	// +optional
+	SplitPolicy *SplitPolicy `json:"splitPolicy,omitempty"`
+
+	// Affinity constrains and ranks the clusters the broker selects from by their
+	// labels and by the reservations they already host. It does not apply to an
+	// explicitly set targetClusterID.
+	// +optional
+	Affinity *ClusterAffinity `json:"affinity,omitempty"`
+
+	// Tolerations let the reservation onto clusters with matching taints
+	// +optional
+	Tolerations []ReservationToleration `json:"tolerations,omitempty"`
+
+	// DryRun asks where the reservation would be placed and what it would cost
+	// without locking anything. The reservation stays Pending with the answer in
+	// status.decision and status.estimatedCost, re-evaluated periodically. Setting
+	// it back to false places the reservation for real; it is ignored once the
+	// reservation has left Pending.
+	// +optional
+	DryRun bool `json:"dryRun,omitempty"`
+}
+
+// ReservationToleration tolerates the cluster taints it matches
+type ReservationToleration struct {
+	// Key of the taints to tolerate. Empty with operator Exists tolerates every taint.
+	// +optional
+	Key string `json:"key,omitempty"`
+
+	// Operator is Equal (the taint value must equal Value) or Exists (any value).
+	// Defaults to Equal.
+	// +kubebuilder:validation:Enum=Equal;Exists
+	// +optional
+	Operator TolerationOperator `json:"operator,omitempty"`
+
+	// Value the taint must have with operator Equal
+	// +optional
+	Value string `json:"value,omitempty"`
+
+	// Effect of the taints to tolerate. Empty tolerates every effect.
+	// +kubebuilder:validation:Enum=NoReserve;PreferNoReserve
+	// +optional
+	Effect TaintEffect `json:"effect,omitempty"`
+}
+
+// TolerationOperator is how a toleration matches taint values
+type TolerationOperator string
+
+const (
+	// TolerationOpEqual - The taint value must equal the toleration value
+	TolerationOpEqual TolerationOperator = "Equal"
+
+	// TolerationOpExists - Any taint value is tolerated
+	TolerationOpExists TolerationOperator = "Exists"
+)
+
+// ClusterAffinity groups the placement constraints of a reservation
+type ClusterAffinity struct {
+	// RequiredClusterSelector must match the labels of a cluster for it to be selected
+	// +optional
+	RequiredClusterSelector *metav1.LabelSelector `json:"requiredClusterSelector,omitempty"`
+
+	// PreferredClusterSelectors raise the score of clusters whose labels they match
+	// +optional
+	PreferredClusterSelectors []WeightedClusterSelector `json:"preferredClusterSelectors,omitempty"`
+
+	// RequiredAntiAffinity keeps the reservation out of every topology domain
+	// that hosts a reservation matched by one of the terms
+	// +optional
+	RequiredAntiAffinity []ReservationAntiAffinityTerm `json:"requiredAntiAffinity,omitempty"`
+
+	// PreferredAntiAffinity lowers the score of clusters in topology domains that
+	// host a reservation matched by one of the terms
+	// +optional
+	PreferredAntiAffinity []WeightedAntiAffinityTerm `json:"preferredAntiAffinity,omitempty"`
+}
+
+// WeightedClusterSelector is a preferred cluster selector and its weight
+type WeightedClusterSelector struct {
+	// Weight of the preference relative to the other preferred terms
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	Weight int32 `json:"weight"`
+
+	// Selector matched against the cluster labels
+	Selector metav1.LabelSelector `json:"selector"`
+}
+
+// ReservationAntiAffinityTerm describes reservations to stay away from
+type ReservationAntiAffinityTerm struct {
+	// ReservationSelector selects the reservations, in any namespace, by their labels.
+	// Reservations that hold resources or are Scheduled count.
+	ReservationSelector metav1.LabelSelector `json:"reservationSelector"`
+
+	// TopologyKey is the cluster label whose value defines the domain to avoid,
+	// e.g. region. Empty means the cluster itself. Clusters without the label
+	// are in no domain.
+	// +optional
+	TopologyKey string `json:"topologyKey,omitempty"`
+}
+
+// WeightedAntiAffinityTerm is a preferred anti-affinity term and its weight
+type WeightedAntiAffinityTerm struct {
+	// Weight of the preference relative to the other preferred terms
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	Weight int32 `json:"weight"`
+
+	// Term describes the reservations to stay away from
+	Term ReservationAntiAffinityTerm `json:"term"`
 }
 
+// SplitPolicy bounds how a reservation may be split across clusters
+type SplitPolicy struct {
+	// MinChunk is the smallest part of the request a fragment may hold. The request
+	// is divided into equal chunks of at least this size in every resource MinChunk
+	// sets to a non-zero value, and every fragment holds a whole number of chunks.
+	MinChunk RequestedResourceQuantities `json:"minChunk"`
+
+	// MaxFragments is the largest number of clusters the reservation may be split across
+	// +kubebuilder:validation:Minimum=2
+	MaxFragments int32 `json:"maxFragments"`
+}
+
+// FailoverPolicy describes how a reservation reacts to its target cluster going stale
+type FailoverPolicy string
+
+const (
+	// FailoverPolicyNone - The reservation stays on its target cluster
+	FailoverPolicyNone FailoverPolicy = "None"
+
+	// FailoverPolicyReschedule - The reservation is re-placed on another cluster
+	FailoverPolicyReschedule FailoverPolicy = "Reschedule"
+)
+
+// PreemptionPolicy describes whether a reservation may preempt others
+type PreemptionPolicy string
+
+const (
+	// PreemptionPolicyNever - The reservation never evicts other reservations
+	PreemptionPolicyNever PreemptionPolicy = "Never"
+
+	// PreemptionPolicyPreemptLowerPriority - The reservation may evict Reserved
+	// reservations of lower priority to make room
+	PreemptionPolicyPreemptLowerPriority PreemptionPolicy = "PreemptLowerPriority"
+)
+
 // RequestedResourceQuantities represents requested resource amounts
 type RequestedResourceQuantities struct {
 	// CPU cores requested
@@ -62,12 +262,17 @@ type RequestedResourceQuantities struct {
 	// Storage requested (optional)
 	// +optional
 	Storage *resource.Quantity `json:"storage,omitempty"`
+
+	// Extended requests any other named resource advertised by clusters,
+	// e.g. nvidia.com/mig-1g.5gb or hugepages-2Mi (optional)
+	// +optional
+	Extended corev1.ResourceList `json:"extended,omitempty"`
 }
 
 // ReservationStatus defines the observed state of Reservation
 type ReservationStatus struct {
 	// Phase represents the current state of the reservation
-	// Possible values: Pending, Reserved, Active, Failed, Released
+	// Possible values: Pending, Queued, Scheduled, Reserved, Active, Failed, Released, Preempted
 	// +optional
 	Phase ReservationPhase `json:"phase,omitempty"`
 
@@ -75,6 +280,15 @@ type ReservationStatus struct {
 	// +optional
 	Message string `json:"message,omitempty"`
 
+	// Reason is a machine-readable explanation of how the reservation ended up
+	// in a terminal phase, e.g. ActivationTimedOut
+	// +optional
+	Reason string `json:"reason,omitempty"`
+
+	// QueuedAt is when the reservation entered the waiting queue
+	// +optional
+	QueuedAt *metav1.Time `json:"queuedAt,omitempty"`
+
 	// ReservedAt is when the reservation was confirmed
 	// +optional
 	ReservedAt *metav1.Time `json:"reservedAt,omitempty"`
@@ -83,6 +297,41 @@ type ReservationStatus struct {
 	// +optional
 	ExpiresAt *metav1.Time `json:"expiresAt,omitempty"`
 
+	// Fragments are the partial allocations of a split reservation, one per
+	// cluster. A split reservation has no targetClusterID.
+	// +optional
+	Fragments []ReservationFragment `json:"fragments,omitempty"`
+
+	// LockedResources are the quantities actually locked on the target cluster,
+	// or in total across the fragments of a split reservation.
+	// They trail spec.requestedResources until a resize has been applied.
+	// +optional
+	LockedResources *RequestedResourceQuantities `json:"lockedResources,omitempty"`
+
+	// ActivatedAt is when the requester confirmed activation
+	// +optional
+	ActivatedAt *metav1.Time `json:"activatedAt,omitempty"`
+
+	// RequesterHeartbeatTime is renewed periodically by the requester while it
+	// consumes an Active reservation
+	// +optional
+	RequesterHeartbeatTime *metav1.Time `json:"requesterHeartbeatTime,omitempty"`
+
+	// ObservedDuration is the spec.duration ExpiresAt was last computed from;
+	// a different spec.duration is a renewal request
+	// +optional
+	ObservedDuration *metav1.Duration `json:"observedDuration,omitempty"`
+
+	// EstimatedCost is the projected cost of the reservation on its target cluster
+	// +optional
+	EstimatedCost *CostEstimate `json:"estimatedCost,omitempty"`
+
+	// Decision explains the broker's last attempt to pick a cluster for the
+	// reservation: which clusters were considered, why some could not host it
+	// and how the others scored
+	// +optional
+	Decision *PlacementDecision `json:"decision,omitempty"`
+
 	// LastUpdateTime
 	// +optional
 	LastUpdateTime metav1.Time `json:"lastUpdateTime,omitempty"`
@@ -90,6 +339,134 @@ type ReservationStatus struct {
 	// Conditions represent the latest observations of the reservation state
 	// +optional
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// PlacementHistory records the moves of the reservation between clusters,
+	// oldest first and bounded to the most recent entries
+	// +optional
+	PlacementHistory []PlacementEvent `json:"placementHistory,omitempty"`
+}
+
+// PlacementDecision is a summary of how the broker chose a cluster
+type PlacementDecision struct {
+	// Strategy is the scoring strategy that ranked the candidates
+	Strategy string `json:"strategy"`
+
+	// SelectedClusterID is the cluster the broker chose, empty if none could host the reservation
+	// +optional
+	SelectedClusterID string `json:"selectedClusterID,omitempty"`
+
+	// Candidates are the clusters considered: the selected one first, then the
+	// others by score, then those filtered out. At most 10 are kept.
+	// +optional
+	Candidates []PlacementCandidate `json:"candidates,omitempty"`
+
+	// OmittedCandidates is the number of clusters left out of candidates
+	// +optional
+	OmittedCandidates int32 `json:"omittedCandidates,omitempty"`
+}
+
+// PlacementCandidate is one cluster considered for a reservation
+type PlacementCandidate struct {
+	// ClusterID of the cluster
+	ClusterID string `json:"clusterID"`
+
+	// FilteredBy is why the cluster cannot host the reservation, empty if it was scored
+	// +optional
+	FilteredBy PlacementFilter `json:"filteredBy,omitempty"`
+
+	// Detail explains the filter, e.g. which resources fall short
+	// +optional
+	Detail string `json:"detail,omitempty"`
+
+	// Score is the total score of a cluster that passed every filter, higher is better
+	// +optional
+	Score string `json:"score,omitempty"`
+
+	// ScoreBreakdown lists the terms the score is made of
+	// +optional
+	ScoreBreakdown *ScoreBreakdown `json:"scoreBreakdown,omitempty"`
+
+	// EstimatedCost is the projected cost of the reservation on the cluster, if it advertises prices
+	// +optional
+	EstimatedCost *CostEstimate `json:"estimatedCost,omitempty"`
+}
+
+// ScoreBreakdown are the terms adding up to a candidate's score
+type ScoreBreakdown struct {
+	// Strategy is the score given by the scoring strategy, between 0 and 1
+	Strategy string `json:"strategy"`
+
+	// Priority is the bonus for the reservation's priority
+	// +optional
+	Priority string `json:"priority,omitempty"`
+
+	// Affinity is the preferred cluster affinity and anti-affinity term
+	// +optional
+	Affinity string `json:"affinity,omitempty"`
+
+	// TaintPenalty is subtracted for a PreferNoReserve taint the reservation does not tolerate
+	// +optional
+	TaintPenalty string `json:"taintPenalty,omitempty"`
+}
+
+// PlacementFilter is why a cluster was ruled out for a reservation
+type PlacementFilter string
+
+const (
+	// PlacementFilterInactive - The cluster's advertisement is stale
+	PlacementFilterInactive PlacementFilter = "Inactive"
+
+	// PlacementFilterOwnCluster - The cluster is the requester's own cluster
+	PlacementFilterOwnCluster PlacementFilter = "OwnCluster"
+
+	// PlacementFilterTainted - The cluster has a NoReserve taint the reservation does not tolerate
+	PlacementFilterTainted PlacementFilter = "Tainted"
+
+	// PlacementFilterAffinity - The reservation's affinity rules out the cluster
+	PlacementFilterAffinity PlacementFilter = "Affinity"
+
+	// PlacementFilterQuota - The requester's quota on the cluster is used up
+	PlacementFilterQuota PlacementFilter = "QuotaExceeded"
+
+	// PlacementFilterInsufficientResources - The cluster lacks capacity for the requested window
+	PlacementFilterInsufficientResources PlacementFilter = "InsufficientResources"
+)
+
+// ReservationFragment is the part of a split reservation held on one cluster
+type ReservationFragment struct {
+	// ClusterID of the cluster holding the fragment
+	ClusterID string `json:"clusterID"`
+
+	// LockedResources are the quantities locked on that cluster
+	LockedResources RequestedResourceQuantities `json:"lockedResources"`
+}
+
+// PlacementEvent records a reservation being moved from one cluster to another
+type PlacementEvent struct {
+	// Time of the move
+	Time metav1.Time `json:"time"`
+
+	// FromClusterID is the cluster the reservation left
+	FromClusterID string `json:"fromClusterID"`
+
+	// ToClusterID is the cluster the reservation moved to
+	ToClusterID string `json:"toClusterID"`
+
+	// Reason for the move, e.g. ClusterStale
+	Reason string `json:"reason"`
+}
+
+// CostEstimate is the projected cost of a reservation, converted to the broker's base currency
+type CostEstimate struct {
+	// HourlyCost is the projected cost per hour
+	HourlyCost string `json:"hourlyCost"`
+
+	// TotalCost is the projected cost over the whole reservation (empty when it has neither duration nor endTime)
+	// +optional
+	TotalCost string `json:"totalCost,omitempty"`
+
+	// Currency of the estimate
+	Currency string `json:"currency"`
 }
 
 const (
@@ -97,6 +474,26 @@ const (
 	ReservationConditionRequesterActive = "RequesterActive"
 	// ReservationConditionRequesterReleased indicates the requester finished consuming resources.
 	ReservationConditionRequesterReleased = "RequesterReleased"
+	// ReservationConditionRenewed reports the outcome of the last renewal request.
+	ReservationConditionRenewed = "Renewed"
+	// ReservationConditionResizeFailed is True while a change of requested resources cannot be applied.
+	ReservationConditionResizeFailed = "ResizeFailed"
+	// ReservationConditionRequesterLost indicates the requester stopped sending heartbeats.
+	ReservationConditionRequesterLost = "RequesterLost"
+)
+
+const (
+	// ReservationReasonActivationTimedOut - The requester never confirmed activation
+	// within the activation timeout and the resources were released
+	ReservationReasonActivationTimedOut = "ActivationTimedOut"
+
+	// ReservationReasonRequesterLost - The requester's heartbeat lapsed while the
+	// reservation was Active and the resources were released
+	ReservationReasonRequesterLost = "RequesterLost"
+
+	// ReservationReasonQuotaExceeded - The reservation would exceed a RequesterQuota
+	// of its requester whose exceed policy is Reject
+	ReservationReasonQuotaExceeded = "QuotaExceeded"
 )
 
 // ReservationPhase represents the phase of a reservation
@@ -106,6 +503,12 @@ const (
 	// ReservationPhasePending - Reservation request is pending
 	ReservationPhasePending ReservationPhase = "Pending"
 
+	// ReservationPhaseQueued - No cluster can currently satisfy the request, waiting for capacity
+	ReservationPhaseQueued ReservationPhase = "Queued"
+
+	// ReservationPhaseScheduled - Capacity is booked for a future window, resources are not locked yet
+	ReservationPhaseScheduled ReservationPhase = "Scheduled"
+
 	// ReservationPhaseReserved - Resources are reserved but not yet active
 	ReservationPhaseReserved ReservationPhase = "Reserved"
 
@@ -117,6 +520,9 @@ const (
 
 	// ReservationPhaseReleased - Reservation has been released
 	ReservationPhaseReleased ReservationPhase = "Released"
+
+	// ReservationPhasePreempted - Resources were taken over by a higher-priority reservation
+	ReservationPhasePreempted ReservationPhase = "Preempted"
 )
 
 // +kubebuilder:object:root=true
@@ -0,0 +1,144 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ReservationGroupFinalizer is the finalizer for reservation groups
+const ReservationGroupFinalizer = "reservationgroup.broker.fluidos.eu/finalizer"
+
+// ReservationGroupSpec defines the desired state of ReservationGroup
+type ReservationGroupSpec struct {
+	// Members are the reservations that are placed together: either every
+	// member is locked on its cluster or none is
+	// +kubebuilder:validation:MinItems=1
+	Members []ReservationGroupMember `json:"members"`
+
+	// DistinctClusters places every member on a different cluster
+	// +optional
+	DistinctClusters bool `json:"distinctClusters,omitempty"`
+
+	// Duration is how long the group should last (optional)
+	// +optional
+	Duration *metav1.Duration `json:"duration,omitempty"`
+
+	// Priority of this group (higher number = higher priority)
+	// +optional
+	Priority int32 `json:"priority,omitempty"`
+
+	// RequesterID identifies who is requesting the group
+	// +optional
+	RequesterID string `json:"requesterID,omitempty"`
+
+	// ScoringStrategy selects how candidate clusters are ranked for the members
+	// (spread, binpack, cost, balanced). Overrides the broker default when set.
+	// +optional
+	ScoringStrategy string `json:"scoringStrategy,omitempty"`
+
+	// Tolerations let the members onto clusters with matching taints
+	// +optional
+	Tolerations []ReservationToleration `json:"tolerations,omitempty"`
+}
+
+// ReservationGroupMember is one reservation of a group
+type ReservationGroupMember struct {
+	// Name identifies the member within the group
+	Name string `json:"name"`
+
+	// TargetClusterID pins the member to a cluster
+	// If not specified, the broker selects the cluster
+	// +optional
+	TargetClusterID string `json:"targetClusterID,omitempty"`
+
+	// RequestedResources are the resources requested by this member
+	RequestedResources RequestedResourceQuantities `json:"requestedResources"`
+}
+
+// ReservationGroupStatus defines the observed state of ReservationGroup
+type ReservationGroupStatus struct {
+	// Phase represents the current state of the group
+	// Possible values: Pending, Reserved, Failed, Released
+	// +optional
+	Phase ReservationPhase `json:"phase,omitempty"`
+
+	// Message provides additional information about the status
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// Placements lists where each member is locked
+	// +optional
+	Placements []MemberPlacement `json:"placements,omitempty"`
+
+	// ReservedAt is when every member was locked
+	// +optional
+	ReservedAt *metav1.Time `json:"reservedAt,omitempty"`
+
+	// ExpiresAt is when the group expires
+	// +optional
+	ExpiresAt *metav1.Time `json:"expiresAt,omitempty"`
+
+	// LastUpdateTime
+	// +optional
+	LastUpdateTime metav1.Time `json:"lastUpdateTime,omitempty"`
+
+	// Conditions represent the latest observations of the group state
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// MemberPlacement records where a group member holds resources
+type MemberPlacement struct {
+	// Name of the member
+	Name string `json:"name"`
+
+	// ClusterID is the cluster the member is locked on
+	ClusterID string `json:"clusterID"`
+
+	// LockedResources are the quantities locked for the member
+	LockedResources RequestedResourceQuantities `json:"lockedResources"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Namespaced
+// +kubebuilder:printcolumn:name="Distinct",type=boolean,JSONPath=`.spec.distinctClusters`
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// ReservationGroup is the Schema for the reservationgroups API
+type ReservationGroup struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ReservationGroupSpec   `json:"spec,omitempty"`
+	Status ReservationGroupStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ReservationGroupList contains a list of ReservationGroup
+type ReservationGroupList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ReservationGroup `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ReservationGroup{}, &ReservationGroupList{})
+}
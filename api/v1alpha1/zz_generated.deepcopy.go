@@ -21,8 +21,10 @@ limitations under the License.
 package v1alpha1
 
 import (
-	"k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 )
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
@@ -87,6 +89,18 @@ func (in *ClusterAdvertisementList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterAdvertisementSpec) DeepCopyInto(out *ClusterAdvertisementSpec) {
 	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Taints != nil {
+		in, out := &in.Taints, &out.Taints
+		*out = make([]ClusterTaint, len(*in))
+		copy(*out, *in)
+	}
 	in.Resources.DeepCopyInto(&out.Resources)
 	if in.Cost != nil {
 		in, out := &in.Cost, &out.Cost
@@ -94,6 +108,13 @@ func (in *ClusterAdvertisementSpec) DeepCopyInto(out *ClusterAdvertisementSpec)
 		**out = **in
 	}
 	in.Timestamp.DeepCopyInto(&out.Timestamp)
+	if in.Bookings != nil {
+		in, out := &in.Bookings, &out.Bookings
+		*out = make([]ClusterBooking, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterAdvertisementSpec.
@@ -112,7 +133,7 @@ func (in *ClusterAdvertisementStatus) DeepCopyInto(out *ClusterAdvertisementStat
 	in.LastUpdateTime.DeepCopyInto(&out.LastUpdateTime)
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
-		*out = make([]v1.Condition, len(*in))
+		*out = make([]metav1.Condition, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -129,6 +150,130 @@ func (in *ClusterAdvertisementStatus) DeepCopy() *ClusterAdvertisementStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterAffinity) DeepCopyInto(out *ClusterAffinity) {
+	*out = *in
+	if in.RequiredClusterSelector != nil {
+		in, out := &in.RequiredClusterSelector, &out.RequiredClusterSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PreferredClusterSelectors != nil {
+		in, out := &in.PreferredClusterSelectors, &out.PreferredClusterSelectors
+		*out = make([]WeightedClusterSelector, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.RequiredAntiAffinity != nil {
+		in, out := &in.RequiredAntiAffinity, &out.RequiredAntiAffinity
+		*out = make([]ReservationAntiAffinityTerm, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PreferredAntiAffinity != nil {
+		in, out := &in.PreferredAntiAffinity, &out.PreferredAntiAffinity
+		*out = make([]WeightedAntiAffinityTerm, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterAffinity.
+func (in *ClusterAffinity) DeepCopy() *ClusterAffinity {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterAffinity)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterBooking) DeepCopyInto(out *ClusterBooking) {
+	*out = *in
+	in.StartTime.DeepCopyInto(&out.StartTime)
+	if in.EndTime != nil {
+		in, out := &in.EndTime, &out.EndTime
+		*out = (*in).DeepCopy()
+	}
+	in.Resources.DeepCopyInto(&out.Resources)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterBooking.
+func (in *ClusterBooking) DeepCopy() *ClusterBooking {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterBooking)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterQuota) DeepCopyInto(out *ClusterQuota) {
+	*out = *in
+	in.Hard.DeepCopyInto(&out.Hard)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterQuota.
+func (in *ClusterQuota) DeepCopy() *ClusterQuota {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterQuota)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterQuotaUsage) DeepCopyInto(out *ClusterQuotaUsage) {
+	*out = *in
+	in.Used.DeepCopyInto(&out.Used)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterQuotaUsage.
+func (in *ClusterQuotaUsage) DeepCopy() *ClusterQuotaUsage {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterQuotaUsage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterTaint) DeepCopyInto(out *ClusterTaint) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterTaint.
+func (in *ClusterTaint) DeepCopy() *ClusterTaint {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterTaint)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CostEstimate) DeepCopyInto(out *CostEstimate) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CostEstimate.
+func (in *CostEstimate) DeepCopy() *CostEstimate {
+	if in == nil {
+		return nil
+	}
+	out := new(CostEstimate)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CostInfo) DeepCopyInto(out *CostInfo) {
 	*out = *in
@@ -144,6 +289,148 @@ func (in *CostInfo) DeepCopy() *CostInfo {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FairShareHistory) DeepCopyInto(out *FairShareHistory) {
+	*out = *in
+	if in.Usage != nil {
+		in, out := &in.Usage, &out.Usage
+		*out = make(v1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	in.ObservedAt.DeepCopyInto(&out.ObservedAt)
+	if in.Settled != nil {
+		in, out := &in.Settled, &out.Settled
+		*out = make([]types.UID, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FairShareHistory.
+func (in *FairShareHistory) DeepCopy() *FairShareHistory {
+	if in == nil {
+		return nil
+	}
+	out := new(FairShareHistory)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MemberPlacement) DeepCopyInto(out *MemberPlacement) {
+	*out = *in
+	in.LockedResources.DeepCopyInto(&out.LockedResources)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MemberPlacement.
+func (in *MemberPlacement) DeepCopy() *MemberPlacement {
+	if in == nil {
+		return nil
+	}
+	out := new(MemberPlacement)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlacementCandidate) DeepCopyInto(out *PlacementCandidate) {
+	*out = *in
+	if in.ScoreBreakdown != nil {
+		in, out := &in.ScoreBreakdown, &out.ScoreBreakdown
+		*out = new(ScoreBreakdown)
+		**out = **in
+	}
+	if in.EstimatedCost != nil {
+		in, out := &in.EstimatedCost, &out.EstimatedCost
+		*out = new(CostEstimate)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlacementCandidate.
+func (in *PlacementCandidate) DeepCopy() *PlacementCandidate {
+	if in == nil {
+		return nil
+	}
+	out := new(PlacementCandidate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlacementDecision) DeepCopyInto(out *PlacementDecision) {
+	*out = *in
+	if in.Candidates != nil {
+		in, out := &in.Candidates, &out.Candidates
+		*out = make([]PlacementCandidate, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlacementDecision.
+func (in *PlacementDecision) DeepCopy() *PlacementDecision {
+	if in == nil {
+		return nil
+	}
+	out := new(PlacementDecision)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlacementEvent) DeepCopyInto(out *PlacementEvent) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlacementEvent.
+func (in *PlacementEvent) DeepCopy() *PlacementEvent {
+	if in == nil {
+		return nil
+	}
+	out := new(PlacementEvent)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *QuotaResources) DeepCopyInto(out *QuotaResources) {
+	*out = *in
+	if in.CPU != nil {
+		in, out := &in.CPU, &out.CPU
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.Memory != nil {
+		in, out := &in.Memory, &out.Memory
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.GPU != nil {
+		in, out := &in.GPU, &out.GPU
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.Reservations != nil {
+		in, out := &in.Reservations, &out.Reservations
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QuotaResources.
+func (in *QuotaResources) DeepCopy() *QuotaResources {
+	if in == nil {
+		return nil
+	}
+	out := new(QuotaResources)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RequestedResourceQuantities) DeepCopyInto(out *RequestedResourceQuantities) {
 	*out = *in
@@ -159,6 +446,13 @@ func (in *RequestedResourceQuantities) DeepCopyInto(out *RequestedResourceQuanti
 		x := (*in).DeepCopy()
 		*out = &x
 	}
+	if in.Extended != nil {
+		in, out := &in.Extended, &out.Extended
+		*out = make(v1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RequestedResourceQuantities.
@@ -171,6 +465,117 @@ func (in *RequestedResourceQuantities) DeepCopy() *RequestedResourceQuantities {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RequesterQuota) DeepCopyInto(out *RequesterQuota) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RequesterQuota.
+func (in *RequesterQuota) DeepCopy() *RequesterQuota {
+	if in == nil {
+		return nil
+	}
+	out := new(RequesterQuota)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *RequesterQuota) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RequesterQuotaList) DeepCopyInto(out *RequesterQuotaList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]RequesterQuota, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RequesterQuotaList.
+func (in *RequesterQuotaList) DeepCopy() *RequesterQuotaList {
+	if in == nil {
+		return nil
+	}
+	out := new(RequesterQuotaList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *RequesterQuotaList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RequesterQuotaSpec) DeepCopyInto(out *RequesterQuotaSpec) {
+	*out = *in
+	in.Hard.DeepCopyInto(&out.Hard)
+	if in.Clusters != nil {
+		in, out := &in.Clusters, &out.Clusters
+		*out = make([]ClusterQuota, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RequesterQuotaSpec.
+func (in *RequesterQuotaSpec) DeepCopy() *RequesterQuotaSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RequesterQuotaSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RequesterQuotaStatus) DeepCopyInto(out *RequesterQuotaStatus) {
+	*out = *in
+	in.Used.DeepCopyInto(&out.Used)
+	if in.Clusters != nil {
+		in, out := &in.Clusters, &out.Clusters
+		*out = make([]ClusterQuotaUsage, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.FairShareHistory != nil {
+		in, out := &in.FairShareHistory, &out.FairShareHistory
+		*out = new(FairShareHistory)
+		(*in).DeepCopyInto(*out)
+	}
+	in.LastUpdateTime.DeepCopyInto(&out.LastUpdateTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RequesterQuotaStatus.
+func (in *RequesterQuotaStatus) DeepCopy() *RequesterQuotaStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RequesterQuotaStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Reservation) DeepCopyInto(out *Reservation) {
 	*out = *in
@@ -198,6 +603,183 @@ func (in *Reservation) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReservationAntiAffinityTerm) DeepCopyInto(out *ReservationAntiAffinityTerm) {
+	*out = *in
+	in.ReservationSelector.DeepCopyInto(&out.ReservationSelector)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReservationAntiAffinityTerm.
+func (in *ReservationAntiAffinityTerm) DeepCopy() *ReservationAntiAffinityTerm {
+	if in == nil {
+		return nil
+	}
+	out := new(ReservationAntiAffinityTerm)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReservationFragment) DeepCopyInto(out *ReservationFragment) {
+	*out = *in
+	in.LockedResources.DeepCopyInto(&out.LockedResources)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReservationFragment.
+func (in *ReservationFragment) DeepCopy() *ReservationFragment {
+	if in == nil {
+		return nil
+	}
+	out := new(ReservationFragment)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReservationGroup) DeepCopyInto(out *ReservationGroup) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReservationGroup.
+func (in *ReservationGroup) DeepCopy() *ReservationGroup {
+	if in == nil {
+		return nil
+	}
+	out := new(ReservationGroup)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ReservationGroup) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReservationGroupList) DeepCopyInto(out *ReservationGroupList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ReservationGroup, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReservationGroupList.
+func (in *ReservationGroupList) DeepCopy() *ReservationGroupList {
+	if in == nil {
+		return nil
+	}
+	out := new(ReservationGroupList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ReservationGroupList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReservationGroupMember) DeepCopyInto(out *ReservationGroupMember) {
+	*out = *in
+	in.RequestedResources.DeepCopyInto(&out.RequestedResources)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReservationGroupMember.
+func (in *ReservationGroupMember) DeepCopy() *ReservationGroupMember {
+	if in == nil {
+		return nil
+	}
+	out := new(ReservationGroupMember)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReservationGroupSpec) DeepCopyInto(out *ReservationGroupSpec) {
+	*out = *in
+	if in.Members != nil {
+		in, out := &in.Members, &out.Members
+		*out = make([]ReservationGroupMember, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Duration != nil {
+		in, out := &in.Duration, &out.Duration
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.Tolerations != nil {
+		in, out := &in.Tolerations, &out.Tolerations
+		*out = make([]ReservationToleration, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReservationGroupSpec.
+func (in *ReservationGroupSpec) DeepCopy() *ReservationGroupSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ReservationGroupSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReservationGroupStatus) DeepCopyInto(out *ReservationGroupStatus) {
+	*out = *in
+	if in.Placements != nil {
+		in, out := &in.Placements, &out.Placements
+		*out = make([]MemberPlacement, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ReservedAt != nil {
+		in, out := &in.ReservedAt, &out.ReservedAt
+		*out = (*in).DeepCopy()
+	}
+	if in.ExpiresAt != nil {
+		in, out := &in.ExpiresAt, &out.ExpiresAt
+		*out = (*in).DeepCopy()
+	}
+	in.LastUpdateTime.DeepCopyInto(&out.LastUpdateTime)
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReservationGroupStatus.
+func (in *ReservationGroupStatus) DeepCopy() *ReservationGroupStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ReservationGroupStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ReservationList) DeepCopyInto(out *ReservationList) {
 	*out = *in
@@ -236,9 +818,47 @@ func (in *ReservationSpec) DeepCopyInto(out *ReservationSpec) {
 	in.RequestedResources.DeepCopyInto(&out.RequestedResources)
 	if in.Duration != nil {
 		in, out := &in.Duration, &out.Duration
-		*out = new(v1.Duration)
+		*out = new(metav1.Duration)
 		**out = **in
 	}
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.EndTime != nil {
+		in, out := &in.EndTime, &out.EndTime
+		*out = (*in).DeepCopy()
+	}
+	if in.QueueTimeout != nil {
+		in, out := &in.QueueTimeout, &out.QueueTimeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.ActivationTimeout != nil {
+		in, out := &in.ActivationTimeout, &out.ActivationTimeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.HeartbeatGracePeriod != nil {
+		in, out := &in.HeartbeatGracePeriod, &out.HeartbeatGracePeriod
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.SplitPolicy != nil {
+		in, out := &in.SplitPolicy, &out.SplitPolicy
+		*out = new(SplitPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Affinity != nil {
+		in, out := &in.Affinity, &out.Affinity
+		*out = new(ClusterAffinity)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Tolerations != nil {
+		in, out := &in.Tolerations, &out.Tolerations
+		*out = make([]ReservationToleration, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReservationSpec.
@@ -254,6 +874,10 @@ func (in *ReservationSpec) DeepCopy() *ReservationSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ReservationStatus) DeepCopyInto(out *ReservationStatus) {
 	*out = *in
+	if in.QueuedAt != nil {
+		in, out := &in.QueuedAt, &out.QueuedAt
+		*out = (*in).DeepCopy()
+	}
 	if in.ReservedAt != nil {
 		in, out := &in.ReservedAt, &out.ReservedAt
 		*out = (*in).DeepCopy()
@@ -262,10 +886,52 @@ func (in *ReservationStatus) DeepCopyInto(out *ReservationStatus) {
 		in, out := &in.ExpiresAt, &out.ExpiresAt
 		*out = (*in).DeepCopy()
 	}
+	if in.Fragments != nil {
+		in, out := &in.Fragments, &out.Fragments
+		*out = make([]ReservationFragment, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LockedResources != nil {
+		in, out := &in.LockedResources, &out.LockedResources
+		*out = new(RequestedResourceQuantities)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ActivatedAt != nil {
+		in, out := &in.ActivatedAt, &out.ActivatedAt
+		*out = (*in).DeepCopy()
+	}
+	if in.RequesterHeartbeatTime != nil {
+		in, out := &in.RequesterHeartbeatTime, &out.RequesterHeartbeatTime
+		*out = (*in).DeepCopy()
+	}
+	if in.ObservedDuration != nil {
+		in, out := &in.ObservedDuration, &out.ObservedDuration
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.EstimatedCost != nil {
+		in, out := &in.EstimatedCost, &out.EstimatedCost
+		*out = new(CostEstimate)
+		**out = **in
+	}
+	if in.Decision != nil {
+		in, out := &in.Decision, &out.Decision
+		*out = new(PlacementDecision)
+		(*in).DeepCopyInto(*out)
+	}
 	in.LastUpdateTime.DeepCopyInto(&out.LastUpdateTime)
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
-		*out = make([]v1.Condition, len(*in))
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PlacementHistory != nil {
+		in, out := &in.PlacementHistory, &out.PlacementHistory
+		*out = make([]PlacementEvent, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -282,6 +948,21 @@ func (in *ReservationStatus) DeepCopy() *ReservationStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReservationToleration) DeepCopyInto(out *ReservationToleration) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReservationToleration.
+func (in *ReservationToleration) DeepCopy() *ReservationToleration {
+	if in == nil {
+		return nil
+	}
+	out := new(ReservationToleration)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ResourceMetrics) DeepCopyInto(out *ResourceMetrics) {
 	*out = *in
@@ -321,6 +1002,13 @@ func (in *ResourceQuantities) DeepCopyInto(out *ResourceQuantities) {
 		x := (*in).DeepCopy()
 		*out = &x
 	}
+	if in.Extended != nil {
+		in, out := &in.Extended, &out.Extended
+		*out = make(v1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceQuantities.
@@ -332,3 +1020,66 @@ func (in *ResourceQuantities) DeepCopy() *ResourceQuantities {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScoreBreakdown) DeepCopyInto(out *ScoreBreakdown) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScoreBreakdown.
+func (in *ScoreBreakdown) DeepCopy() *ScoreBreakdown {
+	if in == nil {
+		return nil
+	}
+	out := new(ScoreBreakdown)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SplitPolicy) DeepCopyInto(out *SplitPolicy) {
+	*out = *in
+	in.MinChunk.DeepCopyInto(&out.MinChunk)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SplitPolicy.
+func (in *SplitPolicy) DeepCopy() *SplitPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(SplitPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WeightedAntiAffinityTerm) DeepCopyInto(out *WeightedAntiAffinityTerm) {
+	*out = *in
+	in.Term.DeepCopyInto(&out.Term)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WeightedAntiAffinityTerm.
+func (in *WeightedAntiAffinityTerm) DeepCopy() *WeightedAntiAffinityTerm {
+	if in == nil {
+		return nil
+	}
+	out := new(WeightedAntiAffinityTerm)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WeightedClusterSelector) DeepCopyInto(out *WeightedClusterSelector) {
+	*out = *in
+	in.Selector.DeepCopyInto(&out.Selector)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WeightedClusterSelector.
+func (in *WeightedClusterSelector) DeepCopy() *WeightedClusterSelector {
+	if in == nil {
+		return nil
+	}
+	out := new(WeightedClusterSelector)
+	in.DeepCopyInto(out)
+	return out
+}
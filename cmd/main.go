@@ -18,8 +18,12 @@ package main
 
 import (
 	"crypto/tls"
+	"crypto/x509"
 	"flag"
+	"fmt"
 	"os"
+	"path/filepath"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
@@ -29,6 +33,7 @@ import (
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/certwatcher"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/filters"
@@ -36,7 +41,13 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/broker"
 	"github.com/mehdiazizian/liqo-resource-broker/internal/controller"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/identity"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/ingest"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/pricing"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/reservationapi"
+	webhookv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/internal/webhook/v1alpha1"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -61,6 +72,24 @@ func main() {
 	var probeAddr string
 	var secureMetrics bool
 	var enableHTTP2 bool
+	var scoringStrategy string
+	var baseCurrency, exchangeRates string
+	var costWeight, headroomWeight float64
+	var repairReservedDrift bool
+	var driftRepairGracePeriod time.Duration
+	var maxReservationLifetime time.Duration
+	var activationTimeout time.Duration
+	var requesterHeartbeatGrace time.Duration
+	var failoverGracePeriod time.Duration
+	var admissionPolicy string
+	var fairShareHalfLife time.Duration
+	var advertisementAddr, advertisementNamespace string
+	var advertisementCertPath, advertisementCertName, advertisementCertKey string
+	var secureAdvertisements bool
+	var agentClientCA, agentNamespace, agentTokenAudience string
+	var allowAnonymousAgents bool
+	var enableReservationAPI bool
+	var reservationAPINamespace string
 	var tlsOpts []func(*tls.Config)
 	flag.StringVar(&metricsAddr, "metrics-bind-address", "0", "The address the metrics endpoint binds to. "+
 		"Use :8443 for HTTPS or :8080 for HTTP, or leave as 0 to disable the metrics service.")
@@ -79,6 +108,71 @@ func main() {
 	flag.StringVar(&metricsCertKey, "metrics-cert-key", "tls.key", "The name of the metrics server key file.")
 	flag.BoolVar(&enableHTTP2, "enable-http2", false,
 		"If set, HTTP/2 will be enabled for the metrics and webhook servers")
+	flag.StringVar(&scoringStrategy, "scoring-strategy", broker.DefaultStrategy,
+		fmt.Sprintf("The default strategy used to rank candidate clusters. One of %v. "+
+			"Reservations can override it with spec.scoringStrategy.", broker.RegisteredStrategies()))
+	flag.StringVar(&baseCurrency, "base-currency", pricing.DefaultBaseCurrency,
+		"The currency advertised prices are converted to before comparing clusters.")
+	flag.StringVar(&exchangeRates, "exchange-rates", "",
+		"Comma-separated CURRENCY=RATE pairs giving the value of one unit of CURRENCY in the base currency, "+
+			"e.g. EUR=1.08,GBP=1.27.")
+	flag.Float64Var(&costWeight, "cost-weight", 1.0,
+		"Weight of the price term in the cost scoring strategy.")
+	flag.Float64Var(&headroomWeight, "headroom-weight", 0.0,
+		"Weight of the headroom term in the cost scoring strategy.")
+	flag.BoolVar(&repairReservedDrift, "repair-reserved-drift", false,
+		"If set, a cluster's Reserved counter is overwritten with the ledger computed from Reservation objects "+
+			"when they disagree for longer than --drift-repair-grace-period.")
+	flag.DurationVar(&driftRepairGracePeriod, "drift-repair-grace-period", 1*time.Minute,
+		"How long drift between Reserved and the reservation ledger must persist before it is repaired.")
+	flag.DurationVar(&maxReservationLifetime, "max-reservation-lifetime", 0,
+		"The maximum time a reservation may hold resources in total, renewals included. 0 means unlimited.")
+	flag.DurationVar(&activationTimeout, "activation-timeout", 0,
+		"How long a Reserved reservation may wait for the requester to set RequesterActive before its resources "+
+			"are released. 0 disables the timeout; reservations can override it with spec.activationTimeout.")
+	flag.DurationVar(&requesterHeartbeatGrace, "requester-heartbeat-grace", 0,
+		"How long an Active reservation survives without the requester renewing status.requesterHeartbeatTime "+
+			"before its resources are released. 0 disables heartbeats; "+
+			"reservations can override it with spec.heartbeatGracePeriod.")
+	flag.DurationVar(&failoverGracePeriod, "failover-grace-period", 5*time.Minute,
+		"How long a cluster must be stale before Reserved reservations with failoverPolicy Reschedule "+
+			"are moved to another cluster.")
+	flag.StringVar(&admissionPolicy, "admission-policy", broker.AdmissionFIFO,
+		"How queued reservations of equal priority are ordered when capacity frees up. "+
+			"fifo serves the one waiting longest, fair-share the requester with the lowest weighted recent usage.")
+	flag.DurationVar(&fairShareHalfLife, "fair-share-half-life", 1*time.Hour,
+		"How long it takes for past usage to count half as much under the fair-share admission policy. "+
+			"0 means usage never decays.")
+	flag.StringVar(&advertisementAddr, "advertisement-bind-address", "0",
+		"The address the advertisement ingestion endpoint binds to, e.g. :9443. "+
+			"Leave as 0 to disable it; agents then create ClusterAdvertisements through the Kubernetes API.")
+	flag.BoolVar(&secureAdvertisements, "advertisement-secure", true,
+		"If set, the advertisement ingestion endpoint is served via HTTPS. "+
+			"Use --advertisement-secure=false to use HTTP instead.")
+	flag.StringVar(&advertisementCertPath, "advertisement-cert-path", "",
+		"The directory that contains the advertisement ingestion server certificate.")
+	flag.StringVar(&advertisementCertName, "advertisement-cert-name", "tls.crt",
+		"The name of the advertisement ingestion server certificate file.")
+	flag.StringVar(&advertisementCertKey, "advertisement-cert-key", "tls.key",
+		"The name of the advertisement ingestion server key file.")
+	flag.StringVar(&advertisementNamespace, "advertisement-namespace", "default",
+		"The namespace ClusterAdvertisements of newly advertised clusters are created in.")
+	flag.StringVar(&agentClientCA, "agent-client-ca", "",
+		"PEM file of the CA that signs agent client certificates. If set, agents authenticate with a client "+
+			"certificate whose common name is their cluster ID.")
+	flag.StringVar(&agentNamespace, "agent-namespace", "",
+		"If set, agents may authenticate with a token of a ServiceAccount in this namespace "+
+			"whose name is their cluster ID.")
+	flag.StringVar(&agentTokenAudience, "agent-token-audience", "",
+		"The audience agent ServiceAccount tokens must be issued for. Empty accepts the API server's default audience.")
+	flag.BoolVar(&allowAnonymousAgents, "insecure-allow-anonymous", false,
+		"If set, the advertisement ingestion endpoint and the reservation API accept unauthenticated agents "+
+			"when neither --agent-client-ca nor --agent-namespace is set. Anyone who can reach them may then "+
+			"advertise any cluster and act for any requester.")
+	flag.BoolVar(&enableReservationAPI, "enable-reservation-api", false,
+		"If set, the advertisement ingestion endpoint also serves the reservation API under /v1alpha1/reservations.")
+	flag.StringVar(&reservationAPINamespace, "reservation-api-namespace", "default",
+		"The namespace reservations created through the reservation API live in.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -87,6 +181,23 @@ func main() {
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
+	exchange, err := pricing.NewExchangeTable(baseCurrency, exchangeRates)
+	if err != nil {
+		setupLog.Error(err, "invalid --exchange-rates")
+		os.Exit(1)
+	}
+	broker.RegisterScorer(broker.StrategyCost, broker.NewCostScorer(exchange, costWeight, headroomWeight))
+
+	if _, err := broker.LookupScorer(scoringStrategy); err != nil {
+		setupLog.Error(err, "invalid --scoring-strategy")
+		os.Exit(1)
+	}
+
+	if err := broker.ValidateAdmissionPolicy(admissionPolicy); err != nil {
+		setupLog.Error(err, "invalid --admission-policy")
+		os.Exit(1)
+	}
+
 	// if the enable-http2 flag is false (the default), http/2 should be disabled
 	// due to its vulnerabilities. More specifically, disabling http/2 will
 	// prevent from being vulnerable to the HTTP/2 Stream Cancellation and
@@ -178,22 +289,170 @@ func main() {
 		os.Exit(1)
 	}
 
+	decisionEngine := &broker.DecisionEngine{
+		Client:   mgr.GetClient(),
+		Strategy: scoringStrategy,
+		Exchange: exchange,
+	}
+
 	if err := (&controller.ClusterAdvertisementReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:                 mgr.GetClient(),
+		Scheme:                 mgr.GetScheme(),
+		DecisionEngine:         decisionEngine,
+		RepairReservedDrift:    repairReservedDrift,
+		DriftRepairGracePeriod: driftRepairGracePeriod,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "ClusterAdvertisement")
 		os.Exit(1)
 	}
 	if err := (&controller.ReservationReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:                  mgr.GetClient(),
+		Scheme:                  mgr.GetScheme(),
+		DecisionEngine:          decisionEngine,
+		MaxReservationLifetime:  maxReservationLifetime,
+		ActivationTimeout:       activationTimeout,
+		RequesterHeartbeatGrace: requesterHeartbeatGrace,
+		FailoverGracePeriod:     failoverGracePeriod,
+		AdmissionPolicy:         admissionPolicy,
+		FairShareHalfLife:       fairShareHalfLife,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Reservation")
 		os.Exit(1)
 	}
+	if err := (&controller.ReservationGroupReconciler{
+		Client:            mgr.GetClient(),
+		Scheme:            mgr.GetScheme(),
+		DecisionEngine:    decisionEngine,
+		FairShareHalfLife: fairShareHalfLife,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ReservationGroup")
+		os.Exit(1)
+	}
+	if err := (&controller.RequesterQuotaReconciler{
+		Client:         mgr.GetClient(),
+		Scheme:         mgr.GetScheme(),
+		DecisionEngine: decisionEngine,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "RequesterQuota")
+		os.Exit(1)
+	}
+	// nolint:goconst
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		if err := webhookv1alpha1.SetupClusterAdvertisementWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "ClusterAdvertisement")
+			os.Exit(1)
+		}
+		if err := webhookv1alpha1.SetupReservationWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "Reservation")
+			os.Exit(1)
+		}
+	}
 	// +kubebuilder:scaffold:builder
 
+	if enableReservationAPI && (advertisementAddr == "0" || advertisementAddr == "") {
+		setupLog.Error(fmt.Errorf("--enable-reservation-api requires --advertisement-bind-address"),
+			"unable to set up reservation API")
+		os.Exit(1)
+	}
+	if advertisementAddr != "0" && advertisementAddr != "" {
+		ingestServer := &ingest.Server{
+			Client:      mgr.GetClient(),
+			BindAddress: advertisementAddr,
+			Namespace:   advertisementNamespace,
+		}
+		if secureAdvertisements {
+			if len(advertisementCertPath) == 0 {
+				setupLog.Error(fmt.Errorf("--advertisement-cert-path is required unless --advertisement-secure=false"),
+					"unable to set up advertisement ingestion")
+				os.Exit(1)
+			}
+			setupLog.Info("Initializing advertisement certificate watcher using provided certificates",
+				"advertisement-cert-path", advertisementCertPath,
+				"advertisement-cert-name", advertisementCertName,
+				"advertisement-cert-key", advertisementCertKey)
+
+			advertisementCertWatcher, err := certwatcher.New(
+				filepath.Join(advertisementCertPath, advertisementCertName),
+				filepath.Join(advertisementCertPath, advertisementCertKey),
+			)
+			if err != nil {
+				setupLog.Error(err, "unable to initialize advertisement certificate watcher")
+				os.Exit(1)
+			}
+			if err := mgr.Add(advertisementCertWatcher); err != nil {
+				setupLog.Error(err, "unable to add advertisement certificate watcher to manager")
+				os.Exit(1)
+			}
+
+			tlsConfig := &tls.Config{
+				GetCertificate: advertisementCertWatcher.GetCertificate,
+				MinVersion:     tls.VersionTLS12,
+			}
+			for _, opt := range tlsOpts {
+				opt(tlsConfig)
+			}
+			ingestServer.TLSConfig = tlsConfig
+		}
+
+		authenticator := &identity.Authenticator{
+			Client:                  mgr.GetClient(),
+			ClientCertificates:      len(agentClientCA) > 0,
+			ServiceAccountNamespace: agentNamespace,
+		}
+		if len(agentTokenAudience) > 0 {
+			authenticator.Audiences = []string{agentTokenAudience}
+		}
+		if authenticator.ClientCertificates {
+			if ingestServer.TLSConfig == nil {
+				setupLog.Error(fmt.Errorf("--agent-client-ca requires --advertisement-secure"),
+					"unable to set up advertisement ingestion")
+				os.Exit(1)
+			}
+			caPEM, err := os.ReadFile(agentClientCA)
+			if err != nil {
+				setupLog.Error(err, "unable to read --agent-client-ca")
+				os.Exit(1)
+			}
+			clientCAs := x509.NewCertPool()
+			if !clientCAs.AppendCertsFromPEM(caPEM) {
+				setupLog.Error(fmt.Errorf("no certificates found in %s", agentClientCA), "invalid --agent-client-ca")
+				os.Exit(1)
+			}
+			ingestServer.TLSConfig.ClientCAs = clientCAs
+			// Agents using ServiceAccount tokens connect without a certificate
+			ingestServer.TLSConfig.ClientAuth = tls.RequireAndVerifyClientCert
+			if len(agentNamespace) > 0 {
+				ingestServer.TLSConfig.ClientAuth = tls.VerifyClientCertIfGiven
+			}
+		}
+		switch {
+		case authenticator.Enabled():
+			ingestServer.Authenticator = authenticator
+		case allowAnonymousAgents:
+			setupLog.Info("Advertisement ingestion accepts unauthenticated agents; " +
+				"set --agent-client-ca or --agent-namespace to bind agents to their cluster ID")
+		default:
+			setupLog.Error(fmt.Errorf("no agent authentication configured"),
+				"unable to set up advertisement ingestion; set --agent-client-ca or --agent-namespace, "+
+					"or --insecure-allow-anonymous to accept unauthenticated agents")
+			os.Exit(1)
+		}
+
+		if enableReservationAPI {
+			reservationAPI := &reservationapi.Handler{
+				Client:        mgr.GetClient(),
+				Namespace:     reservationAPINamespace,
+				Authenticator: ingestServer.Authenticator,
+			}
+			ingestServer.Routes = append(ingestServer.Routes, reservationAPI.Register)
+		}
+
+		if err := mgr.Add(ingestServer); err != nil {
+			setupLog.Error(err, "unable to set up advertisement ingestion")
+			os.Exit(1)
+		}
+	}
+
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
 		os.Exit(1)
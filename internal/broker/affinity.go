@@ -0,0 +1,205 @@
+package broker
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/resource"
+)
+
+// affinity evaluates a reservation's ClusterAffinity against candidate clusters.
+// A nil *affinity allows every cluster and scores them all zero.
+type affinity struct {
+	required      labels.Selector
+	preferred     []weightedSelector
+	antiRequired  []occupiedDomains
+	antiPreferred []weightedDomains
+	totalWeight   int32
+}
+
+type weightedSelector struct {
+	weight   int32
+	selector labels.Selector
+}
+
+// occupiedDomains are the values of a topology key that host a matching reservation.
+// An empty key means the domains are cluster IDs.
+type occupiedDomains struct {
+	topologyKey string
+	values      map[string]bool
+}
+
+type weightedDomains struct {
+	weight  int32
+	domains occupiedDomains
+}
+
+// ValidateAffinity checks that every selector of a ClusterAffinity can be parsed
+func ValidateAffinity(spec *brokerv1alpha1.ClusterAffinity) error {
+	if spec == nil {
+		return nil
+	}
+	if spec.RequiredClusterSelector != nil {
+		if _, err := metav1.LabelSelectorAsSelector(spec.RequiredClusterSelector); err != nil {
+			return fmt.Errorf("affinity.requiredClusterSelector: %w", err)
+		}
+	}
+	for i := range spec.PreferredClusterSelectors {
+		if _, err := metav1.LabelSelectorAsSelector(&spec.PreferredClusterSelectors[i].Selector); err != nil {
+			return fmt.Errorf("affinity.preferredClusterSelectors[%d]: %w", i, err)
+		}
+	}
+	for i := range spec.RequiredAntiAffinity {
+		if _, err := metav1.LabelSelectorAsSelector(&spec.RequiredAntiAffinity[i].ReservationSelector); err != nil {
+			return fmt.Errorf("affinity.requiredAntiAffinity[%d]: %w", i, err)
+		}
+	}
+	for i := range spec.PreferredAntiAffinity {
+		if _, err := metav1.LabelSelectorAsSelector(&spec.PreferredAntiAffinity[i].Term.ReservationSelector); err != nil {
+			return fmt.Errorf("affinity.preferredAntiAffinity[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// newAffinity prepares the affinity of a reservation. clusters and claims are
+// used to find the topology domains that already host matching reservations.
+func newAffinity(
+	spec *brokerv1alpha1.ReservationSpec,
+	clusters []brokerv1alpha1.ClusterAdvertisement,
+	claims *Claims,
+) (*affinity, error) {
+	if spec.Affinity == nil {
+		return nil, nil
+	}
+	if err := ValidateAffinity(spec.Affinity); err != nil {
+		return nil, err
+	}
+
+	a := &affinity{}
+	if spec.Affinity.RequiredClusterSelector != nil {
+		a.required, _ = metav1.LabelSelectorAsSelector(spec.Affinity.RequiredClusterSelector)
+	}
+	for _, term := range spec.Affinity.PreferredClusterSelectors {
+		selector, _ := metav1.LabelSelectorAsSelector(&term.Selector)
+		a.preferred = append(a.preferred, weightedSelector{weight: term.Weight, selector: selector})
+		a.totalWeight += term.Weight
+	}
+
+	clusterLabels := make(map[string]map[string]string, len(clusters))
+	for i := range clusters {
+		clusterLabels[clusters[i].Spec.ClusterID] = clusters[i].Spec.Labels
+	}
+	for i := range spec.Affinity.RequiredAntiAffinity {
+		a.antiRequired = append(a.antiRequired,
+			occupied(&spec.Affinity.RequiredAntiAffinity[i], clusterLabels, claims))
+	}
+	for i := range spec.Affinity.PreferredAntiAffinity {
+		term := &spec.Affinity.PreferredAntiAffinity[i]
+		a.antiPreferred = append(a.antiPreferred, weightedDomains{
+			weight:  term.Weight,
+			domains: occupied(&term.Term, clusterLabels, claims),
+		})
+		a.totalWeight += term.Weight
+	}
+	return a, nil
+}
+
+// occupied collects the topology domains hosting a reservation matched by term
+func occupied(
+	term *brokerv1alpha1.ReservationAntiAffinityTerm,
+	clusterLabels map[string]map[string]string,
+	claims *Claims,
+) occupiedDomains {
+	domains := occupiedDomains{topologyKey: term.TopologyKey, values: map[string]bool{}}
+	selector, _ := metav1.LabelSelectorAsSelector(&term.ReservationSelector)
+
+	for i := range claims.Reservations {
+		reservation := &claims.Reservations[i]
+		if !selector.Matches(labels.Set(reservation.Labels)) {
+			continue
+		}
+
+		var clusterIDs []string
+		switch {
+		case resource.HoldsResources(reservation.Status.Phase):
+			for _, holding := range resource.Holdings(reservation) {
+				clusterIDs = append(clusterIDs, holding.ClusterID)
+			}
+		case reservation.Status.Phase == brokerv1alpha1.ReservationPhaseScheduled:
+			clusterIDs = append(clusterIDs, reservation.Spec.TargetClusterID)
+		}
+
+		for _, clusterID := range clusterIDs {
+			if value, ok := domains.domainOf(clusterID, clusterLabels[clusterID]); ok {
+				domains.values[value] = true
+			}
+		}
+	}
+	return domains
+}
+
+// domainOf returns the topology domain of a cluster, if it is in one
+func (o occupiedDomains) domainOf(clusterID string, clusterLabels map[string]string) (string, bool) {
+	if o.topologyKey == "" {
+		return clusterID, clusterID != ""
+	}
+	value, ok := clusterLabels[o.topologyKey]
+	return value, ok
+}
+
+// contains reports whether a cluster lies in one of the occupied domains
+func (o occupiedDomains) contains(cluster *brokerv1alpha1.ClusterAdvertisement) bool {
+	value, ok := o.domainOf(cluster.Spec.ClusterID, cluster.Spec.Labels)
+	return ok && o.values[value]
+}
+
+// Allows reports whether the required terms let the reservation onto a cluster
+func (a *affinity) Allows(cluster *brokerv1alpha1.ClusterAdvertisement) bool {
+	return a.Rejection(cluster) == ""
+}
+
+// Rejection explains which required term keeps the reservation off a cluster,
+// or returns "" if none does
+func (a *affinity) Rejection(cluster *brokerv1alpha1.ClusterAdvertisement) string {
+	if a == nil {
+		return ""
+	}
+	if a.required != nil && !a.required.Matches(labels.Set(cluster.Spec.Labels)) {
+		return fmt.Sprintf("labels do not match required cluster selector %s", a.required.String())
+	}
+	for _, domains := range a.antiRequired {
+		if domains.contains(cluster) {
+			if domains.topologyKey == "" {
+				return "a reservation matched by required anti-affinity is already on the cluster"
+			}
+			return fmt.Sprintf("a reservation matched by required anti-affinity is already in %s=%s",
+				domains.topologyKey, cluster.Spec.Labels[domains.topologyKey])
+		}
+	}
+	return ""
+}
+
+// Score returns the preferred terms' verdict on a cluster in [-1, 1]: the weight
+// of the matching preferred selectors minus the weight of the matching preferred
+// anti-affinity terms, relative to the weight of all preferred terms
+func (a *affinity) Score(cluster *brokerv1alpha1.ClusterAdvertisement) float64 {
+	if a == nil || a.totalWeight == 0 {
+		return 0
+	}
+	var weight int32
+	for _, term := range a.preferred {
+		if term.selector.Matches(labels.Set(cluster.Spec.Labels)) {
+			weight += term.weight
+		}
+	}
+	for _, term := range a.antiPreferred {
+		if term.domains.contains(cluster) {
+			weight -= term.weight
+		}
+	}
+	return float64(weight) / float64(a.totalWeight)
+}
@@ -0,0 +1,174 @@
+package broker
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+)
+
+// affinityClusters are eu-gold, eu and us clusters and one without labels
+func affinityClusters() []brokerv1alpha1.ClusterAdvertisement {
+	cluster := func(name string, labels map[string]string) brokerv1alpha1.ClusterAdvertisement {
+		cluster := scoringCluster(name, "14", "56Gi", nil)
+		cluster.Spec.Labels = labels
+		cluster.Status.Active = true
+		return *cluster
+	}
+	return []brokerv1alpha1.ClusterAdvertisement{
+		cluster("eu-gold", map[string]string{"region": "eu", "tier": "gold"}),
+		cluster("eu", map[string]string{"region": "eu"}),
+		cluster("us", map[string]string{"region": "us"}),
+		cluster("unlabeled", nil),
+	}
+}
+
+// affinityClaims holds a database reservation on eu-gold, a Scheduled one on
+// us and a released one on unlabeled
+func affinityClaims() *Claims {
+	database := func(name, clusterID string, phase brokerv1alpha1.ReservationPhase) brokerv1alpha1.Reservation {
+		return brokerv1alpha1.Reservation{
+			ObjectMeta: metav1.ObjectMeta{Name: name, UID: types.UID(name), Labels: map[string]string{"app": "db"}},
+			Spec:       brokerv1alpha1.ReservationSpec{TargetClusterID: clusterID},
+			Status:     brokerv1alpha1.ReservationStatus{Phase: phase},
+		}
+	}
+	return &Claims{Reservations: []brokerv1alpha1.Reservation{
+		database("primary", "eu-gold", brokerv1alpha1.ReservationPhaseReserved),
+		database("replica", "us", brokerv1alpha1.ReservationPhaseScheduled),
+		database("old", "unlabeled", brokerv1alpha1.ReservationPhaseReleased),
+	}}
+}
+
+func TestAffinityRejection(t *testing.T) {
+	databases := metav1.LabelSelector{MatchLabels: map[string]string{"app": "db"}}
+
+	tests := []struct {
+		name    string
+		spec    *brokerv1alpha1.ClusterAffinity
+		allowed []string
+	}{
+		{name: "no affinity", allowed: []string{"eu-gold", "eu", "us", "unlabeled"}},
+		{
+			name: "required cluster selector",
+			spec: &brokerv1alpha1.ClusterAffinity{
+				RequiredClusterSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"region": "eu"}},
+			},
+			allowed: []string{"eu-gold", "eu"},
+		},
+		{
+			name: "anti-affinity to clusters hosting a match, scheduled ones included",
+			spec: &brokerv1alpha1.ClusterAffinity{
+				RequiredAntiAffinity: []brokerv1alpha1.ReservationAntiAffinityTerm{{ReservationSelector: databases}},
+			},
+			allowed: []string{"eu", "unlabeled"},
+		},
+		{
+			name: "anti-affinity to regions hosting a match",
+			spec: &brokerv1alpha1.ClusterAffinity{
+				RequiredAntiAffinity: []brokerv1alpha1.ReservationAntiAffinityTerm{{
+					ReservationSelector: databases,
+					TopologyKey:         "region",
+				}},
+			},
+			allowed: []string{"unlabeled"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clusters := affinityClusters()
+			a, err := newAffinity(&brokerv1alpha1.ReservationSpec{Affinity: tt.spec}, clusters, affinityClaims())
+			if err != nil {
+				t.Fatal(err)
+			}
+			var allowed []string
+			for i := range clusters {
+				if a.Allows(&clusters[i]) {
+					allowed = append(allowed, clusters[i].Name)
+				} else if a.Rejection(&clusters[i]) == "" {
+					t.Errorf("%s is not allowed but has no rejection", clusters[i].Name)
+				}
+			}
+			if len(allowed) != len(tt.allowed) {
+				t.Fatalf("allowed = %v, want %v", allowed, tt.allowed)
+			}
+			for i := range allowed {
+				if allowed[i] != tt.allowed[i] {
+					t.Errorf("allowed = %v, want %v", allowed, tt.allowed)
+				}
+			}
+		})
+	}
+}
+
+func TestAffinityScore(t *testing.T) {
+	spec := &brokerv1alpha1.ReservationSpec{Affinity: &brokerv1alpha1.ClusterAffinity{
+		PreferredClusterSelectors: []brokerv1alpha1.WeightedClusterSelector{{
+			Weight:   3,
+			Selector: metav1.LabelSelector{MatchLabels: map[string]string{"tier": "gold"}},
+		}},
+		PreferredAntiAffinity: []brokerv1alpha1.WeightedAntiAffinityTerm{{
+			Weight: 1,
+			Term: brokerv1alpha1.ReservationAntiAffinityTerm{
+				ReservationSelector: metav1.LabelSelector{MatchLabels: map[string]string{"app": "db"}},
+				TopologyKey:         "region",
+			},
+		}},
+	}}
+	clusters := affinityClusters()
+	a, err := newAffinity(spec, clusters, affinityClaims())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]float64{"eu-gold": 0.5, "eu": -0.25, "us": -0.25, "unlabeled": 0}
+	for i := range clusters {
+		if got := a.Score(&clusters[i]); got != want[clusters[i].Name] {
+			t.Errorf("Score(%s) = %v, want %v", clusters[i].Name, got, want[clusters[i].Name])
+		}
+	}
+
+	var none *affinity
+	if got := none.Score(&clusters[0]); got != 0 {
+		t.Errorf("Score() without affinity = %v, want 0", got)
+	}
+}
+
+func TestSelectBestClusterAffinity(t *testing.T) {
+	spec := &brokerv1alpha1.ReservationSpec{
+		RequesterID: "requester",
+		RequestedResources: brokerv1alpha1.RequestedResourceQuantities{
+			CPU:    resource.MustParse("2"),
+			Memory: resource.MustParse("4Gi"),
+		},
+		Affinity: &brokerv1alpha1.ClusterAffinity{
+			RequiredClusterSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"region": "eu"}},
+			PreferredClusterSelectors: []brokerv1alpha1.WeightedClusterSelector{{
+				Weight:   1,
+				Selector: metav1.LabelSelector{MatchLabels: map[string]string{"tier": "gold"}},
+			}},
+		},
+	}
+	snapshot := &Snapshot{Clusters: affinityClusters(), Claims: &Claims{}}
+
+	selected, decision, err := (&DecisionEngine{}).SelectBestClusterIn(snapshot, spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if selected.Name != "eu-gold" {
+		t.Errorf("selected %s, want eu-gold", selected.Name)
+	}
+	for _, candidate := range decision.Candidates {
+		wantFiltered := candidate.Cluster.Name == "us" || candidate.Cluster.Name == "unlabeled"
+		if filtered := candidate.FilteredBy == brokerv1alpha1.PlacementFilterAffinity; filtered != wantFiltered {
+			t.Errorf("%s filtered by %q, want filtered by affinity: %v", candidate.Cluster.Name, candidate.FilteredBy, wantFiltered)
+		}
+		if candidate.Cluster.Name == "eu-gold" && candidate.Score.Affinity != 1 {
+			t.Errorf("affinity score of eu-gold = %v, want 1", candidate.Score.Affinity)
+		}
+	}
+}
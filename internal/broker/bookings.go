@@ -0,0 +1,140 @@
+package broker
+
+import (
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/resource"
+)
+
+// RecordBooking records on the cluster the window a reservation books, replacing
+// any booking it recorded before. The caller updates the ClusterAdvertisement,
+// so that concurrent bookings on the same cluster conflict and are re-checked.
+func RecordBooking(
+	cluster *brokerv1alpha1.ClusterAdvertisement,
+	reservation *brokerv1alpha1.Reservation,
+	now time.Time,
+) {
+	start, end := RequestedWindow(&reservation.Spec, now)
+	booking := brokerv1alpha1.ClusterBooking{
+		ReservationUID: reservation.UID,
+		Reservation:    reservation.Namespace + "/" + reservation.Name,
+		StartTime:      metav1.NewTime(start),
+		Resources:      reservation.Spec.RequestedResources,
+	}
+	if !end.IsZero() {
+		endTime := metav1.NewTime(end)
+		booking.EndTime = &endTime
+	}
+
+	DropBooking(cluster, reservation.UID)
+	cluster.Spec.Bookings = append(cluster.Spec.Bookings, booking)
+}
+
+// DropBooking removes the booking of a reservation from the cluster and
+// reports whether there was one
+func DropBooking(cluster *brokerv1alpha1.ClusterAdvertisement, uid types.UID) bool {
+	var kept []brokerv1alpha1.ClusterBooking
+	for _, booking := range cluster.Spec.Bookings {
+		if booking.ReservationUID != uid {
+			kept = append(kept, booking)
+		}
+	}
+	dropped := len(kept) != len(cluster.Spec.Bookings)
+	cluster.Spec.Bookings = kept
+	return dropped
+}
+
+// PruneBookings removes the bookings that no longer claim capacity: their window
+// has ended, or their reservation is gone, holds resources, has ended or is
+// Scheduled on another cluster. It reports whether anything was removed.
+func PruneBookings(cluster *brokerv1alpha1.ClusterAdvertisement, claims *Claims, now time.Time) bool {
+	reservations := reservationsByUID(claims)
+	var kept []brokerv1alpha1.ClusterBooking
+	for _, booking := range cluster.Spec.Bookings {
+		if recordedBookingPending(cluster, booking, reservations[booking.ReservationUID], now) {
+			kept = append(kept, booking)
+		}
+	}
+	pruned := len(kept) != len(cluster.Spec.Bookings)
+	cluster.Spec.Bookings = kept
+	return pruned
+}
+
+// recordedBookings are the bookings recorded on the cluster that the claims do
+// not account for yet, typically because the reservation's Scheduled status has
+// not reached the cache. The reservation with UID self is left out.
+func recordedBookings(
+	cluster *brokerv1alpha1.ClusterAdvertisement,
+	claims *Claims,
+	self types.UID,
+	now time.Time,
+) []Booking {
+	reservations := reservationsByUID(claims)
+	var bookings []Booking
+	for _, recorded := range cluster.Spec.Bookings {
+		if self != "" && recorded.ReservationUID == self {
+			continue
+		}
+		reservation := reservations[recorded.ReservationUID]
+		if !recordedBookingPending(cluster, recorded, reservation, now) ||
+			reservation.Status.Phase == brokerv1alpha1.ReservationPhaseScheduled {
+			// Scheduled reservations are counted by ClusterBookings
+			continue
+		}
+
+		booking := Booking{Start: recorded.StartTime.Time, Resources: resource.RequestedList(recorded.Resources)}
+		if recorded.EndTime != nil {
+			booking.End = recorded.EndTime.Time
+		}
+		if booking.Start.Before(now) {
+			booking.Start = now
+		}
+		bookings = append(bookings, booking)
+	}
+	return bookings
+}
+
+// recordedBookingPending reports whether a recorded booking still claims
+// capacity: its window has not ended and its reservation has not moved on.
+// A reservation still Pending or Queued in the cache is being booked right now.
+func recordedBookingPending(
+	cluster *brokerv1alpha1.ClusterAdvertisement,
+	booking brokerv1alpha1.ClusterBooking,
+	reservation *brokerv1alpha1.Reservation,
+	now time.Time,
+) bool {
+	if reservation == nil || (booking.EndTime != nil && !now.Before(booking.EndTime.Time)) {
+		return false
+	}
+	switch reservation.Status.Phase {
+	case "", brokerv1alpha1.ReservationPhasePending, brokerv1alpha1.ReservationPhaseQueued:
+		return true
+	case brokerv1alpha1.ReservationPhaseScheduled:
+		return reservation.Spec.TargetClusterID == cluster.Spec.ClusterID
+	}
+	return false
+}
+
+// clusterTimeline is everything booked on a cluster: the bookings derived from
+// the claims and the recorded bookings the claims do not account for yet
+func clusterTimeline(
+	cluster *brokerv1alpha1.ClusterAdvertisement,
+	claims *Claims,
+	self types.UID,
+	now time.Time,
+) []Booking {
+	return append(ClusterBookings(cluster.Spec.ClusterID, claims, self, now),
+		recordedBookings(cluster, claims, self, now)...)
+}
+
+func reservationsByUID(claims *Claims) map[types.UID]*brokerv1alpha1.Reservation {
+	reservations := make(map[types.UID]*brokerv1alpha1.Reservation, len(claims.Reservations))
+	for i := range claims.Reservations {
+		reservations[claims.Reservations[i].UID] = &claims.Reservations[i]
+	}
+	return reservations
+}
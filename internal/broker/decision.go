@@ -4,118 +4,200 @@ import (
 	"context"
 	"fmt"
 	"strconv"
+	"time"
 
 	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
-	"k8s.io/apimachinery/pkg/api/resource"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/pricing"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/resource"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 // DecisionEngine selects the best cluster for resource allocation
 type DecisionEngine struct {
 	Client client.Client
+
+	// Strategy is the name of the scoring strategy used when a reservation does
+	// not select one itself. Defaults to DefaultStrategy.
+	Strategy string
+
+	// Exchange converts advertised prices to the broker's base currency for cost estimates
+	Exchange *pricing.ExchangeTable
 }
 
-// SelectBestCluster finds the most suitable cluster based on requested resources
+// SelectBestCluster finds the most suitable cluster based on requested resources.
+// The decision explains the choice, or why no cluster fits; it is nil only if
+// the clusters could not be evaluated at all.
 func (d *DecisionEngine) SelectBestCluster(
 	ctx context.Context,
-	requesterID string,
-	requestedCPU, requestedMemory resource.Quantity,
-	priority int32,
-) (*brokerv1alpha1.ClusterAdvertisement, error) {
-
-	// List all cluster advertisements
-	advList := &brokerv1alpha1.ClusterAdvertisementList{}
-	if err := d.Client.List(ctx, advList); err != nil {
-		return nil, fmt.Errorf("failed to list cluster advertisements: %w", err)
+	spec *brokerv1alpha1.ReservationSpec,
+) (*brokerv1alpha1.ClusterAdvertisement, *Decision, error) {
+	snapshot, err := d.TakeSnapshot(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return d.SelectBestClusterIn(snapshot, spec)
+}
+
+// SelectBestClusterIn is SelectBestCluster evaluated against a snapshot
+func (d *DecisionEngine) SelectBestClusterIn(
+	snapshot *Snapshot,
+	spec *brokerv1alpha1.ReservationSpec,
+) (*brokerv1alpha1.ClusterAdvertisement, *Decision, error) {
+
+	scorer, err := d.scorerFor(spec)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	if len(advList.Items) == 0 {
-		return nil, fmt.Errorf("no clusters available")
+	if len(snapshot.Clusters) == 0 {
+		return nil, nil, fmt.Errorf("no clusters available")
 	}
 
-	var bestCluster *brokerv1alpha1.ClusterAdvertisement
-	var bestScore float64 = -1
+	// Reservations and groups are needed to check the clusters' booking timelines
+	claims := snapshot.Claims
+	now := time.Now()
+
+	clusterAffinity, err := newAffinity(spec, snapshot.Clusters, claims)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	quotas := snapshot.RequesterQuotas(spec.RequesterID)
+	usage := RequesterUsage(spec.RequesterID, claims)
+
+	decision := &Decision{Strategy: d.strategyName(spec)}
+	candidates := make([]*brokerv1alpha1.ClusterAdvertisement, 0, len(snapshot.Clusters))
+	for i := range snapshot.Clusters {
+		cluster := &snapshot.Clusters[i]
 
-	for i := range advList.Items {
-		cluster := &advList.Items[i]
+		// Skip the requester's own cluster and inactive clusters
+		if filter, detail := ineligibility(cluster, spec); filter != "" {
+			decision.filter(cluster, filter, detail)
+			continue
+		}
 
-		// Skip if it's the requester's own cluster
-		if cluster.Spec.ClusterID == requesterID {
+		// Skip clusters the reservation's affinity rules out
+		if rejection := clusterAffinity.Rejection(cluster); rejection != "" {
+			decision.filter(cluster, brokerv1alpha1.PlacementFilterAffinity, rejection)
 			continue
 		}
 
-		// Skip inactive clusters
-		if !cluster.Status.Active {
+		// Skip clusters where the requester's quota is used up
+		if exceeded := exceededClusterQuota(quotas, usage, cluster.Spec.ClusterID, spec.RequestedResources); exceeded != "" {
+			decision.filter(cluster, brokerv1alpha1.PlacementFilterQuota, exceeded)
 			continue
 		}
 
-		// Check if cluster has enough resources
-		if !d.hasEnoughResources(cluster, requestedCPU, requestedMemory) {
+		// Check if cluster has enough resources over the requested window
+		if !d.CanHost(cluster, spec, claims, "", now) {
+			decision.filter(cluster, brokerv1alpha1.PlacementFilterInsufficientResources, shortfall(cluster, spec, now))
 			continue
 		}
 
-		// Calculate score
-		score := d.calculateScore(cluster, requestedCPU, requestedMemory, priority)
+		candidates = append(candidates, cluster)
+	}
+
+	if len(candidates) == 0 {
+		return nil, decision, fmt.Errorf("no suitable cluster found for requested resources")
+	}
+
+	var bestScore float64
 
-		if score > bestScore {
-			bestScore = score
-			bestCluster = cluster
+	priorityBonus := float64(spec.Priority) * 0.01
+	for i, score := range scorer.Score(spec, candidates) {
+		terms := ScoreTerms{
+			Strategy:     score,
+			Priority:     priorityBonus,
+			Affinity:     clusterAffinity.Score(candidates[i]),
+			TaintPenalty: taintPenalty(candidates[i], spec),
+		}
+		decision.Candidates = append(decision.Candidates, Candidate{
+			Cluster: candidates[i],
+			Score:   terms,
+			Cost:    d.EstimateCost(candidates[i], spec),
+		})
+		if decision.Selected == nil || terms.Total() > bestScore {
+			bestScore = terms.Total()
+			decision.Selected = candidates[i]
 		}
 	}
 
-	if bestCluster == nil {
-		return nil, fmt.Errorf("no suitable cluster found for requested resources")
+	return decision.Selected, decision, nil
+}
+
+// scorerFor resolves the scoring strategy for a request: the reservation's own
+// choice wins over the broker-wide default
+func (d *DecisionEngine) scorerFor(spec *brokerv1alpha1.ReservationSpec) (Scorer, error) {
+	return LookupScorer(d.strategyName(spec))
+}
+
+// strategyName is the name of the scoring strategy that ranks clusters for a request
+func (d *DecisionEngine) strategyName(spec *brokerv1alpha1.ReservationSpec) string {
+	name := spec.ScoringStrategy
+	if name == "" {
+		name = d.Strategy
+	}
+	if name == "" {
+		name = DefaultStrategy
 	}
+	return name
+}
 
-	return bestCluster, nil
+// isEligible reports whether a cluster may host the reservation at all:
+// it must be active, must not be the requester's own cluster and must not
+// carry a NoReserve taint the reservation does not tolerate
+func (d *DecisionEngine) isEligible(
+	cluster *brokerv1alpha1.ClusterAdvertisement,
+	spec *brokerv1alpha1.ReservationSpec,
+) bool {
+	filter, _ := ineligibility(cluster, spec)
+	return filter == ""
 }
 
 // hasEnoughResources checks if cluster has sufficient available resources
+// in every requested dimension (CPU, memory, GPU, storage)
 func (d *DecisionEngine) hasEnoughResources(
 	cluster *brokerv1alpha1.ClusterAdvertisement,
-	requestedCPU, requestedMemory resource.Quantity,
+	requested brokerv1alpha1.RequestedResourceQuantities,
 ) bool {
-	availableCPU := cluster.Spec.Resources.Available.CPU
-	availableMemory := cluster.Spec.Resources.Available.Memory
-
-	return availableCPU.Cmp(requestedCPU) >= 0 && availableMemory.Cmp(requestedMemory) >= 0
+	return resource.CanReserve(cluster, requested)
 }
 
-// calculateScore computes a score for the cluster based on availability
-// Higher score = better choice
-func (d *DecisionEngine) calculateScore(
+// EstimateCost projects the hourly and total cost of a reservation on a cluster.
+// The total covers spec.duration, or the requested window up to spec.endTime.
+// It returns nil if the cluster does not advertise usable prices.
+func (d *DecisionEngine) EstimateCost(
 	cluster *brokerv1alpha1.ClusterAdvertisement,
-	requestedCPU, requestedMemory resource.Quantity,
-	priority int32,
-) float64 {
-	// Calculate CPU utilization after reservation (0-1)
-	allocatableCPU := cluster.Spec.Resources.Allocatable.CPU.AsApproximateFloat64()
-	availableCPU := cluster.Spec.Resources.Available.CPU.AsApproximateFloat64()
-	requestedCPUFloat := requestedCPU.AsApproximateFloat64()
-
-	cpuUtilization := 1.0 - ((availableCPU - requestedCPUFloat) / allocatableCPU)
-
-	// Calculate Memory utilization after reservation (0-1)
-	allocatableMemory := cluster.Spec.Resources.Allocatable.Memory.AsApproximateFloat64()
-	availableMemory := cluster.Spec.Resources.Available.Memory.AsApproximateFloat64()
-	requestedMemoryFloat := requestedMemory.AsApproximateFloat64()
-
-	memoryUtilization := 1.0 - ((availableMemory - requestedMemoryFloat) / allocatableMemory)
-
-	// Balanced score: prefer clusters with lower utilization (more headroom)
-	// Score is higher when utilization is lower
-	score := (1.0 - cpuUtilization*0.5) + (1.0 - memoryUtilization*0.5)
+	spec *brokerv1alpha1.ReservationSpec,
+) *brokerv1alpha1.CostEstimate {
+	if cluster.Spec.Cost == nil || d.Exchange == nil {
+		return nil
+	}
 
-	// If cost info is available, factor it in (lower cost = higher score)
-	if cluster.Spec.Cost != nil {
-		// For now, we just ensure cost doesn't make score negative
-		// In production, you'd normalize cost and subtract it
-		score = score * 0.9 // Slight penalty if cost exists but not optimized
+	// Reservations bounded by endTime have no duration of their own
+	var duration time.Duration
+	switch {
+	case spec.EndTime != nil:
+		if start, end := RequestedWindow(spec, time.Now()); end.After(start) {
+			duration = end.Sub(start)
+		}
+	case spec.Duration != nil:
+		duration = spec.Duration.Duration
 	}
 
-	priorityBonus := float64(priority) * 0.01
+	estimate, err := d.Exchange.Estimate(cluster.Spec.Cost, spec.RequestedResources, duration)
+	if err != nil {
+		return nil
+	}
 
-	return score + priorityBonus
+	result := &brokerv1alpha1.CostEstimate{
+		HourlyCost: pricing.FormatAmount(estimate.Hourly),
+		Currency:   estimate.Currency,
+	}
+	if estimate.Bounded {
+		result.TotalCost = pricing.FormatAmount(estimate.Total)
+	}
+	return result
 }
 
 // UpdateClusterScore updates the score field in the cluster advertisement status
@@ -0,0 +1,73 @@
+package broker
+
+import (
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/pricing"
+)
+
+func TestEstimateCost(t *testing.T) {
+	exchange, err := pricing.NewExchangeTable("USD", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine := &DecisionEngine{Exchange: exchange}
+	cluster := &brokerv1alpha1.ClusterAdvertisement{
+		Spec: brokerv1alpha1.ClusterAdvertisementSpec{
+			Cost: &brokerv1alpha1.CostInfo{CPUCost: "1", Currency: "USD"},
+		},
+	}
+	requested := brokerv1alpha1.RequestedResourceQuantities{
+		CPU:    resource.MustParse("2"),
+		Memory: resource.MustParse("1Gi"),
+	}
+	now := time.Now()
+
+	tests := []struct {
+		name      string
+		spec      brokerv1alpha1.ReservationSpec
+		wantTotal string
+	}{
+		{
+			name:      "duration",
+			spec:      brokerv1alpha1.ReservationSpec{Duration: &metav1.Duration{Duration: 3 * time.Hour}},
+			wantTotal: "6.0000",
+		},
+		{
+			name: "window up to endTime",
+			spec: brokerv1alpha1.ReservationSpec{
+				StartTime: &metav1.Time{Time: now.Add(time.Hour)},
+				EndTime:   &metav1.Time{Time: now.Add(5 * time.Hour)},
+			},
+			wantTotal: "8.0000",
+		},
+		{name: "open-ended", spec: brokerv1alpha1.ReservationSpec{}},
+		{
+			name:      "endTime already passed",
+			spec:      brokerv1alpha1.ReservationSpec{EndTime: &metav1.Time{Time: now.Add(-time.Hour)}},
+			wantTotal: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := tt.spec
+			spec.RequestedResources = requested
+			estimate := engine.EstimateCost(cluster, &spec)
+			if estimate == nil {
+				t.Fatal("EstimateCost() = nil")
+			}
+			if estimate.HourlyCost != "2.0000" {
+				t.Errorf("HourlyCost = %q, want %q", estimate.HourlyCost, "2.0000")
+			}
+			if estimate.TotalCost != tt.wantTotal {
+				t.Errorf("TotalCost = %q, want %q", estimate.TotalCost, tt.wantTotal)
+			}
+		})
+	}
+}
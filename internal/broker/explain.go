@@ -0,0 +1,179 @@
+package broker
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/resource"
+)
+
+// maxDecisionCandidates bounds the candidates kept in a reservation's status
+const maxDecisionCandidates = 10
+
+// Decision records how SelectBestCluster chose a cluster: every cluster it
+// considered, why the ones that cannot host the reservation were filtered out,
+// and what the others scored
+type Decision struct {
+	// Strategy is the name of the scoring strategy that ranked the candidates
+	Strategy string
+
+	// Selected is the chosen cluster, nil if no cluster passed every filter
+	Selected *brokerv1alpha1.ClusterAdvertisement
+
+	// Candidates are all clusters, in the order they were listed
+	Candidates []Candidate
+}
+
+// Candidate is one cluster considered for a reservation
+type Candidate struct {
+	Cluster *brokerv1alpha1.ClusterAdvertisement
+
+	// FilteredBy is why the cluster cannot host the reservation, empty if it was scored
+	FilteredBy brokerv1alpha1.PlacementFilter
+
+	// Detail explains the filter
+	Detail string
+
+	// Score is set for clusters that passed every filter
+	Score ScoreTerms
+
+	// Cost is the projected cost on a cluster that passed every filter, nil if it
+	// does not advertise prices
+	Cost *brokerv1alpha1.CostEstimate
+}
+
+// ScoreTerms are the parts of a candidate's score
+type ScoreTerms struct {
+	Strategy     float64
+	Priority     float64
+	Affinity     float64
+	TaintPenalty float64
+}
+
+// Total is the score candidates are ranked by
+func (s ScoreTerms) Total() float64 {
+	return s.Strategy + s.Priority + s.Affinity - s.TaintPenalty
+}
+
+// filter records that a cluster was ruled out
+func (d *Decision) filter(cluster *brokerv1alpha1.ClusterAdvertisement, by brokerv1alpha1.PlacementFilter, detail string) {
+	d.Candidates = append(d.Candidates, Candidate{Cluster: cluster, FilteredBy: by, Detail: detail})
+}
+
+// Summary condenses the decision for a reservation's status: the selected
+// cluster first, then the other scored clusters best first, then the filtered
+// ones by cluster ID, keeping at most maxDecisionCandidates
+func (d *Decision) Summary() *brokerv1alpha1.PlacementDecision {
+	candidates := make([]Candidate, len(d.Candidates))
+	copy(candidates, d.Candidates)
+	rank := func(c Candidate) int {
+		switch {
+		case d.Selected != nil && c.Cluster.Spec.ClusterID == d.Selected.Spec.ClusterID:
+			return 0
+		case c.FilteredBy == "":
+			return 1
+		}
+		return 2
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if rank(a) != rank(b) {
+			return rank(a) < rank(b)
+		}
+		if a.FilteredBy == "" && a.Score.Total() != b.Score.Total() {
+			return a.Score.Total() > b.Score.Total()
+		}
+		return a.Cluster.Spec.ClusterID < b.Cluster.Spec.ClusterID
+	})
+
+	summary := &brokerv1alpha1.PlacementDecision{Strategy: d.Strategy}
+	if d.Selected != nil {
+		summary.SelectedClusterID = d.Selected.Spec.ClusterID
+	}
+	if len(candidates) > maxDecisionCandidates {
+		summary.OmittedCandidates = int32(len(candidates) - maxDecisionCandidates)
+		candidates = candidates[:maxDecisionCandidates]
+	}
+	for _, c := range candidates {
+		candidate := brokerv1alpha1.PlacementCandidate{
+			ClusterID:  c.Cluster.Spec.ClusterID,
+			FilteredBy: c.FilteredBy,
+			Detail:     c.Detail,
+		}
+		if c.FilteredBy == "" {
+			candidate.Score = formatScore(c.Score.Total())
+			candidate.ScoreBreakdown = &brokerv1alpha1.ScoreBreakdown{
+				Strategy:     formatScore(c.Score.Strategy),
+				Priority:     formatNonZero(c.Score.Priority),
+				Affinity:     formatNonZero(c.Score.Affinity),
+				TaintPenalty: formatNonZero(c.Score.TaintPenalty),
+			}
+			candidate.EstimatedCost = c.Cost
+		}
+		summary.Candidates = append(summary.Candidates, candidate)
+	}
+	return summary
+}
+
+// ineligibility explains why a cluster may not host the reservation at all,
+// or returns an empty filter if it may
+func ineligibility(
+	cluster *brokerv1alpha1.ClusterAdvertisement,
+	spec *brokerv1alpha1.ReservationSpec,
+) (brokerv1alpha1.PlacementFilter, string) {
+	if cluster.Spec.ClusterID == spec.RequesterID {
+		return brokerv1alpha1.PlacementFilterOwnCluster, "the requester's own cluster"
+	}
+	if !cluster.Status.Active {
+		return brokerv1alpha1.PlacementFilterInactive, "advertisement is stale"
+	}
+	if taint := UntoleratedTaint(cluster, spec.Tolerations, brokerv1alpha1.TaintEffectNoReserve); taint != nil {
+		return brokerv1alpha1.PlacementFilterTainted, "untolerated taint " + FormatTaint(*taint)
+	}
+	return "", ""
+}
+
+// shortfall explains why a cluster cannot host the requested resources: the
+// resources available in smaller quantities than requested, or otherwise the
+// bookings that fill the requested window
+func shortfall(
+	cluster *brokerv1alpha1.ClusterAdvertisement,
+	spec *brokerv1alpha1.ReservationSpec,
+	now time.Time,
+) string {
+	if start, _ := RequestedWindow(spec, now); !start.After(now) {
+		available := resource.ToList(cluster.Spec.Resources.Available)
+		requested := resource.RequestedList(spec.RequestedResources)
+		var short []string
+		for _, name := range resource.SortedNames(requested) {
+			quantity := requested[name]
+			if quantity.Sign() <= 0 {
+				continue
+			}
+			if have := available[name]; have.Cmp(quantity) < 0 {
+				short = append(short, fmt.Sprintf("%s requested %s, available %s", name, quantity.String(), have.String()))
+			}
+		}
+		if len(short) > 0 {
+			return strings.Join(short, "; ")
+		}
+	}
+	return "other bookings leave too little capacity during the requested window"
+}
+
+// formatScore renders a score for status
+func formatScore(score float64) string {
+	return strconv.FormatFloat(score, 'f', 3, 64)
+}
+
+// formatNonZero renders a score term, omitting terms that do not contribute
+func formatNonZero(score float64) string {
+	if score == 0 {
+		return ""
+	}
+	return formatScore(score)
+}
@@ -0,0 +1,129 @@
+package broker
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+)
+
+func TestSelectBestClusterDecision(t *testing.T) {
+	spec := &brokerv1alpha1.ReservationSpec{
+		RequesterID:     "requester",
+		ScoringStrategy: StrategySpread,
+		RequestedResources: brokerv1alpha1.RequestedResourceQuantities{
+			CPU:    resource.MustParse("2"),
+			Memory: resource.MustParse("8Gi"),
+		},
+	}
+	active := func(name, cpu, memory string) brokerv1alpha1.ClusterAdvertisement {
+		cluster := scoringCluster(name, cpu, memory, nil)
+		cluster.Status.Active = true
+		return *cluster
+	}
+	snapshot := &Snapshot{
+		Clusters: []brokerv1alpha1.ClusterAdvertisement{
+			active("tight", "4", "16Gi"),
+			active("requester", "14", "56Gi"),
+			*scoringCluster("stale", "14", "56Gi", nil),
+			active("full", "1", "56Gi"),
+			active("limited", "14", "56Gi"),
+			active("roomy", "14", "56Gi"),
+		},
+		Claims: &Claims{},
+		Quotas: []brokerv1alpha1.RequesterQuota{{
+			ObjectMeta: metav1.ObjectMeta{Name: "requester", Namespace: "default"},
+			Spec: brokerv1alpha1.RequesterQuotaSpec{
+				RequesterID: "requester",
+				Clusters: []brokerv1alpha1.ClusterQuota{{
+					ClusterID: "limited",
+					Hard:      brokerv1alpha1.QuotaResources{CPU: ptrTo(resource.MustParse("1"))},
+				}},
+			},
+		}},
+	}
+
+	selected, decision, err := (&DecisionEngine{}).SelectBestClusterIn(snapshot, spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if selected.Name != "roomy" {
+		t.Errorf("selected %s, want roomy", selected.Name)
+	}
+	if decision.Strategy != StrategySpread {
+		t.Errorf("Strategy = %q, want %q", decision.Strategy, StrategySpread)
+	}
+	if len(decision.Candidates) != len(snapshot.Clusters) {
+		t.Fatalf("decision has %d candidates, want one per cluster (%d)", len(decision.Candidates), len(snapshot.Clusters))
+	}
+
+	wantFilters := map[string]brokerv1alpha1.PlacementFilter{
+		"requester": brokerv1alpha1.PlacementFilterOwnCluster,
+		"stale":     brokerv1alpha1.PlacementFilterInactive,
+		"full":      brokerv1alpha1.PlacementFilterInsufficientResources,
+		"limited":   brokerv1alpha1.PlacementFilterQuota,
+	}
+	for _, candidate := range decision.Candidates {
+		name := candidate.Cluster.Name
+		if candidate.FilteredBy != wantFilters[name] {
+			t.Errorf("%s filtered by %q, want %q", name, candidate.FilteredBy, wantFilters[name])
+		}
+		if candidate.FilteredBy != "" && candidate.Detail == "" {
+			t.Errorf("%s filtered without detail", name)
+		}
+		if candidate.FilteredBy == "" && candidate.Score.Strategy <= 0 {
+			t.Errorf("%s scored %v, want a positive strategy score", name, candidate.Score.Strategy)
+		}
+	}
+	for _, candidate := range decision.Candidates {
+		if candidate.Cluster.Name == "full" && !strings.Contains(candidate.Detail, "cpu requested 2, available 1") {
+			t.Errorf("detail of full = %q, want the CPU shortfall", candidate.Detail)
+		}
+	}
+
+	// The selected cluster comes first, then the other scored one, then the
+	// filtered ones by cluster ID
+	summary := decision.Summary()
+	if summary.SelectedClusterID != "roomy" || summary.OmittedCandidates != 0 {
+		t.Errorf("summary selected %q omitting %d, want roomy omitting 0", summary.SelectedClusterID, summary.OmittedCandidates)
+	}
+	var order []string
+	for _, candidate := range summary.Candidates {
+		order = append(order, candidate.ClusterID)
+	}
+	if want := []string{"roomy", "tight", "full", "limited", "requester", "stale"}; !slices.Equal(order, want) {
+		t.Errorf("summary order = %v, want %v", order, want)
+	}
+	roomy, full := summary.Candidates[0], summary.Candidates[2]
+	if roomy.Score == "" || roomy.ScoreBreakdown == nil || roomy.ScoreBreakdown.Strategy != roomy.Score {
+		t.Errorf("roomy score = %q breakdown %+v, want the strategy term only", roomy.Score, roomy.ScoreBreakdown)
+	}
+	if full.Score != "" || full.ScoreBreakdown != nil {
+		t.Errorf("filtered full has score %q breakdown %+v, want none", full.Score, full.ScoreBreakdown)
+	}
+}
+
+func TestDecisionSummaryBound(t *testing.T) {
+	decision := &Decision{Strategy: StrategySpread}
+	for i := range maxDecisionCandidates + 2 {
+		cluster := scoringCluster(fmt.Sprintf("cluster-%02d", i), "14", "56Gi", nil)
+		decision.filter(cluster, brokerv1alpha1.PlacementFilterInactive, "advertisement is stale")
+	}
+
+	summary := decision.Summary()
+	if summary.SelectedClusterID != "" {
+		t.Errorf("SelectedClusterID = %q, want none", summary.SelectedClusterID)
+	}
+	if len(summary.Candidates) != maxDecisionCandidates || summary.OmittedCandidates != 2 {
+		t.Errorf("summary keeps %d candidates omitting %d, want %d omitting 2",
+			len(summary.Candidates), summary.OmittedCandidates, maxDecisionCandidates)
+	}
+	if last := summary.Candidates[len(summary.Candidates)-1].ClusterID; last != "cluster-09" {
+		t.Errorf("last kept candidate = %s, want cluster-09", last)
+	}
+}
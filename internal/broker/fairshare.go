@@ -0,0 +1,395 @@
+package broker
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apiresource "k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/resource"
+)
+
+// Admission policies decide which waiting reservation gets freed capacity first
+const (
+	// AdmissionFIFO serves higher priority first, then the reservation waiting longest
+	AdmissionFIFO = "fifo"
+	// AdmissionFairShare serves higher priority first, then the requester with the
+	// lowest weighted dominant share of recent usage
+	AdmissionFairShare = "fair-share"
+)
+
+// ValidateAdmissionPolicy checks that name is a known admission policy
+func ValidateAdmissionPolicy(name string) error {
+	switch name {
+	case AdmissionFIFO, AdmissionFairShare:
+		return nil
+	}
+	return fmt.Errorf("unknown admission policy %q, must be one of [%s %s]", name, AdmissionFIFO, AdmissionFairShare)
+}
+
+// FairShares returns the weighted dominant share of every requester that has
+// held resources. A requester's usage is the resources it held multiplied by
+// how long it held them, where usage further in the past counts less and less:
+// it halves every halfLife. Deleted reservations and groups count through the
+// FairShareHistory in the status of the requester's RequesterQuotas. The
+// dominant share is the largest fraction of any resource's total capacity the
+// decayed usage amounts to, divided by the requester's weight (RequesterQuota
+// spec.fairShareWeight, default 1).
+// Requesters missing from the result have a share of 0.
+func FairShares(snapshot *Snapshot, halfLife time.Duration, now time.Time) map[string]float64 {
+	capacity := corev1.ResourceList{}
+	for i := range snapshot.Clusters {
+		if snapshot.Clusters[i].Status.Active {
+			resource.AddTo(capacity, resource.ToList(snapshot.Clusters[i].Spec.Resources.Allocatable))
+		}
+	}
+
+	weights := map[string]float64{}
+	for _, quota := range snapshot.Quotas {
+		if weight := float64(quota.Spec.FairShareWeight); weight > weights[quota.Spec.RequesterID] {
+			weights[quota.Spec.RequesterID] = weight
+		}
+	}
+
+	// Deleted objects count through the history kept in the requester's quotas
+	histories := fairShareHistories(snapshot.Quotas)
+	usages := DecayedUsage(withoutSettled(snapshot.Claims, histories), halfLife, now)
+	for requesterID, history := range histories {
+		addHistory(usages, requesterID, history, halfLife, now)
+	}
+
+	shares := map[string]float64{}
+	for requesterID, usage := range usages {
+		var dominant float64
+		for name, used := range usage {
+			total := capacity[name]
+			if total.Sign() <= 0 {
+				continue
+			}
+			dominant = math.Max(dominant, used/total.AsApproximateFloat64())
+		}
+		weight := weights[requesterID]
+		if weight <= 0 {
+			weight = 1
+		}
+		shares[requesterID] = dominant / weight
+	}
+	return shares
+}
+
+// DecayedUsage sums, per requester and resource, the quantities held by
+// reservations and reservation groups integrated over the time they were held,
+// weighted by exp(-ln2 * age / halfLife). A reservation counts from ReservedAt
+// until now while it holds resources, or until its last status update once it
+// has ended. Only the given objects count; see SettleReservation for the usage
+// of deleted ones.
+func DecayedUsage(claims *Claims, halfLife time.Duration, now time.Time) map[string]map[corev1.ResourceName]float64 {
+	usage := map[string]map[corev1.ResourceName]float64{}
+	add := func(requesterID string, quantities brokerv1alpha1.RequestedResourceQuantities, start, end time.Time) {
+		weight := decayedDuration(start, end, halfLife, now)
+		if weight <= 0 {
+			return
+		}
+		if usage[requesterID] == nil {
+			usage[requesterID] = map[corev1.ResourceName]float64{}
+		}
+		for name, quantity := range resource.RequestedList(quantities) {
+			usage[requesterID][name] += quantity.AsApproximateFloat64() * weight
+		}
+	}
+
+	for i := range claims.Reservations {
+		reservation := &claims.Reservations[i]
+		if reservation.Status.ReservedAt == nil {
+			continue
+		}
+		end := now
+		if !resource.HoldsResources(reservation.Status.Phase) {
+			end = reservation.Status.LastUpdateTime.Time
+		}
+		add(reservation.Spec.RequesterID, resource.LockedResources(reservation), reservation.Status.ReservedAt.Time, end)
+	}
+
+	for i := range claims.Groups {
+		group := &claims.Groups[i]
+		if group.Status.ReservedAt == nil {
+			continue
+		}
+		end := now
+		if !resource.HoldsResources(group.Status.Phase) {
+			end = group.Status.LastUpdateTime.Time
+		}
+		for _, placement := range group.Status.Placements {
+			add(group.Spec.RequesterID, placement.LockedResources, group.Status.ReservedAt.Time, end)
+		}
+	}
+	return usage
+}
+
+// decayedDuration integrates exp(-ln2 * (now - t) / halfLife) over [start, end],
+// in seconds. Without a half-life usage does not decay.
+func decayedDuration(start, end time.Time, halfLife time.Duration, now time.Time) float64 {
+	if end.After(now) {
+		end = now
+	}
+	if !end.After(start) {
+		return 0
+	}
+	if halfLife <= 0 {
+		return end.Sub(start).Seconds()
+	}
+	rate := math.Ln2 / halfLife.Seconds()
+	return (math.Exp(-rate*now.Sub(end).Seconds()) - math.Exp(-rate*now.Sub(start).Seconds())) / rate
+}
+
+// SettleReservation adds the usage of a reservation being deleted to the
+// fair-share history of its requester, so that it keeps counting once the
+// reservation is gone. Settling the same reservation twice has no effect.
+func (d *DecisionEngine) SettleReservation(
+	ctx context.Context,
+	reservation *brokerv1alpha1.Reservation,
+	halfLife time.Duration,
+	now time.Time,
+) error {
+	return d.settleUsage(ctx, reservation.Spec.RequesterID, reservation.Namespace, reservation.UID,
+		&Claims{Reservations: []brokerv1alpha1.Reservation{*reservation}}, halfLife, now)
+}
+
+// SettleGroup is SettleReservation for a reservation group
+func (d *DecisionEngine) SettleGroup(
+	ctx context.Context,
+	group *brokerv1alpha1.ReservationGroup,
+	halfLife time.Duration,
+	now time.Time,
+) error {
+	return d.settleUsage(ctx, group.Spec.RequesterID, group.Namespace, group.UID,
+		&Claims{Groups: []brokerv1alpha1.ReservationGroup{*group}}, halfLife, now)
+}
+
+// settleUsage records the decayed usage of the object with UID uid, the only
+// claim in claim, in the history of every RequesterQuota naming its requester.
+// For a requester without a RequesterQuota one without limits is created in
+// namespace to hold the history.
+func (d *DecisionEngine) settleUsage(
+	ctx context.Context,
+	requesterID string,
+	namespace string,
+	uid types.UID,
+	claim *Claims,
+	halfLife time.Duration,
+	now time.Time,
+) error {
+	used := DecayedUsage(claim, halfLife, now)[requesterID]
+	if len(used) == 0 {
+		return nil
+	}
+
+	quotas, err := d.RequesterQuotas(ctx, requesterID)
+	if err != nil {
+		return err
+	}
+	created := false
+	if len(quotas) == 0 {
+		quota, err := d.historyQuota(ctx, requesterID, namespace)
+		if err != nil {
+			return err
+		}
+		quotas = append(quotas, *quota)
+		created = true
+	}
+	claims, err := d.ListClaims(ctx)
+	if err != nil {
+		return err
+	}
+	existing := map[types.UID]bool{}
+	for i := range claims.Reservations {
+		existing[claims.Reservations[i].UID] = true
+	}
+	for i := range claims.Groups {
+		existing[claims.Groups[i].UID] = true
+	}
+
+	for i := range quotas {
+		key := client.ObjectKeyFromObject(&quotas[i])
+		err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			quota := &brokerv1alpha1.RequesterQuota{}
+			if err := d.Client.Get(ctx, key, quota); err != nil {
+				if !created || !apierrors.IsNotFound(err) {
+					return client.IgnoreNotFound(err)
+				}
+				// The cache has not seen the quota just created yet
+				quota = quotas[i].DeepCopy()
+			}
+			history := quota.Status.FairShareHistory
+			if history != nil && slices.Contains(history.Settled, uid) {
+				return nil
+			}
+
+			total := historyUsage(history, halfLife, now)
+			for name, amount := range used {
+				total[name] += amount
+			}
+			settled := []types.UID{uid}
+			if history != nil {
+				for _, other := range history.Settled {
+					if existing[other] && other != uid {
+						settled = append(settled, other)
+					}
+				}
+			}
+
+			quota.Status.FairShareHistory = &brokerv1alpha1.FairShareHistory{
+				Usage:      usageList(total),
+				ObservedAt: metav1.NewTime(now),
+				Settled:    settled,
+			}
+			return d.Client.Status().Update(ctx, quota)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to record fair-share history in RequesterQuota %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// historyQuota creates the RequesterQuota that keeps the fair-share history of
+// a requester without one, or returns it if it already exists. It sets no limits.
+func (d *DecisionEngine) historyQuota(
+	ctx context.Context,
+	requesterID string,
+	namespace string,
+) (*brokerv1alpha1.RequesterQuota, error) {
+	name := "fair-share-" + requesterID
+	if len(validation.IsDNS1123Subdomain(name)) > 0 {
+		sum := sha256.Sum256([]byte(requesterID))
+		name = "fair-share-" + hex.EncodeToString(sum[:8])
+	}
+
+	quota := &brokerv1alpha1.RequesterQuota{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    map[string]string{brokerv1alpha1.RequesterQuotaFairShareHistoryLabel: "true"},
+		},
+		Spec: brokerv1alpha1.RequesterQuotaSpec{RequesterID: requesterID, FairShareWeight: 1},
+	}
+	err := d.Client.Create(ctx, quota)
+	if apierrors.IsAlreadyExists(err) {
+		err = d.Client.Get(ctx, client.ObjectKeyFromObject(quota), quota)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create RequesterQuota %s/%s for the fair-share history: %w",
+			namespace, name, err)
+	}
+	if quota.Spec.RequesterID != requesterID {
+		return nil, fmt.Errorf("RequesterQuota %s/%s for the fair-share history of %s belongs to requester %s",
+			namespace, name, requesterID, quota.Spec.RequesterID)
+	}
+	return quota, nil
+}
+
+// fairShareHistories returns the most recent history of every requester. Every
+// quota of a requester records the same history, possibly not yet all of them.
+func fairShareHistories(quotas []brokerv1alpha1.RequesterQuota) map[string]*brokerv1alpha1.FairShareHistory {
+	histories := map[string]*brokerv1alpha1.FairShareHistory{}
+	for i := range quotas {
+		history := quotas[i].Status.FairShareHistory
+		if history == nil {
+			continue
+		}
+		requesterID := quotas[i].Spec.RequesterID
+		if latest := histories[requesterID]; latest == nil || history.ObservedAt.After(latest.ObservedAt.Time) {
+			histories[requesterID] = history
+		}
+	}
+	return histories
+}
+
+// withoutSettled leaves out the objects whose usage is already in a history
+func withoutSettled(claims *Claims, histories map[string]*brokerv1alpha1.FairShareHistory) *Claims {
+	settled := map[types.UID]bool{}
+	for _, history := range histories {
+		for _, uid := range history.Settled {
+			settled[uid] = true
+		}
+	}
+	if len(settled) == 0 {
+		return claims
+	}
+
+	remaining := &Claims{}
+	for i := range claims.Reservations {
+		if !settled[claims.Reservations[i].UID] {
+			remaining.Reservations = append(remaining.Reservations, claims.Reservations[i])
+		}
+	}
+	for i := range claims.Groups {
+		if !settled[claims.Groups[i].UID] {
+			remaining.Groups = append(remaining.Groups, claims.Groups[i])
+		}
+	}
+	return remaining
+}
+
+// addHistory adds a requester's history, decayed to now, to the usage of every requester
+func addHistory(
+	usages map[string]map[corev1.ResourceName]float64,
+	requesterID string,
+	history *brokerv1alpha1.FairShareHistory,
+	halfLife time.Duration,
+	now time.Time,
+) {
+	for name, amount := range historyUsage(history, halfLife, now) {
+		if usages[requesterID] == nil {
+			usages[requesterID] = map[corev1.ResourceName]float64{}
+		}
+		usages[requesterID][name] += amount
+	}
+}
+
+// historyUsage is the usage recorded in a history, decayed from ObservedAt to now
+func historyUsage(
+	history *brokerv1alpha1.FairShareHistory,
+	halfLife time.Duration,
+	now time.Time,
+) map[corev1.ResourceName]float64 {
+	usage := map[corev1.ResourceName]float64{}
+	if history == nil {
+		return usage
+	}
+	factor := 1.0
+	if age := now.Sub(history.ObservedAt.Time); halfLife > 0 && age > 0 {
+		factor = math.Exp(-math.Ln2 * age.Seconds() / halfLife.Seconds())
+	}
+	for name, amount := range history.Usage {
+		usage[name] = amount.AsApproximateFloat64() * factor
+	}
+	return usage
+}
+
+// usageList stores usage as whole resource-seconds
+func usageList(usage map[corev1.ResourceName]float64) corev1.ResourceList {
+	list := corev1.ResourceList{}
+	for name, amount := range usage {
+		quantity, err := apiresource.ParseQuantity(strconv.FormatFloat(math.Round(amount), 'f', 0, 64))
+		if err != nil {
+			continue
+		}
+		list[name] = quantity
+	}
+	return list
+}
@@ -0,0 +1,322 @@
+package broker
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+)
+
+func TestDecayedDuration(t *testing.T) {
+	now := time.Now()
+	hour := time.Hour
+
+	tests := []struct {
+		name     string
+		start    time.Time
+		end      time.Time
+		halfLife time.Duration
+		want     float64
+	}{
+		{name: "no decay", start: now.Add(-2 * hour), end: now.Add(-hour), want: 3600},
+		{
+			name:     "held until now over one half-life",
+			start:    now.Add(-hour),
+			end:      now,
+			halfLife: hour,
+			// integral of 2^(-age/h) over [0, h] = h / ln2 * (1 - 1/2)
+			want: 3600 / math.Ln2 / 2,
+		},
+		{
+			name:     "one half-life ago counts half",
+			start:    now.Add(-2 * hour),
+			end:      now.Add(-hour),
+			halfLife: hour,
+			want:     3600 / math.Ln2 / 4,
+		},
+		{name: "end after now is clamped", start: now.Add(-hour), end: now.Add(hour), want: 3600},
+		{name: "empty interval", start: now, end: now.Add(-hour), want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := decayedDuration(tt.start, tt.end, tt.halfLife, now); math.Abs(got-tt.want) > 1e-6 {
+				t.Errorf("decayedDuration() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func fairShareReservation(
+	uid, requesterID string,
+	phase brokerv1alpha1.ReservationPhase,
+	cpu string,
+	reservedAt, lastUpdate time.Time,
+) brokerv1alpha1.Reservation {
+	return brokerv1alpha1.Reservation{
+		ObjectMeta: metav1.ObjectMeta{Name: uid, Namespace: "default", UID: types.UID(uid)},
+		Spec: brokerv1alpha1.ReservationSpec{
+			RequesterID:        requesterID,
+			RequestedResources: brokerv1alpha1.RequestedResourceQuantities{CPU: resource.MustParse(cpu)},
+		},
+		Status: brokerv1alpha1.ReservationStatus{
+			Phase:          phase,
+			ReservedAt:     &metav1.Time{Time: reservedAt},
+			LastUpdateTime: metav1.Time{Time: lastUpdate},
+		},
+	}
+}
+
+func TestDecayedUsage(t *testing.T) {
+	now := time.Now()
+	claims := &Claims{
+		Reservations: []brokerv1alpha1.Reservation{
+			// Held for the last hour
+			fairShareReservation("held", "alice", brokerv1alpha1.ReservationPhaseReserved, "2", now.Add(-time.Hour), now),
+			// Held for an hour and released an hour ago
+			fairShareReservation("ended", "alice", brokerv1alpha1.ReservationPhaseReleased, "1",
+				now.Add(-2*time.Hour), now.Add(-time.Hour)),
+			fairShareReservation("bob", "bob", brokerv1alpha1.ReservationPhaseActive, "4", now.Add(-30*time.Minute), now),
+			{Spec: brokerv1alpha1.ReservationSpec{RequesterID: "never-placed"}},
+		},
+		Groups: []brokerv1alpha1.ReservationGroup{{
+			Spec: brokerv1alpha1.ReservationGroupSpec{RequesterID: "carol"},
+			Status: brokerv1alpha1.ReservationGroupStatus{
+				Phase:      brokerv1alpha1.ReservationPhaseReserved,
+				ReservedAt: &metav1.Time{Time: now.Add(-time.Hour)},
+				Placements: []brokerv1alpha1.MemberPlacement{
+					{LockedResources: brokerv1alpha1.RequestedResourceQuantities{CPU: resource.MustParse("1")}},
+					{LockedResources: brokerv1alpha1.RequestedResourceQuantities{CPU: resource.MustParse("1")}},
+				},
+			},
+		}},
+	}
+
+	usage := DecayedUsage(claims, 0, now)
+	want := map[string]float64{"alice": 2*3600 + 3600, "bob": 4 * 1800, "carol": 2 * 3600}
+	for requesterID, cpu := range want {
+		if got := usage[requesterID][corev1.ResourceCPU]; math.Abs(got-cpu) > 1e-6 {
+			t.Errorf("DecayedUsage()[%s] cpu = %v, want %v", requesterID, got, cpu)
+		}
+	}
+	if _, ok := usage["never-placed"]; ok {
+		t.Error("DecayedUsage() counts a reservation that never held resources")
+	}
+}
+
+func TestFairShares(t *testing.T) {
+	now := time.Now()
+	cluster := brokerv1alpha1.ClusterAdvertisement{
+		Spec: brokerv1alpha1.ClusterAdvertisementSpec{
+			Resources: brokerv1alpha1.ResourceMetrics{
+				Allocatable: brokerv1alpha1.ResourceQuantities{CPU: resource.MustParse("10"), Memory: resource.MustParse("100")},
+			},
+		},
+		Status: brokerv1alpha1.ClusterAdvertisementStatus{Active: true},
+	}
+	memory := func(uid, requesterID, cpu, mem string) brokerv1alpha1.Reservation {
+		reservation := fairShareReservation(uid, requesterID, brokerv1alpha1.ReservationPhaseReserved, cpu,
+			now.Add(-time.Second), now)
+		reservation.Spec.RequestedResources.Memory = resource.MustParse(mem)
+		return reservation
+	}
+
+	tests := []struct {
+		name         string
+		reservations []brokerv1alpha1.Reservation
+		quotas       []brokerv1alpha1.RequesterQuota
+		want         map[string]float64
+	}{
+		{
+			name: "dominant resource",
+			reservations: []brokerv1alpha1.Reservation{
+				// cpu: 1/10 of the capacity for a second, memory: 50/100
+				memory("alice", "alice", "1", "50"),
+				// cpu: 5/10, memory: 10/100
+				memory("bob", "bob", "5", "10"),
+			},
+			want: map[string]float64{"alice": 0.5, "bob": 0.5},
+		},
+		{
+			name:         "weight divides the share",
+			reservations: []brokerv1alpha1.Reservation{memory("alice", "alice", "4", "0")},
+			quotas: []brokerv1alpha1.RequesterQuota{{
+				Spec: brokerv1alpha1.RequesterQuotaSpec{RequesterID: "alice", FairShareWeight: 2},
+			}},
+			want: map[string]float64{"alice": 0.2},
+		},
+		{
+			name:         "history of deleted reservations counts",
+			reservations: []brokerv1alpha1.Reservation{memory("alice", "alice", "1", "0")},
+			quotas: []brokerv1alpha1.RequesterQuota{{
+				Spec: brokerv1alpha1.RequesterQuotaSpec{RequesterID: "alice"},
+				Status: brokerv1alpha1.RequesterQuotaStatus{FairShareHistory: &brokerv1alpha1.FairShareHistory{
+					Usage:      corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")},
+					ObservedAt: metav1.NewTime(now),
+				}},
+			}},
+			want: map[string]float64{"alice": 0.3},
+		},
+		{
+			name:         "settled reservations are not counted twice",
+			reservations: []brokerv1alpha1.Reservation{memory("alice", "alice", "1", "0")},
+			quotas: []brokerv1alpha1.RequesterQuota{{
+				Spec: brokerv1alpha1.RequesterQuotaSpec{RequesterID: "alice"},
+				Status: brokerv1alpha1.RequesterQuotaStatus{FairShareHistory: &brokerv1alpha1.FairShareHistory{
+					Usage:      corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+					ObservedAt: metav1.NewTime(now),
+					Settled:    []types.UID{"alice"},
+				}},
+			}},
+			want: map[string]float64{"alice": 0.1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			snapshot := &Snapshot{
+				Clusters: []brokerv1alpha1.ClusterAdvertisement{cluster},
+				Claims:   &Claims{Reservations: tt.reservations},
+				Quotas:   tt.quotas,
+			}
+			shares := FairShares(snapshot, 0, now)
+			if len(shares) != len(tt.want) {
+				t.Fatalf("FairShares() = %v, want %v", shares, tt.want)
+			}
+			for requesterID, share := range tt.want {
+				if math.Abs(shares[requesterID]-share) > 1e-6 {
+					t.Errorf("FairShares()[%s] = %v, want %v", requesterID, shares[requesterID], share)
+				}
+			}
+		})
+	}
+}
+
+func TestHistoryUsage(t *testing.T) {
+	now := time.Now()
+	history := &brokerv1alpha1.FairShareHistory{
+		Usage:      corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1000")},
+		ObservedAt: metav1.NewTime(now.Add(-2 * time.Hour)),
+	}
+
+	if got := historyUsage(history, time.Hour, now)[corev1.ResourceCPU]; math.Abs(got-250) > 1e-6 {
+		t.Errorf("historyUsage() after two half-lives = %v, want 250", got)
+	}
+	if got := historyUsage(history, 0, now)[corev1.ResourceCPU]; got != 1000 {
+		t.Errorf("historyUsage() without decay = %v, want 1000", got)
+	}
+	if got := historyUsage(nil, time.Hour, now); len(got) != 0 {
+		t.Errorf("historyUsage(nil) = %v, want nothing", got)
+	}
+}
+
+func TestSettleReservation(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := brokerv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	quota := &brokerv1alpha1.RequesterQuota{
+		ObjectMeta: metav1.ObjectMeta{Name: "alice", Namespace: "default"},
+		Spec:       brokerv1alpha1.RequesterQuotaSpec{RequesterID: "alice"},
+		Status: brokerv1alpha1.RequesterQuotaStatus{FairShareHistory: &brokerv1alpha1.FairShareHistory{
+			Usage:      corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100")},
+			ObservedAt: metav1.NewTime(now),
+			Settled:    []types.UID{"deleted-long-ago"},
+		}},
+	}
+	reservation := fairShareReservation("released", "alice", brokerv1alpha1.ReservationPhaseReleased, "2",
+		now.Add(-time.Hour), now)
+	c := fake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(quota, &reservation).
+		WithStatusSubresource(quota).
+		Build()
+	engine := &DecisionEngine{Client: c}
+
+	// Settling twice, e.g. when removing the finalizer is retried, adds the usage once
+	for range 2 {
+		if err := engine.SettleReservation(context.Background(), &reservation, 0, now); err != nil {
+			t.Fatalf("SettleReservation() error = %v", err)
+		}
+	}
+
+	stored := &brokerv1alpha1.RequesterQuota{}
+	if err := c.Get(context.Background(), client.ObjectKeyFromObject(quota), stored); err != nil {
+		t.Fatal(err)
+	}
+	history := stored.Status.FairShareHistory
+	if history == nil {
+		t.Fatal("FairShareHistory = nil")
+	}
+	cpu := history.Usage[corev1.ResourceCPU]
+	if want := resource.MustParse("7300"); cpu.Cmp(want) != 0 {
+		t.Errorf("history cpu = %s, want %s", cpu.String(), want.String())
+	}
+	if len(history.Settled) != 1 || history.Settled[0] != reservation.UID {
+		t.Errorf("Settled = %v, want [%s]", history.Settled, reservation.UID)
+	}
+}
+
+func TestSettleReservationWithoutQuota(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := brokerv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	reservation := fairShareReservation("released", "alice", brokerv1alpha1.ReservationPhaseReleased, "2",
+		now.Add(-time.Hour), now)
+	// Requester IDs that are no valid object name get a hashed one
+	invalid := fairShareReservation("released-too", "Bob_Cluster", brokerv1alpha1.ReservationPhaseReleased, "1",
+		now.Add(-time.Hour), now)
+	c := fake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(&reservation, &invalid).
+		WithStatusSubresource(&brokerv1alpha1.RequesterQuota{}).
+		Build()
+	engine := &DecisionEngine{Client: c}
+
+	for _, settled := range []*brokerv1alpha1.Reservation{&reservation, &invalid} {
+		if err := engine.SettleReservation(context.Background(), settled, 0, now); err != nil {
+			t.Fatalf("SettleReservation(%s) error = %v", settled.Name, err)
+		}
+	}
+
+	quotas := &brokerv1alpha1.RequesterQuotaList{}
+	if err := c.List(context.Background(), quotas); err != nil {
+		t.Fatal(err)
+	}
+	if len(quotas.Items) != 2 {
+		t.Fatalf("created %d RequesterQuotas, want 2", len(quotas.Items))
+	}
+	for _, quota := range quotas.Items {
+		if quota.Labels[brokerv1alpha1.RequesterQuotaFairShareHistoryLabel] != "true" {
+			t.Errorf("RequesterQuota %s lacks the fair-share history label", quota.Name)
+		}
+		if quota.Spec.Hard != (brokerv1alpha1.QuotaResources{}) || len(quota.Spec.Clusters) > 0 {
+			t.Errorf("RequesterQuota %s has limits, want none", quota.Name)
+		}
+		history := quota.Status.FairShareHistory
+		if history == nil || len(history.Settled) != 1 {
+			t.Errorf("RequesterQuota %s history = %+v, want one settled reservation", quota.Name, history)
+		}
+	}
+
+	stored := &brokerv1alpha1.RequesterQuota{}
+	key := types.NamespacedName{Name: "fair-share-alice", Namespace: "default"}
+	if err := c.Get(context.Background(), key, stored); err != nil {
+		t.Fatalf("RequesterQuota of alice: %v", err)
+	}
+	cpu := stored.Status.FairShareHistory.Usage[corev1.ResourceCPU]
+	if want := resource.MustParse("7200"); cpu.Cmp(want) != 0 {
+		t.Errorf("history cpu = %s, want %s", cpu.String(), want.String())
+	}
+}
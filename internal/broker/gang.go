@@ -0,0 +1,189 @@
+package broker
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/resource"
+)
+
+// maxGroupSearchSteps bounds the backtracking search of SelectClustersForGroup
+const maxGroupSearchSteps = 10000
+
+// MemberSpec returns the reservation spec a group member is placed with
+func MemberSpec(group *brokerv1alpha1.ReservationGroup, member *brokerv1alpha1.ReservationGroupMember) *brokerv1alpha1.ReservationSpec {
+	return &brokerv1alpha1.ReservationSpec{
+		TargetClusterID:    member.TargetClusterID,
+		RequestedResources: member.RequestedResources,
+		Duration:           group.Spec.Duration,
+		Priority:           group.Spec.Priority,
+		RequesterID:        group.Spec.RequesterID,
+		ScoringStrategy:    group.Spec.ScoringStrategy,
+		Tolerations:        group.Spec.Tolerations,
+	}
+}
+
+// SelectClustersForGroup chooses a cluster for every member of a group so that
+// all members fit at the same time. Each member's candidates are tried in score
+// order and the search backtracks when a later member no longer fits. The result
+// is in member order; an error means no joint placement exists.
+func (d *DecisionEngine) SelectClustersForGroup(
+	ctx context.Context,
+	group *brokerv1alpha1.ReservationGroup,
+) ([]*brokerv1alpha1.ClusterAdvertisement, error) {
+	snapshot, err := d.TakeSnapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return d.SelectClustersForGroupIn(snapshot, group)
+}
+
+// SelectClustersForGroupIn is SelectClustersForGroup evaluated against a snapshot.
+// Every tentatively placed member is checked with CanHost against the snapshot's
+// claims and the members placed before it.
+func (d *DecisionEngine) SelectClustersForGroupIn(
+	snapshot *Snapshot,
+	group *brokerv1alpha1.ReservationGroup,
+) ([]*brokerv1alpha1.ClusterAdvertisement, error) {
+
+	if len(snapshot.Clusters) == 0 {
+		return nil, fmt.Errorf("no clusters available")
+	}
+	claims := snapshot.Claims
+	now := time.Now()
+
+	members := group.Spec.Members
+	specs := make([]*brokerv1alpha1.ReservationSpec, len(members))
+	requested := make([]corev1.ResourceList, len(members))
+	candidates := make([][]*brokerv1alpha1.ClusterAdvertisement, len(members))
+	for i := range members {
+		spec := MemberSpec(group, &members[i])
+		specs[i] = spec
+		requested[i] = resource.RequestedList(spec.RequestedResources)
+
+		scorer, err := d.scorerFor(spec)
+		if err != nil {
+			return nil, err
+		}
+
+		var eligible []*brokerv1alpha1.ClusterAdvertisement
+		for j := range snapshot.Clusters {
+			cluster := &snapshot.Clusters[j]
+			if spec.TargetClusterID != "" {
+				if cluster.Spec.ClusterID != spec.TargetClusterID ||
+					UntoleratedTaint(cluster, spec.Tolerations, brokerv1alpha1.TaintEffectNoReserve) != nil {
+					continue
+				}
+			} else if !d.isEligible(cluster, spec) {
+				continue
+			}
+			if d.CanHost(cluster, spec, claims, "", now) {
+				eligible = append(eligible, cluster)
+			}
+		}
+		if len(eligible) == 0 {
+			return nil, fmt.Errorf("no suitable cluster found for member %q", members[i].Name)
+		}
+
+		scores := scorer.Score(spec, eligible)
+		for j, cluster := range eligible {
+			scores[j] -= taintPenalty(cluster, spec)
+		}
+		order := make([]int, len(eligible))
+		for j := range order {
+			order[j] = j
+		}
+		sort.SliceStable(order, func(a, b int) bool { return scores[order[a]] > scores[order[b]] })
+		for _, j := range order {
+			candidates[i] = append(candidates[i], eligible[j])
+		}
+	}
+
+	// What is left on each cluster as members are tentatively placed
+	remaining := map[string]corev1.ResourceList{}
+	used := map[string]bool{}
+	assignment := make([]*brokerv1alpha1.ClusterAdvertisement, len(members))
+	placements := make([]brokerv1alpha1.MemberPlacement, 0, len(members))
+	steps := 0
+
+	var place func(i int) bool
+	place = func(i int) bool {
+		if i == len(members) {
+			return true
+		}
+		for _, cluster := range candidates[i] {
+			clusterID := cluster.Spec.ClusterID
+			if group.Spec.DistinctClusters && used[clusterID] {
+				continue
+			}
+			available, ok := remaining[clusterID]
+			if !ok {
+				available = resource.ToList(cluster.Spec.Resources.Available)
+				remaining[clusterID] = available
+			}
+			if !resource.Fits(available, requested[i]) {
+				continue
+			}
+			if steps++; steps > maxGroupSearchSteps {
+				return false
+			}
+			// The members placed so far book the cluster for the group's whole lifetime
+			if len(placements) > 0 && !d.CanHost(cluster, specs[i], WithPlacements(claims, group, placements, now), "", now) {
+				continue
+			}
+
+			resource.SubFrom(available, requested[i])
+			used[clusterID] = true
+			assignment[i] = cluster
+			placements = append(placements, brokerv1alpha1.MemberPlacement{
+				Name:            members[i].Name,
+				ClusterID:       clusterID,
+				LockedResources: members[i].RequestedResources,
+			})
+			if place(i + 1) {
+				return true
+			}
+			placements = placements[:len(placements)-1]
+			resource.AddTo(available, requested[i])
+			used[clusterID] = false
+		}
+		return false
+	}
+
+	if !place(0) {
+		return nil, fmt.Errorf("no placement fits all %d members at once", len(members))
+	}
+	return assignment, nil
+}
+
+// WithPlacements returns the claims with the group holding the given member
+// placements, as if it were locked from now until it expires. Members are
+// placed and locked one at a time, so each is checked against the ones before it.
+func WithPlacements(
+	claims *Claims,
+	group *brokerv1alpha1.ReservationGroup,
+	placements []brokerv1alpha1.MemberPlacement,
+	now time.Time,
+) *Claims {
+	locked := brokerv1alpha1.ReservationGroup{ObjectMeta: *group.ObjectMeta.DeepCopy()}
+	locked.Status.Phase = brokerv1alpha1.ReservationPhaseReserved
+	locked.Status.Placements = placements
+	if group.Spec.Duration != nil {
+		expiresAt := metav1.NewTime(now.Add(group.Spec.Duration.Duration))
+		locked.Status.ExpiresAt = &expiresAt
+	}
+
+	groups := make([]brokerv1alpha1.ReservationGroup, 0, len(claims.Groups)+1)
+	for i := range claims.Groups {
+		if group.UID == "" || claims.Groups[i].UID != group.UID {
+			groups = append(groups, claims.Groups[i])
+		}
+	}
+	return &Claims{Reservations: claims.Reservations, Groups: append(groups, locked)}
+}
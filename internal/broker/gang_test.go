@@ -0,0 +1,127 @@
+package broker
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+)
+
+func TestSelectClustersForGroupIn(t *testing.T) {
+	now := time.Now()
+
+	cluster := func(name string) brokerv1alpha1.ClusterAdvertisement {
+		cluster := scoringCluster(name, "16", "64Gi", nil)
+		cluster.Status.Active = true
+		return *cluster
+	}
+	// Books 10 of the 16 cores of cluster-a an hour from now
+	booking := brokerv1alpha1.Reservation{
+		ObjectMeta: metav1.ObjectMeta{Name: "booking", UID: "booking"},
+		Spec: brokerv1alpha1.ReservationSpec{
+			TargetClusterID:    "cluster-a",
+			StartTime:          &metav1.Time{Time: now.Add(time.Hour)},
+			Duration:           &metav1.Duration{Duration: time.Hour},
+			RequestedResources: brokerv1alpha1.RequestedResourceQuantities{CPU: resource.MustParse("10")},
+		},
+		Status: brokerv1alpha1.ReservationStatus{Phase: brokerv1alpha1.ReservationPhaseScheduled},
+	}
+	member := func(name string) brokerv1alpha1.ReservationGroupMember {
+		return brokerv1alpha1.ReservationGroupMember{
+			Name: name,
+			RequestedResources: brokerv1alpha1.RequestedResourceQuantities{
+				CPU: resource.MustParse("4"), Memory: resource.MustParse("4Gi"),
+			},
+		}
+	}
+	group := &brokerv1alpha1.ReservationGroup{
+		ObjectMeta: metav1.ObjectMeta{Name: "gang", UID: "gang"},
+		Spec: brokerv1alpha1.ReservationGroupSpec{
+			RequesterID: "requester",
+			Duration:    &metav1.Duration{Duration: 3 * time.Hour},
+			Members:     []brokerv1alpha1.ReservationGroupMember{member("first"), member("second")},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		clusters []brokerv1alpha1.ClusterAdvertisement
+		booked   bool
+		want     []string
+	}{
+		{
+			name:     "both members fit on the first cluster",
+			clusters: []brokerv1alpha1.ClusterAdvertisement{cluster("cluster-a"), cluster("cluster-b")},
+			want:     []string{"cluster-a", "cluster-a"},
+		},
+		{
+			name:     "a booking leaves room for one member only",
+			clusters: []brokerv1alpha1.ClusterAdvertisement{cluster("cluster-a"), cluster("cluster-b")},
+			booked:   true,
+			want:     []string{"cluster-a", "cluster-b"},
+		},
+		{
+			name:     "no joint placement",
+			clusters: []brokerv1alpha1.ClusterAdvertisement{cluster("cluster-a")},
+			booked:   true,
+		},
+	}
+
+	engine := &DecisionEngine{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			snapshot := &Snapshot{Clusters: tt.clusters, Claims: &Claims{}}
+			if tt.booked {
+				snapshot.Claims.Reservations = []brokerv1alpha1.Reservation{booking}
+			}
+
+			clusters, err := engine.SelectClustersForGroupIn(snapshot, group)
+			if tt.want == nil {
+				if err == nil {
+					t.Fatal("SelectClustersForGroupIn() found a placement, want none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SelectClustersForGroupIn() error = %v", err)
+			}
+			var got []string
+			for _, cluster := range clusters {
+				got = append(got, cluster.Spec.ClusterID)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("SelectClustersForGroupIn() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithPlacements(t *testing.T) {
+	now := time.Now()
+	group := &brokerv1alpha1.ReservationGroup{
+		ObjectMeta: metav1.ObjectMeta{Name: "gang", UID: "gang"},
+		Spec:       brokerv1alpha1.ReservationGroupSpec{Duration: &metav1.Duration{Duration: time.Hour}},
+		Status:     brokerv1alpha1.ReservationGroupStatus{Phase: brokerv1alpha1.ReservationPhasePending},
+	}
+	claims := &Claims{Groups: []brokerv1alpha1.ReservationGroup{*group}}
+	placements := []brokerv1alpha1.MemberPlacement{{
+		Name:            "first",
+		ClusterID:       "cluster-a",
+		LockedResources: brokerv1alpha1.RequestedResourceQuantities{CPU: resource.MustParse("4")},
+	}}
+
+	bookings := ClusterBookings("cluster-a", WithPlacements(claims, group, placements, now), "", now)
+	if len(bookings) != 1 {
+		t.Fatalf("ClusterBookings() = %d bookings, want the placed member only", len(bookings))
+	}
+	if !bookings[0].Start.Equal(now) || !bookings[0].End.Equal(now.Add(time.Hour)) {
+		t.Errorf("booking = [%v, %v), want [now, now+1h)", bookings[0].Start, bookings[0].End)
+	}
+	if len(claims.Groups) != 1 || claims.Groups[0].Status.Phase != brokerv1alpha1.ReservationPhasePending {
+		t.Error("WithPlacements() modified the claims it was given")
+	}
+}
@@ -0,0 +1,216 @@
+package broker
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/resource"
+)
+
+// PreemptionPlan is a cluster on which a reservation fits once the victims have been evicted
+type PreemptionPlan struct {
+	Cluster *brokerv1alpha1.ClusterAdvertisement
+	Victims []*brokerv1alpha1.Reservation
+}
+
+// PlanPreemption finds the cluster where the reservation can be placed by evicting
+// the fewest, and then the lowest-priority, reservations. Only Reserved reservations
+// of strictly lower priority are considered; Active ones are never preempted.
+// Clusters are filtered like in SelectBestCluster; if spec.TargetClusterID is set
+// only that cluster is considered, and the affinity rules do not apply.
+// It returns nil if no cluster can make room.
+func (d *DecisionEngine) PlanPreemption(
+	ctx context.Context,
+	spec *brokerv1alpha1.ReservationSpec,
+) (*PreemptionPlan, error) {
+
+	snapshot, err := d.TakeSnapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	claims := snapshot.Claims
+	now := time.Now()
+
+	clusterAffinity, err := newAffinity(spec, snapshot.Clusters, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	quotas := snapshot.RequesterQuotas(spec.RequesterID)
+	usage := RequesterUsage(spec.RequesterID, claims)
+
+	var best *PreemptionPlan
+	for i := range snapshot.Clusters {
+		cluster := &snapshot.Clusters[i]
+
+		if spec.TargetClusterID != "" {
+			if cluster.Spec.ClusterID != spec.TargetClusterID {
+				continue
+			}
+		} else if !clusterAffinity.Allows(cluster) {
+			continue
+		}
+		if !d.isEligible(cluster, spec) ||
+			!withinClusterQuotas(quotas, usage, cluster.Spec.ClusterID, spec.RequestedResources) {
+			continue
+		}
+
+		victims, ok := d.SelectVictims(cluster, spec, claims, now)
+		if !ok {
+			continue
+		}
+
+		plan := &PreemptionPlan{Cluster: cluster, Victims: victims}
+		if best == nil || cheaperPreemption(plan, best) {
+			best = plan
+		}
+	}
+
+	return best, nil
+}
+
+// SelectVictims picks the reservations to evict from a cluster so that spec fits.
+// Victims are taken lowest priority first and, within a priority, most recently
+// reserved first; victims that turn out not to be needed are dropped again.
+// Whether spec fits is decided by CanHost with the victims' resources given back
+// and their bookings removed, so fragments of split reservations, group members
+// and Scheduled bookings on the cluster are taken into account.
+// ok is false if evicting every eligible reservation still does not make room.
+func (d *DecisionEngine) SelectVictims(
+	cluster *brokerv1alpha1.ClusterAdvertisement,
+	spec *brokerv1alpha1.ReservationSpec,
+	claims *Claims,
+	now time.Time,
+) (victims []*brokerv1alpha1.Reservation, ok bool) {
+
+	if d.CanHost(cluster, spec, claims, "", now) {
+		return nil, true
+	}
+
+	// Split reservations have no target cluster and are never preempted
+	var candidates []*brokerv1alpha1.Reservation
+	for i := range claims.Reservations {
+		reservation := &claims.Reservations[i]
+		if reservation.Spec.TargetClusterID != cluster.Spec.ClusterID ||
+			reservation.Status.Phase != brokerv1alpha1.ReservationPhaseReserved ||
+			reservation.Spec.Priority >= spec.Priority {
+			continue
+		}
+		candidates = append(candidates, reservation)
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return evictBefore(candidates[i], candidates[j])
+	})
+
+	fits := func(victims []*brokerv1alpha1.Reservation) bool {
+		return d.CanHostWithout(cluster, spec, claims, victims, "", now)
+	}
+
+	for _, candidate := range candidates {
+		victims = append(victims, candidate)
+		if fits(victims) {
+			ok = true
+			break
+		}
+	}
+	if !ok {
+		return nil, false
+	}
+
+	// Spare the most valuable victims whose eviction turned out to be unnecessary
+	for i := len(victims) - 1; i >= 0; i-- {
+		without := append(append([]*brokerv1alpha1.Reservation{}, victims[:i]...), victims[i+1:]...)
+		if fits(without) {
+			victims = without
+		}
+	}
+
+	return victims, true
+}
+
+// CanHostWithout reports whether a cluster can take the reservation once the
+// victims are evicted: CanHost with the victims' locked resources given back
+// and their claims removed
+func (d *DecisionEngine) CanHostWithout(
+	cluster *brokerv1alpha1.ClusterAdvertisement,
+	spec *brokerv1alpha1.ReservationSpec,
+	claims *Claims,
+	victims []*brokerv1alpha1.Reservation,
+	self types.UID,
+	now time.Time,
+) bool {
+	freed, remaining := withoutVictims(cluster, claims, victims)
+	return d.CanHost(freed, spec, remaining, self, now)
+}
+
+// withoutVictims returns the cluster with the victims' locked resources given
+// back to its Available counter, and the claims without the victims
+func withoutVictims(
+	cluster *brokerv1alpha1.ClusterAdvertisement,
+	claims *Claims,
+	victims []*brokerv1alpha1.Reservation,
+) (*brokerv1alpha1.ClusterAdvertisement, *Claims) {
+	freed := cluster.DeepCopy()
+	available := resource.ToList(freed.Spec.Resources.Available)
+	evicted := map[types.UID]bool{}
+	for _, victim := range victims {
+		resource.AddTo(available, resource.RequestedList(resource.LockedResources(victim)))
+		evicted[victim.UID] = true
+	}
+	freed.Spec.Resources.Available = resource.FromList(available)
+
+	remaining := &Claims{Groups: claims.Groups}
+	for i := range claims.Reservations {
+		if !evicted[claims.Reservations[i].UID] {
+			remaining.Reservations = append(remaining.Reservations, claims.Reservations[i])
+		}
+	}
+	return freed, remaining
+}
+
+// evictBefore orders preemption candidates: lowest priority first, then the
+// most recently reserved, then by namespace and name for a stable result
+func evictBefore(a, b *brokerv1alpha1.Reservation) bool {
+	if a.Spec.Priority != b.Spec.Priority {
+		return a.Spec.Priority < b.Spec.Priority
+	}
+	aReserved, bReserved := reservedAt(a), reservedAt(b)
+	if !aReserved.Equal(&bReserved) {
+		return bReserved.Before(&aReserved)
+	}
+	if a.Namespace != b.Namespace {
+		return a.Namespace < b.Namespace
+	}
+	return a.Name < b.Name
+}
+
+func reservedAt(reservation *brokerv1alpha1.Reservation) metav1.Time {
+	if reservation.Status.ReservedAt != nil {
+		return *reservation.Status.ReservedAt
+	}
+	return reservation.CreationTimestamp
+}
+
+// cheaperPreemption reports whether plan a disrupts less than plan b:
+// fewer victims first, then a lower highest victim priority
+func cheaperPreemption(a, b *PreemptionPlan) bool {
+	if len(a.Victims) != len(b.Victims) {
+		return len(a.Victims) < len(b.Victims)
+	}
+	return maxPriority(a.Victims) < maxPriority(b.Victims)
+}
+
+func maxPriority(reservations []*brokerv1alpha1.Reservation) int32 {
+	var highest int32
+	for i, reservation := range reservations {
+		if i == 0 || reservation.Spec.Priority > highest {
+			highest = reservation.Spec.Priority
+		}
+	}
+	return highest
+}
@@ -0,0 +1,184 @@
+package broker
+
+import (
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+)
+
+func TestSelectVictims(t *testing.T) {
+	now := time.Now()
+
+	cpu := func(cores string) brokerv1alpha1.RequestedResourceQuantities {
+		return brokerv1alpha1.RequestedResourceQuantities{CPU: resource.MustParse(cores), Memory: resource.MustParse("0")}
+	}
+	cluster := func(available string) *brokerv1alpha1.ClusterAdvertisement {
+		return &brokerv1alpha1.ClusterAdvertisement{
+			Spec: brokerv1alpha1.ClusterAdvertisementSpec{
+				ClusterID: "cluster-a",
+				Resources: brokerv1alpha1.ResourceMetrics{
+					Allocatable: brokerv1alpha1.ResourceQuantities{CPU: resource.MustParse("8"), Memory: resource.MustParse("16Gi")},
+					Available:   brokerv1alpha1.ResourceQuantities{CPU: resource.MustParse(available), Memory: resource.MustParse("16Gi")},
+				},
+			},
+			Status: brokerv1alpha1.ClusterAdvertisementStatus{Active: true},
+		}
+	}
+	reserved := func(name string, priority int32, cores string) brokerv1alpha1.Reservation {
+		return brokerv1alpha1.Reservation{
+			ObjectMeta: metav1.ObjectMeta{Name: name, UID: types.UID(name)},
+			Spec: brokerv1alpha1.ReservationSpec{
+				TargetClusterID:    "cluster-a",
+				Priority:           priority,
+				RequestedResources: cpu(cores),
+			},
+			Status: brokerv1alpha1.ReservationStatus{Phase: brokerv1alpha1.ReservationPhaseReserved},
+		}
+	}
+	scheduled := func(name string, cores string, start time.Time) brokerv1alpha1.Reservation {
+		reservation := reserved(name, 9, cores)
+		reservation.Spec.StartTime = &metav1.Time{Time: start}
+		reservation.Status.Phase = brokerv1alpha1.ReservationPhaseScheduled
+		return reservation
+	}
+
+	tests := []struct {
+		name         string
+		cluster      *brokerv1alpha1.ClusterAdvertisement
+		reservations []brokerv1alpha1.Reservation
+		requested    string
+		wantVictims  []string
+		wantOK       bool
+	}{
+		{
+			name:         "fits without evictions",
+			cluster:      cluster("4"),
+			reservations: []brokerv1alpha1.Reservation{reserved("low", 1, "4")},
+			requested:    "4",
+			wantOK:       true,
+		},
+		{
+			name:    "lowest priority goes first",
+			cluster: cluster("0"),
+			reservations: []brokerv1alpha1.Reservation{
+				reserved("p3", 3, "2"), reserved("p1", 1, "2"), reserved("p2", 2, "2"), reserved("p4", 4, "2"),
+			},
+			requested:   "2",
+			wantVictims: []string{"p1"},
+			wantOK:      true,
+		},
+		{
+			name:    "unneeded victims are spared",
+			cluster: cluster("0"),
+			reservations: []brokerv1alpha1.Reservation{
+				reserved("small", 1, "2"), reserved("large", 2, "6"),
+			},
+			requested:   "6",
+			wantVictims: []string{"large"},
+			wantOK:      true,
+		},
+		{
+			name:    "scheduled booking counts",
+			cluster: cluster("4"),
+			reservations: []brokerv1alpha1.Reservation{
+				reserved("low", 1, "4"), scheduled("later", "4", now.Add(time.Hour)),
+			},
+			requested:   "4",
+			wantVictims: []string{"low"},
+			wantOK:      true,
+		},
+		{
+			name:    "equal or higher priority is never evicted",
+			cluster: cluster("0"),
+			reservations: []brokerv1alpha1.Reservation{
+				reserved("same", 5, "4"), reserved("higher", 6, "4"),
+			},
+			requested: "2",
+		},
+	}
+
+	engine := &DecisionEngine{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := &brokerv1alpha1.ReservationSpec{Priority: 5, RequestedResources: cpu(tt.requested)}
+			victims, ok := engine.SelectVictims(tt.cluster, spec, &Claims{Reservations: tt.reservations}, now)
+			if ok != tt.wantOK {
+				t.Fatalf("SelectVictims() ok = %v, want %v", ok, tt.wantOK)
+			}
+			var names []string
+			for _, victim := range victims {
+				names = append(names, victim.Name)
+			}
+			if len(names) != len(tt.wantVictims) {
+				t.Fatalf("SelectVictims() victims = %v, want %v", names, tt.wantVictims)
+			}
+			for i := range names {
+				if names[i] != tt.wantVictims[i] {
+					t.Errorf("SelectVictims() victims = %v, want %v", names, tt.wantVictims)
+				}
+			}
+		})
+	}
+}
+
+func TestCanHostWithout(t *testing.T) {
+	now := time.Now()
+
+	cluster := &brokerv1alpha1.ClusterAdvertisement{
+		Spec: brokerv1alpha1.ClusterAdvertisementSpec{
+			ClusterID: "cluster-a",
+			Resources: brokerv1alpha1.ResourceMetrics{
+				Allocatable: brokerv1alpha1.ResourceQuantities{CPU: resource.MustParse("8"), Memory: resource.MustParse("16Gi")},
+				Available:   brokerv1alpha1.ResourceQuantities{CPU: resource.MustParse("0"), Memory: resource.MustParse("16Gi")},
+			},
+		},
+		Status: brokerv1alpha1.ClusterAdvertisementStatus{Active: true},
+	}
+	victim := brokerv1alpha1.Reservation{
+		ObjectMeta: metav1.ObjectMeta{Name: "low", UID: "low"},
+		Spec: brokerv1alpha1.ReservationSpec{
+			TargetClusterID:    "cluster-a",
+			Priority:           1,
+			RequestedResources: brokerv1alpha1.RequestedResourceQuantities{CPU: resource.MustParse("8")},
+		},
+		Status: brokerv1alpha1.ReservationStatus{Phase: brokerv1alpha1.ReservationPhaseReserved},
+	}
+	// Booked on the cluster after the victims were planned
+	booked := brokerv1alpha1.Reservation{
+		ObjectMeta: metav1.ObjectMeta{Name: "booked", UID: "booked"},
+		Spec: brokerv1alpha1.ReservationSpec{
+			TargetClusterID:    "cluster-a",
+			StartTime:          &metav1.Time{Time: now.Add(time.Hour)},
+			Duration:           &metav1.Duration{Duration: time.Hour},
+			RequestedResources: brokerv1alpha1.RequestedResourceQuantities{CPU: resource.MustParse("6")},
+		},
+		Status: brokerv1alpha1.ReservationStatus{Phase: brokerv1alpha1.ReservationPhasePending},
+	}
+	spec := &brokerv1alpha1.ReservationSpec{
+		Priority:           5,
+		RequestedResources: brokerv1alpha1.RequestedResourceQuantities{CPU: resource.MustParse("4")},
+	}
+	victims := []*brokerv1alpha1.Reservation{&victim}
+
+	engine := &DecisionEngine{}
+	claims := &Claims{Reservations: []brokerv1alpha1.Reservation{victim}}
+	if !engine.CanHostWithout(cluster, spec, claims, victims, "", now) {
+		t.Error("CanHostWithout() = false with the victim evicted")
+	}
+	if engine.CanHost(cluster, spec, claims, "", now) {
+		t.Error("CanHost() = true with the victim still holding the cluster")
+	}
+
+	// Evicting frees enough now, but not for the booking that starts later
+	withBooking := cluster.DeepCopy()
+	RecordBooking(withBooking, &booked, now)
+	claims.Reservations = append(claims.Reservations, booked)
+	if engine.CanHostWithout(withBooking, spec, claims, victims, "", now) {
+		t.Error("CanHostWithout() = true over a conflicting booking")
+	}
+}
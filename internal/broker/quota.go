@@ -0,0 +1,232 @@
+package broker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apiresource "k8s.io/apimachinery/pkg/api/resource"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/resource"
+)
+
+// Usage is what a requester holds, in total and per cluster
+type Usage struct {
+	Total    UsageAmounts
+	Clusters map[string]*UsageAmounts
+}
+
+// UsageAmounts are the resources and the number of reservations held
+type UsageAmounts struct {
+	Resources    corev1.ResourceList
+	Reservations int32
+}
+
+// RequesterQuotas lists the quotas that apply to a requester
+func (d *DecisionEngine) RequesterQuotas(
+	ctx context.Context,
+	requesterID string,
+) ([]brokerv1alpha1.RequesterQuota, error) {
+	quotaList := &brokerv1alpha1.RequesterQuotaList{}
+	if err := d.Client.List(ctx, quotaList); err != nil {
+		return nil, fmt.Errorf("failed to list requester quotas: %w", err)
+	}
+
+	return requesterQuotas(quotaList.Items, requesterID), nil
+}
+
+// requesterQuotas filters the quotas that apply to a requester
+func requesterQuotas(all []brokerv1alpha1.RequesterQuota, requesterID string) []brokerv1alpha1.RequesterQuota {
+	var quotas []brokerv1alpha1.RequesterQuota
+	for _, quota := range all {
+		if quota.Spec.RequesterID == requesterID {
+			quotas = append(quotas, quota)
+		}
+	}
+	return quotas
+}
+
+// RequesterUsage sums what a requester holds: reservations holding resources,
+// Scheduled bookings and members of reservation groups holding resources
+func RequesterUsage(requesterID string, claims *Claims) *Usage {
+	usage := &Usage{Total: UsageAmounts{Resources: corev1.ResourceList{}}, Clusters: map[string]*UsageAmounts{}}
+
+	for i := range claims.Reservations {
+		reservation := &claims.Reservations[i]
+		if reservation.Spec.RequesterID != requesterID {
+			continue
+		}
+		switch {
+		case resource.HoldsResources(reservation.Status.Phase):
+			usage.Add(resource.Holdings(reservation), 1)
+		case reservation.Status.Phase == brokerv1alpha1.ReservationPhaseScheduled:
+			usage.Add([]brokerv1alpha1.ReservationFragment{{
+				ClusterID:       reservation.Spec.TargetClusterID,
+				LockedResources: reservation.Spec.RequestedResources,
+			}}, 1)
+		}
+	}
+
+	for i := range claims.Groups {
+		group := &claims.Groups[i]
+		if group.Spec.RequesterID != requesterID || !resource.HoldsResources(group.Status.Phase) {
+			continue
+		}
+		for _, placement := range group.Status.Placements {
+			usage.Add([]brokerv1alpha1.ReservationFragment{{
+				ClusterID:       placement.ClusterID,
+				LockedResources: placement.LockedResources,
+			}}, 1)
+		}
+	}
+	return usage
+}
+
+// Add counts additional reservations holding the given quantities. Each cluster
+// a holding is on counts one more reservation; holdings without a cluster ID
+// only count towards the total.
+func (u *Usage) Add(holdings []brokerv1alpha1.ReservationFragment, reservations int32) {
+	u.Total.Reservations += reservations
+	for _, holding := range holdings {
+		requested := resource.RequestedList(holding.LockedResources)
+		resource.AddTo(u.Total.Resources, requested)
+		if holding.ClusterID == "" {
+			continue
+		}
+		cluster, ok := u.Clusters[holding.ClusterID]
+		if !ok {
+			cluster = &UsageAmounts{Resources: corev1.ResourceList{}}
+			u.Clusters[holding.ClusterID] = cluster
+		}
+		resource.AddTo(cluster.Resources, requested)
+		cluster.Reservations++
+	}
+}
+
+// Grow counts additional quantities of a reservation already counted on a cluster
+func (u *Usage) Grow(clusterID string, quantities corev1.ResourceList) {
+	resource.AddTo(u.Total.Resources, quantities)
+	if cluster, ok := u.Clusters[clusterID]; ok {
+		resource.AddTo(cluster.Resources, quantities)
+	}
+}
+
+// Clone returns a deep copy of the usage
+func (u *Usage) Clone() *Usage {
+	clone := &Usage{
+		Total:    UsageAmounts{Resources: u.Total.Resources.DeepCopy(), Reservations: u.Total.Reservations},
+		Clusters: make(map[string]*UsageAmounts, len(u.Clusters)),
+	}
+	for clusterID, cluster := range u.Clusters {
+		clone.Clusters[clusterID] = &UsageAmounts{
+			Resources:    cluster.Resources.DeepCopy(),
+			Reservations: cluster.Reservations,
+		}
+	}
+	return clone
+}
+
+// ExceededQuota describes the limits of a quota that usage exceeds, in total or
+// on one of the given clusters, or returns "" if usage is within the quota
+func ExceededQuota(quota *brokerv1alpha1.RequesterQuota, usage *Usage, clusterIDs []string) string {
+	var exceeded []string
+	exceeded = append(exceeded, exceededLimits(quota.Spec.Hard, usage.Total, "")...)
+
+	for _, clusterID := range clusterIDs {
+		used, ok := usage.Clusters[clusterID]
+		if !ok {
+			continue
+		}
+		for _, clusterQuota := range quota.Spec.Clusters {
+			if clusterQuota.ClusterID == clusterID {
+				exceeded = append(exceeded, exceededLimits(clusterQuota.Hard, *used, clusterID)...)
+			}
+		}
+	}
+	return strings.Join(exceeded, ", ")
+}
+
+// exceededLimits lists the limits used exceeds, e.g. "cpu 12 > 10 on cluster-a"
+func exceededLimits(hard brokerv1alpha1.QuotaResources, used UsageAmounts, clusterID string) []string {
+	suffix := ""
+	if clusterID != "" {
+		suffix = " on " + clusterID
+	}
+
+	var exceeded []string
+	limits := []struct {
+		name  corev1.ResourceName
+		limit *apiresource.Quantity
+	}{
+		{resource.ResourceCPU, hard.CPU},
+		{resource.ResourceMemory, hard.Memory},
+		{resource.ResourceGPU, hard.GPU},
+	}
+	for _, limit := range limits {
+		if limit.limit == nil {
+			continue
+		}
+		if quantity := used.Resources[limit.name]; quantity.Cmp(*limit.limit) > 0 {
+			exceeded = append(exceeded, fmt.Sprintf("%s %s > %s%s",
+				limit.name, quantity.String(), limit.limit.String(), suffix))
+		}
+	}
+	if hard.Reservations != nil && used.Reservations > *hard.Reservations {
+		exceeded = append(exceeded, fmt.Sprintf("reservations %d > %d%s", used.Reservations, *hard.Reservations, suffix))
+	}
+	return exceeded
+}
+
+// QuotaResources converts usage amounts into the representation of a quota status
+func QuotaResources(used UsageAmounts) brokerv1alpha1.QuotaResources {
+	cpu := used.Resources[resource.ResourceCPU]
+	memory := used.Resources[resource.ResourceMemory]
+	reservations := used.Reservations
+	quotaResources := brokerv1alpha1.QuotaResources{
+		CPU:          &cpu,
+		Memory:       &memory,
+		Reservations: &reservations,
+	}
+	if gpu, ok := used.Resources[resource.ResourceGPU]; ok {
+		quotaResources.GPU = &gpu
+	}
+	return quotaResources
+}
+
+// withinClusterQuotas reports whether a requester may additionally hold
+// requested on a cluster without exceeding the per-cluster limits of its quotas
+func withinClusterQuotas(
+	quotas []brokerv1alpha1.RequesterQuota,
+	usage *Usage,
+	clusterID string,
+	requested brokerv1alpha1.RequestedResourceQuantities,
+) bool {
+	return exceededClusterQuota(quotas, usage, clusterID, requested) == ""
+}
+
+// exceededClusterQuota describes the per-cluster limits holding requested on a
+// cluster would exceed, or returns "" if it stays within every quota
+func exceededClusterQuota(
+	quotas []brokerv1alpha1.RequesterQuota,
+	usage *Usage,
+	clusterID string,
+	requested brokerv1alpha1.RequestedResourceQuantities,
+) string {
+	if len(quotas) == 0 {
+		return ""
+	}
+	with := usage.Clone()
+	with.Add([]brokerv1alpha1.ReservationFragment{{ClusterID: clusterID, LockedResources: requested}}, 1)
+
+	// The totals are checked before placement, only the cluster's limits matter here
+	for i := range quotas {
+		clusterOnly := quotas[i].DeepCopy()
+		clusterOnly.Spec.Hard = brokerv1alpha1.QuotaResources{}
+		if exceeded := ExceededQuota(clusterOnly, with, []string{clusterID}); exceeded != "" {
+			return fmt.Sprintf("quota %s/%s: %s", quotas[i].Namespace, quotas[i].Name, exceeded)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,235 @@
+package broker
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+
+	apiresource "k8s.io/apimachinery/pkg/api/resource"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/pricing"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/resource"
+)
+
+// Built-in scoring strategy names
+const (
+	// StrategySpread prefers clusters with the most headroom left after placement (least-allocated)
+	StrategySpread = "spread"
+	// StrategyBinPack prefers clusters that end up the most utilized after placement (most-allocated)
+	StrategyBinPack = "binpack"
+	// StrategyCost prefers the cheapest cluster for the requested resources, optionally
+	// traded off against headroom (see CostScorer)
+	StrategyCost = "cost"
+	// StrategyBalanced prefers clusters whose utilization stays even across the requested resources
+	StrategyBalanced = "balanced"
+
+	// DefaultStrategy is used when neither the broker nor the reservation selects a strategy
+	DefaultStrategy = StrategySpread
+)
+
+// Scorer ranks the clusters that passed filtering for a reservation request.
+// Implementations return one score per candidate, in the same order, where a
+// higher score means a better placement. Scores should be normalized to [0, 1]
+// so that the priority bonus keeps the same weight across strategies.
+type Scorer interface {
+	Score(spec *brokerv1alpha1.ReservationSpec, candidates []*brokerv1alpha1.ClusterAdvertisement) []float64
+}
+
+// ScorerFunc adapts a per-cluster scoring function to the Scorer interface
+type ScorerFunc func(spec *brokerv1alpha1.ReservationSpec, cluster *brokerv1alpha1.ClusterAdvertisement) float64
+
+// Score calls f for every candidate
+func (f ScorerFunc) Score(
+	spec *brokerv1alpha1.ReservationSpec,
+	candidates []*brokerv1alpha1.ClusterAdvertisement,
+) []float64 {
+	scores := make([]float64, len(candidates))
+	for i, cluster := range candidates {
+		scores[i] = f(spec, cluster)
+	}
+	return scores
+}
+
+var (
+	scorersMu sync.RWMutex
+	scorers   = map[string]Scorer{}
+)
+
+func init() {
+	RegisterScorer(StrategySpread, ScorerFunc(spreadScore))
+	RegisterScorer(StrategyBinPack, ScorerFunc(binPackScore))
+	defaultExchange, _ := pricing.NewExchangeTable(pricing.DefaultBaseCurrency, "")
+	RegisterScorer(StrategyCost, NewCostScorer(defaultExchange, 1, 0))
+	RegisterScorer(StrategyBalanced, ScorerFunc(balancedScore))
+}
+
+// RegisterScorer makes a scoring strategy available under the given name,
+// replacing any strategy previously registered with the same name
+func RegisterScorer(name string, scorer Scorer) {
+	scorersMu.Lock()
+	defer scorersMu.Unlock()
+	scorers[name] = scorer
+}
+
+// LookupScorer returns the scoring strategy registered under name
+func LookupScorer(name string) (Scorer, error) {
+	scorersMu.RLock()
+	defer scorersMu.RUnlock()
+	scorer, ok := scorers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown scoring strategy %q", name)
+	}
+	return scorer, nil
+}
+
+// RegisteredStrategies returns the names of all registered scoring strategies, sorted
+func RegisteredStrategies() []string {
+	scorersMu.RLock()
+	defer scorersMu.RUnlock()
+	names := make([]string, 0, len(scorers))
+	for name := range scorers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// utilizationAfter returns the fraction (0-1) of allocatable that would be in use
+// once the request is placed. A zero allocatable counts as fully utilized.
+func utilizationAfter(allocatable, available, requested apiresource.Quantity) float64 {
+	allocatableFloat := allocatable.AsApproximateFloat64()
+	if allocatableFloat <= 0 {
+		return 1
+	}
+	utilization := 1.0 - ((available.AsApproximateFloat64() - requested.AsApproximateFloat64()) / allocatableFloat)
+	return clamp01(utilization)
+}
+
+// requestUtilization returns the utilization of the cluster after placement for
+// every resource the request uses: CPU and memory always, GPU, storage and
+// extended resources only when requested
+func requestUtilization(
+	spec *brokerv1alpha1.ReservationSpec,
+	cluster *brokerv1alpha1.ClusterAdvertisement,
+) []float64 {
+	allocatable := resource.ToList(cluster.Spec.Resources.Allocatable)
+	available := resource.ToList(cluster.Spec.Resources.Available)
+	requested := resource.RequestedList(spec.RequestedResources)
+
+	utilization := make([]float64, 0, len(requested))
+	for _, name := range resource.SortedNames(requested) {
+		quantity := requested[name]
+		if quantity.Sign() <= 0 && name != resource.ResourceCPU && name != resource.ResourceMemory {
+			continue
+		}
+		utilization = append(utilization, utilizationAfter(allocatable[name], available[name], quantity))
+	}
+	return utilization
+}
+
+func mean(values []float64) float64 {
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+// spreadScore favors the cluster with the most headroom left, weighting every requested dimension equally
+func spreadScore(spec *brokerv1alpha1.ReservationSpec, cluster *brokerv1alpha1.ClusterAdvertisement) float64 {
+	return 1.0 - mean(requestUtilization(spec, cluster))
+}
+
+// binPackScore favors the cluster that ends up the most utilized, keeping other clusters free
+func binPackScore(spec *brokerv1alpha1.ReservationSpec, cluster *brokerv1alpha1.ClusterAdvertisement) float64 {
+	return mean(requestUtilization(spec, cluster))
+}
+
+// balancedScore favors clusters where the utilization of the requested dimensions
+// stays even, avoiding clusters with stranded CPU, memory, GPUs or other resources
+func balancedScore(spec *brokerv1alpha1.ReservationSpec, cluster *brokerv1alpha1.ClusterAdvertisement) float64 {
+	utilization := requestUtilization(spec, cluster)
+	lowest, highest := utilization[0], utilization[0]
+	for _, v := range utilization[1:] {
+		lowest = math.Min(lowest, v)
+		highest = math.Max(highest, v)
+	}
+	return 1.0 - (highest - lowest)
+}
+
+// CostScorer trades off the projected hourly price of a request against the
+// headroom left on the cluster. The cost term is relative to the cheapest
+// candidate (1 for the cheapest, lower for pricier clusters); clusters without
+// usable cost information get a cost term of zero so that priced clusters are
+// preferred.
+type CostScorer struct {
+	// Exchange converts advertised prices to a common currency
+	Exchange *pricing.ExchangeTable
+	// CostWeight is the weight of the cost term
+	CostWeight float64
+	// HeadroomWeight is the weight of the spread (headroom) term
+	HeadroomWeight float64
+}
+
+// NewCostScorer returns a CostScorer. Negative weights count as zero, and if both
+// weights are zero the scorer falls back to pure cost.
+func NewCostScorer(exchange *pricing.ExchangeTable, costWeight, headroomWeight float64) *CostScorer {
+	costWeight = math.Max(costWeight, 0)
+	headroomWeight = math.Max(headroomWeight, 0)
+	if costWeight == 0 && headroomWeight == 0 {
+		costWeight = 1
+	}
+	return &CostScorer{
+		Exchange:       exchange,
+		CostWeight:     costWeight,
+		HeadroomWeight: headroomWeight,
+	}
+}
+
+// Score implements Scorer
+func (s *CostScorer) Score(
+	spec *brokerv1alpha1.ReservationSpec,
+	candidates []*brokerv1alpha1.ClusterAdvertisement,
+) []float64 {
+	costs := make([]float64, len(candidates))
+	priced := make([]bool, len(candidates))
+	cheapest := -1.0
+	for i, cluster := range candidates {
+		cost, err := s.Exchange.HourlyCost(cluster.Spec.Cost, spec.RequestedResources)
+		if err != nil {
+			continue
+		}
+		costs[i], priced[i] = cost, true
+		if cheapest < 0 || cost < cheapest {
+			cheapest = cost
+		}
+	}
+
+	totalWeight := s.CostWeight + s.HeadroomWeight
+	scores := make([]float64, len(candidates))
+	for i, cluster := range candidates {
+		costTerm := 0.0
+		switch {
+		case !priced[i]:
+		case costs[i] == 0:
+			costTerm = 1
+		default:
+			costTerm = cheapest / costs[i]
+		}
+		headroomTerm := spreadScore(spec, cluster)
+		scores[i] = (s.CostWeight*costTerm + s.HeadroomWeight*headroomTerm) / totalWeight
+	}
+	return scores
+}
+
+func clamp01(v float64) float64 {
+	if v < 0 {
+		return 0
+	}
+	if v > 1 {
+		return 1
+	}
+	return v
+}
@@ -0,0 +1,192 @@
+package broker
+
+import (
+	"slices"
+	"sort"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/pricing"
+)
+
+// scoringCluster is a cluster with 16 cores and 64Gi allocatable, of which the
+// given amounts are still available
+func scoringCluster(name, cpu, memory string, cost *brokerv1alpha1.CostInfo) *brokerv1alpha1.ClusterAdvertisement {
+	return &brokerv1alpha1.ClusterAdvertisement{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: brokerv1alpha1.ClusterAdvertisementSpec{
+			ClusterID: name,
+			Resources: brokerv1alpha1.ResourceMetrics{
+				Allocatable: brokerv1alpha1.ResourceQuantities{CPU: resource.MustParse("16"), Memory: resource.MustParse("64Gi")},
+				Available:   brokerv1alpha1.ResourceQuantities{CPU: resource.MustParse(cpu), Memory: resource.MustParse(memory)},
+			},
+			Cost: cost,
+		},
+	}
+}
+
+// ranking orders the candidates by descending score
+func ranking(scores []float64, candidates []*brokerv1alpha1.ClusterAdvertisement) []string {
+	order := make([]int, len(candidates))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return scores[order[a]] > scores[order[b]] })
+	names := make([]string, len(order))
+	for i, index := range order {
+		names[i] = candidates[index].Name
+	}
+	return names
+}
+
+func TestScorers(t *testing.T) {
+	exchange, err := pricing.NewExchangeTable("USD", "EUR=2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec := &brokerv1alpha1.ReservationSpec{
+		RequestedResources: brokerv1alpha1.RequestedResourceQuantities{
+			CPU:    resource.MustParse("2"),
+			Memory: resource.MustParse("8Gi"),
+		},
+	}
+	// After placing the request, utilization is 25% on roomy, 87.5% on tight,
+	// and 25% of the CPU but 87.5% of the memory on skewed
+	roomy := scoringCluster("roomy", "14", "56Gi", nil)
+	tight := scoringCluster("tight", "4", "16Gi", nil)
+	skewed := scoringCluster("skewed", "14", "16Gi", nil)
+
+	price := func(cpuCost, currency string) *brokerv1alpha1.CostInfo {
+		return &brokerv1alpha1.CostInfo{CPUCost: cpuCost, Currency: currency}
+	}
+	cheap := scoringCluster("cheap", "4", "16Gi", price("0.01", "USD"))
+	// 0.01 EUR is worth 0.02 USD
+	pricey := scoringCluster("pricey", "14", "56Gi", price("0.01", "EUR"))
+	unpriced := scoringCluster("unpriced", "14", "56Gi", nil)
+
+	tests := []struct {
+		name       string
+		scorer     Scorer
+		candidates []*brokerv1alpha1.ClusterAdvertisement
+		want       []string
+	}{
+		{
+			name:       "spread prefers headroom",
+			scorer:     ScorerFunc(spreadScore),
+			candidates: []*brokerv1alpha1.ClusterAdvertisement{tight, skewed, roomy},
+			want:       []string{"roomy", "skewed", "tight"},
+		},
+		{
+			name:       "binpack prefers utilization",
+			scorer:     ScorerFunc(binPackScore),
+			candidates: []*brokerv1alpha1.ClusterAdvertisement{roomy, skewed, tight},
+			want:       []string{"tight", "skewed", "roomy"},
+		},
+		{
+			name:       "balanced avoids stranded resources",
+			scorer:     ScorerFunc(balancedScore),
+			candidates: []*brokerv1alpha1.ClusterAdvertisement{skewed, tight},
+			want:       []string{"tight", "skewed"},
+		},
+		{
+			name:       "cost prefers the cheapest after conversion, unpriced last",
+			scorer:     NewCostScorer(exchange, 1, 0),
+			candidates: []*brokerv1alpha1.ClusterAdvertisement{unpriced, pricey, cheap},
+			want:       []string{"cheap", "pricey", "unpriced"},
+		},
+		{
+			name:       "cost with only headroom weight spreads",
+			scorer:     NewCostScorer(exchange, 0, 1),
+			candidates: []*brokerv1alpha1.ClusterAdvertisement{cheap, pricey},
+			want:       []string{"pricey", "cheap"},
+		},
+		{
+			name:       "cost without weights falls back to pure cost",
+			scorer:     NewCostScorer(exchange, -1, 0),
+			candidates: []*brokerv1alpha1.ClusterAdvertisement{pricey, cheap},
+			want:       []string{"cheap", "pricey"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scores := tt.scorer.Score(spec, tt.candidates)
+			if len(scores) != len(tt.candidates) {
+				t.Fatalf("Score() returned %d scores for %d candidates", len(scores), len(tt.candidates))
+			}
+			for i, score := range scores {
+				if score < 0 || score > 1 {
+					t.Errorf("score of %s = %v, want within [0, 1]", tt.candidates[i].Name, score)
+				}
+			}
+			if got := ranking(scores, tt.candidates); !slices.Equal(got, tt.want) {
+				t.Errorf("ranking = %v, want %v (scores %v)", got, tt.want, scores)
+			}
+		})
+	}
+}
+
+func TestScoringExtendedResources(t *testing.T) {
+	withGPUs := func(name, gpus string) *brokerv1alpha1.ClusterAdvertisement {
+		cluster := scoringCluster(name, "14", "56Gi", nil)
+		cluster.Spec.Resources.Allocatable.GPU = ptrTo(resource.MustParse("4"))
+		cluster.Spec.Resources.Available.GPU = ptrTo(resource.MustParse(gpus))
+		return cluster
+	}
+	candidates := []*brokerv1alpha1.ClusterAdvertisement{withGPUs("busy", "1"), withGPUs("free", "4")}
+	spec := &brokerv1alpha1.ReservationSpec{
+		RequestedResources: brokerv1alpha1.RequestedResourceQuantities{
+			CPU:    resource.MustParse("2"),
+			Memory: resource.MustParse("8Gi"),
+			GPU:    ptrTo(resource.MustParse("1")),
+		},
+	}
+
+	// CPU and memory are the same everywhere, so the GPUs decide
+	if got := ranking(ScorerFunc(spreadScore).Score(spec, candidates), candidates); !slices.Equal(got, []string{"free", "busy"}) {
+		t.Errorf("spread ranking = %v, want [free busy]", got)
+	}
+	if got := ranking(ScorerFunc(binPackScore).Score(spec, candidates), candidates); !slices.Equal(got, []string{"busy", "free"}) {
+		t.Errorf("binpack ranking = %v, want [busy free]", got)
+	}
+}
+
+func TestScorerRegistry(t *testing.T) {
+	for _, name := range []string{StrategySpread, StrategyBinPack, StrategyCost, StrategyBalanced} {
+		if _, err := LookupScorer(name); err != nil {
+			t.Errorf("LookupScorer(%q) error = %v", name, err)
+		}
+	}
+	if _, err := LookupScorer("no-such-strategy"); err == nil {
+		t.Error("LookupScorer() of an unknown strategy succeeded")
+	}
+
+	constant := ScorerFunc(func(*brokerv1alpha1.ReservationSpec, *brokerv1alpha1.ClusterAdvertisement) float64 {
+		return 0.5
+	})
+	RegisterScorer("test-constant", constant)
+	t.Cleanup(func() {
+		scorersMu.Lock()
+		defer scorersMu.Unlock()
+		delete(scorers, "test-constant")
+	})
+
+	scorer, err := LookupScorer("test-constant")
+	if err != nil {
+		t.Fatalf("LookupScorer() of a registered strategy error = %v", err)
+	}
+	if scores := scorer.Score(&brokerv1alpha1.ReservationSpec{}, []*brokerv1alpha1.ClusterAdvertisement{{}}); scores[0] != 0.5 {
+		t.Errorf("registered scorer returned %v, want 0.5", scores[0])
+	}
+	strategies := RegisteredStrategies()
+	if !slices.Contains(strategies, "test-constant") || !slices.IsSorted(strategies) {
+		t.Errorf("RegisteredStrategies() = %v, want a sorted list containing test-constant", strategies)
+	}
+}
+
+func ptrTo(quantity resource.Quantity) *resource.Quantity {
+	return &quantity
+}
@@ -0,0 +1,98 @@
+package broker
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+)
+
+// Snapshot is the state placement decisions are made on: every cluster, every
+// claim on them and every requester quota. Evaluating several requests against
+// one snapshot lists these objects once instead of once per request.
+type Snapshot struct {
+	Clusters []brokerv1alpha1.ClusterAdvertisement
+	Claims   *Claims
+	Quotas   []brokerv1alpha1.RequesterQuota
+}
+
+// TakeSnapshot lists every ClusterAdvertisement, claim and RequesterQuota
+func (d *DecisionEngine) TakeSnapshot(ctx context.Context) (*Snapshot, error) {
+	advList := &brokerv1alpha1.ClusterAdvertisementList{}
+	if err := d.Client.List(ctx, advList); err != nil {
+		return nil, fmt.Errorf("failed to list cluster advertisements: %w", err)
+	}
+
+	claims, err := d.ListClaims(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	quotaList := &brokerv1alpha1.RequesterQuotaList{}
+	if err := d.Client.List(ctx, quotaList); err != nil {
+		return nil, fmt.Errorf("failed to list requester quotas: %w", err)
+	}
+
+	return &Snapshot{Clusters: advList.Items, Claims: claims, Quotas: quotaList.Items}, nil
+}
+
+// Cluster returns the cluster with the given ID, or nil if there is none
+func (s *Snapshot) Cluster(clusterID string) *brokerv1alpha1.ClusterAdvertisement {
+	for i := range s.Clusters {
+		if s.Clusters[i].Spec.ClusterID == clusterID {
+			return &s.Clusters[i]
+		}
+	}
+	return nil
+}
+
+// RequesterQuotas returns the quotas that apply to a requester
+func (s *Snapshot) RequesterQuotas(requesterID string) []brokerv1alpha1.RequesterQuota {
+	return requesterQuotas(s.Quotas, requesterID)
+}
+
+// Version identifies the capacity state a snapshot holds: its clusters, its
+// quotas and every claim that is past Pending or Queued, at their resource
+// versions. Reservations that are still waiting do not change where others can
+// be placed, so a reservation is placed the same way in snapshots of the same
+// version.
+func (s *Snapshot) Version() string {
+	var keys []string
+	add := func(object metav1.Object) {
+		keys = append(keys, string(object.GetUID())+"@"+object.GetResourceVersion())
+	}
+	waiting := func(phase brokerv1alpha1.ReservationPhase) bool {
+		return phase == "" || phase == brokerv1alpha1.ReservationPhasePending ||
+			phase == brokerv1alpha1.ReservationPhaseQueued
+	}
+
+	for i := range s.Clusters {
+		add(&s.Clusters[i])
+	}
+	for i := range s.Quotas {
+		add(&s.Quotas[i])
+	}
+	for i := range s.Claims.Reservations {
+		if !waiting(s.Claims.Reservations[i].Status.Phase) {
+			add(&s.Claims.Reservations[i])
+		}
+	}
+	for i := range s.Claims.Groups {
+		if !waiting(s.Claims.Groups[i].Status.Phase) {
+			add(&s.Claims.Groups[i])
+		}
+	}
+
+	sort.Strings(keys)
+	hash := sha256.New()
+	for _, key := range keys {
+		hash.Write([]byte(key))
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
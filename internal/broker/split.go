@@ -0,0 +1,144 @@
+package broker
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/resource"
+)
+
+// PlanSplit divides a request that no single cluster can host into fragments on
+// different clusters. The request is cut into the chunks its split policy allows
+// (see resource.Chunks) and every fragment holds a whole number of them. Clusters
+// that can take the most chunks are used first, the cluster score breaking ties,
+// so that the request ends up in as few fragments as possible.
+func (d *DecisionEngine) PlanSplit(
+	ctx context.Context,
+	spec *brokerv1alpha1.ReservationSpec,
+) ([]brokerv1alpha1.ReservationFragment, error) {
+	snapshot, err := d.TakeSnapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return d.PlanSplitIn(snapshot, spec)
+}
+
+// PlanSplitIn is PlanSplit evaluated against a snapshot
+func (d *DecisionEngine) PlanSplitIn(
+	snapshot *Snapshot,
+	spec *brokerv1alpha1.ReservationSpec,
+) ([]brokerv1alpha1.ReservationFragment, error) {
+
+	policy := spec.SplitPolicy
+	if policy == nil {
+		return nil, fmt.Errorf("reservation does not allow splitting")
+	}
+
+	requested := resource.RequestedList(spec.RequestedResources)
+	chunks := resource.Chunks(requested, resource.RequestedList(policy.MinChunk))
+	if chunks < 2 {
+		return nil, fmt.Errorf("request is smaller than two chunks of %s", resource.FormatRequested(policy.MinChunk))
+	}
+
+	scorer, err := d.scorerFor(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(snapshot.Clusters) == 0 {
+		return nil, fmt.Errorf("no clusters available")
+	}
+
+	claims := snapshot.Claims
+	now := time.Now()
+
+	clusterAffinity, err := newAffinity(spec, snapshot.Clusters, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	quotas := snapshot.RequesterQuotas(spec.RequesterID)
+	usage := RequesterUsage(spec.RequesterID, claims)
+
+	// fragmentSpec is the spec of the fragment holding count chunks after the first from
+	fragmentSpec := func(from, count int64) *brokerv1alpha1.ReservationSpec {
+		fragment := *spec
+		fragment.RequestedResources = resource.RequestedFromList(resource.Slice(requested, from, count, chunks))
+		return &fragment
+	}
+
+	// fittingChunks is the largest number of chunks after the first from a cluster can host
+	fittingChunks := func(cluster *brokerv1alpha1.ClusterAdvertisement, from int64) int64 {
+		low, high := int64(0), chunks-from
+		for low < high {
+			middle := (low + high + 1) / 2
+			fragment := fragmentSpec(from, middle)
+			if d.CanHost(cluster, fragment, claims, "", now) &&
+				withinClusterQuotas(quotas, usage, cluster.Spec.ClusterID, fragment.RequestedResources) {
+				low = middle
+			} else {
+				high = middle - 1
+			}
+		}
+		return low
+	}
+
+	var eligible []*brokerv1alpha1.ClusterAdvertisement
+	capacity := map[string]int64{}
+	for i := range snapshot.Clusters {
+		cluster := &snapshot.Clusters[i]
+		if !d.isEligible(cluster, spec) || !clusterAffinity.Allows(cluster) {
+			continue
+		}
+		if fitting := fittingChunks(cluster, 0); fitting > 0 {
+			eligible = append(eligible, cluster)
+			capacity[cluster.Spec.ClusterID] = fitting
+		}
+	}
+	if len(eligible) == 0 {
+		return nil, fmt.Errorf("no cluster can host a single chunk of %s", resource.FormatRequested(policy.MinChunk))
+	}
+
+	scores := scorer.Score(spec, eligible)
+	for i, cluster := range eligible {
+		scores[i] += clusterAffinity.Score(cluster) - taintPenalty(cluster, spec)
+	}
+	order := make([]int, len(eligible))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		ca, cb := capacity[eligible[order[a]].Spec.ClusterID], capacity[eligible[order[b]].Spec.ClusterID]
+		if ca != cb {
+			return ca > cb
+		}
+		return scores[order[a]] > scores[order[b]]
+	})
+
+	var fragments []brokerv1alpha1.ReservationFragment
+	placed := int64(0)
+	for _, i := range order {
+		if placed == chunks || len(fragments) == int(policy.MaxFragments) {
+			break
+		}
+		cluster := eligible[i]
+		count := fittingChunks(cluster, placed)
+		if count == 0 {
+			continue
+		}
+		fragments = append(fragments, brokerv1alpha1.ReservationFragment{
+			ClusterID:       cluster.Spec.ClusterID,
+			LockedResources: fragmentSpec(placed, count).RequestedResources,
+		})
+		placed += count
+	}
+
+	if placed < chunks {
+		return nil, fmt.Errorf("at most %d fragments cover only %d of %d chunks",
+			policy.MaxFragments, placed, chunks)
+	}
+	return fragments, nil
+}
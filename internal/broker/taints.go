@@ -0,0 +1,91 @@
+package broker
+
+import (
+	"fmt"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+)
+
+// preferNoReservePenalty is subtracted from the score of a cluster with a
+// PreferNoReserve taint the reservation does not tolerate. Strategy scores are
+// normalized to [0, 1], so such clusters rank behind every untainted one.
+const preferNoReservePenalty = 1.0
+
+// Tolerates reports whether a toleration matches a taint
+func Tolerates(toleration brokerv1alpha1.ReservationToleration, taint brokerv1alpha1.ClusterTaint) bool {
+	if toleration.Effect != "" && toleration.Effect != taint.Effect {
+		return false
+	}
+	if toleration.Key != "" && toleration.Key != taint.Key {
+		return false
+	}
+	switch toleration.Operator {
+	case brokerv1alpha1.TolerationOpExists:
+		return true
+	case "", brokerv1alpha1.TolerationOpEqual:
+		return toleration.Key != "" && toleration.Value == taint.Value
+	}
+	return false
+}
+
+// UntoleratedTaint returns the first taint of a cluster with the given effect
+// that none of the tolerations matches, or nil
+func UntoleratedTaint(
+	cluster *brokerv1alpha1.ClusterAdvertisement,
+	tolerations []brokerv1alpha1.ReservationToleration,
+	effect brokerv1alpha1.TaintEffect,
+) *brokerv1alpha1.ClusterTaint {
+	for i := range cluster.Spec.Taints {
+		taint := &cluster.Spec.Taints[i]
+		if taint.Effect != effect {
+			continue
+		}
+		tolerated := false
+		for _, toleration := range tolerations {
+			if Tolerates(toleration, *taint) {
+				tolerated = true
+				break
+			}
+		}
+		if !tolerated {
+			return taint
+		}
+	}
+	return nil
+}
+
+// FormatTaint renders a taint as key=value:Effect
+func FormatTaint(taint brokerv1alpha1.ClusterTaint) string {
+	if taint.Value == "" {
+		return fmt.Sprintf("%s:%s", taint.Key, taint.Effect)
+	}
+	return fmt.Sprintf("%s=%s:%s", taint.Key, taint.Value, taint.Effect)
+}
+
+// ValidateTolerations checks that every toleration can match a taint
+func ValidateTolerations(tolerations []brokerv1alpha1.ReservationToleration) error {
+	for i, toleration := range tolerations {
+		switch toleration.Operator {
+		case "", brokerv1alpha1.TolerationOpEqual:
+			if toleration.Key == "" {
+				return fmt.Errorf("tolerations[%d]: operator Equal needs a key", i)
+			}
+		case brokerv1alpha1.TolerationOpExists:
+			if toleration.Value != "" {
+				return fmt.Errorf("tolerations[%d]: operator Exists must not set a value", i)
+			}
+		default:
+			return fmt.Errorf("tolerations[%d]: unknown operator %q", i, toleration.Operator)
+		}
+	}
+	return nil
+}
+
+// taintPenalty is the score penalty of a cluster whose PreferNoReserve taints
+// the reservation does not tolerate
+func taintPenalty(cluster *brokerv1alpha1.ClusterAdvertisement, spec *brokerv1alpha1.ReservationSpec) float64 {
+	if UntoleratedTaint(cluster, spec.Tolerations, brokerv1alpha1.TaintEffectPreferNoReserve) != nil {
+		return preferNoReservePenalty
+	}
+	return 0
+}
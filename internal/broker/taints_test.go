@@ -0,0 +1,182 @@
+package broker
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+)
+
+func TestTolerates(t *testing.T) {
+	gpu := brokerv1alpha1.ClusterTaint{Key: "dedicated", Value: "gpu", Effect: brokerv1alpha1.TaintEffectNoReserve}
+
+	tests := []struct {
+		name       string
+		toleration brokerv1alpha1.ReservationToleration
+		want       bool
+	}{
+		{
+			name:       "equal key and value",
+			toleration: brokerv1alpha1.ReservationToleration{Key: "dedicated", Value: "gpu"},
+			want:       true,
+		},
+		{
+			name:       "other value",
+			toleration: brokerv1alpha1.ReservationToleration{Key: "dedicated", Value: "batch"},
+		},
+		{
+			name: "exists on the key",
+			toleration: brokerv1alpha1.ReservationToleration{
+				Key: "dedicated", Operator: brokerv1alpha1.TolerationOpExists,
+			},
+			want: true,
+		},
+		{
+			name:       "exists without a key tolerates everything",
+			toleration: brokerv1alpha1.ReservationToleration{Operator: brokerv1alpha1.TolerationOpExists},
+			want:       true,
+		},
+		{
+			name: "matching effect",
+			toleration: brokerv1alpha1.ReservationToleration{
+				Key: "dedicated", Value: "gpu", Effect: brokerv1alpha1.TaintEffectNoReserve,
+			},
+			want: true,
+		},
+		{
+			name: "other effect",
+			toleration: brokerv1alpha1.ReservationToleration{
+				Key: "dedicated", Value: "gpu", Effect: brokerv1alpha1.TaintEffectPreferNoReserve,
+			},
+		},
+		{
+			name:       "equal without a key",
+			toleration: brokerv1alpha1.ReservationToleration{Value: "gpu"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Tolerates(tt.toleration, gpu); got != tt.want {
+				t.Errorf("Tolerates() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateTolerations(t *testing.T) {
+	tests := []struct {
+		name        string
+		toleration  brokerv1alpha1.ReservationToleration
+		wantInvalid bool
+	}{
+		{name: "equal", toleration: brokerv1alpha1.ReservationToleration{Key: "dedicated", Value: "gpu"}},
+		{name: "equal without a key", toleration: brokerv1alpha1.ReservationToleration{Value: "gpu"}, wantInvalid: true},
+		{
+			name:       "exists",
+			toleration: brokerv1alpha1.ReservationToleration{Operator: brokerv1alpha1.TolerationOpExists},
+		},
+		{
+			name: "exists with a value",
+			toleration: brokerv1alpha1.ReservationToleration{
+				Key: "dedicated", Value: "gpu", Operator: brokerv1alpha1.TolerationOpExists,
+			},
+			wantInvalid: true,
+		},
+		{
+			name:        "unknown operator",
+			toleration:  brokerv1alpha1.ReservationToleration{Key: "dedicated", Operator: "In"},
+			wantInvalid: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateTolerations([]brokerv1alpha1.ReservationToleration{tt.toleration})
+			if (err != nil) != tt.wantInvalid {
+				t.Errorf("ValidateTolerations() error = %v, want invalid %v", err, tt.wantInvalid)
+			}
+		})
+	}
+}
+
+func TestSelectBestClusterTaints(t *testing.T) {
+	cluster := func(name, cpu string, taints ...brokerv1alpha1.ClusterTaint) brokerv1alpha1.ClusterAdvertisement {
+		cluster := scoringCluster(name, cpu, "56Gi", nil)
+		cluster.Spec.Taints = taints
+		cluster.Status.Active = true
+		return *cluster
+	}
+	dedicated := brokerv1alpha1.ClusterTaint{Key: "dedicated", Value: "gpu", Effect: brokerv1alpha1.TaintEffectNoReserve}
+	maintenance := brokerv1alpha1.ClusterTaint{Key: "maintenance", Effect: brokerv1alpha1.TaintEffectPreferNoReserve}
+
+	// Spread prefers the most headroom: dedicated, then draining, then busy
+	snapshot := &Snapshot{
+		Clusters: []brokerv1alpha1.ClusterAdvertisement{
+			cluster("dedicated", "14", dedicated),
+			cluster("draining", "12", maintenance),
+			cluster("busy", "4"),
+		},
+		Claims: &Claims{},
+	}
+
+	tests := []struct {
+		name         string
+		tolerations  []brokerv1alpha1.ReservationToleration
+		wantSelected string
+		wantFiltered bool
+		wantPenalty  float64
+	}{
+		{
+			name:         "untolerated taints",
+			wantSelected: "busy",
+			wantFiltered: true,
+			wantPenalty:  preferNoReservePenalty,
+		},
+		{
+			name:         "tolerating the PreferNoReserve taint",
+			tolerations:  []brokerv1alpha1.ReservationToleration{{Key: "maintenance", Operator: brokerv1alpha1.TolerationOpExists}},
+			wantSelected: "draining",
+			wantFiltered: true,
+		},
+		{
+			name:         "tolerating every taint",
+			tolerations:  []brokerv1alpha1.ReservationToleration{{Operator: brokerv1alpha1.TolerationOpExists}},
+			wantSelected: "dedicated",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := &brokerv1alpha1.ReservationSpec{
+				RequesterID:     "requester",
+				ScoringStrategy: StrategySpread,
+				Tolerations:     tt.tolerations,
+				RequestedResources: brokerv1alpha1.RequestedResourceQuantities{
+					CPU:    resource.MustParse("2"),
+					Memory: resource.MustParse("4Gi"),
+				},
+			}
+			selected, decision, err := (&DecisionEngine{}).SelectBestClusterIn(snapshot, spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if selected.Name != tt.wantSelected {
+				t.Errorf("selected %s, want %s", selected.Name, tt.wantSelected)
+			}
+			for _, candidate := range decision.Candidates {
+				switch candidate.Cluster.Name {
+				case "dedicated":
+					if filtered := candidate.FilteredBy == brokerv1alpha1.PlacementFilterTainted; filtered != tt.wantFiltered {
+						t.Errorf("dedicated filtered by %q, want tainted filter %v", candidate.FilteredBy, tt.wantFiltered)
+					}
+				case "draining":
+					if candidate.Score.TaintPenalty != tt.wantPenalty {
+						t.Errorf("draining taint penalty = %v, want %v", candidate.Score.TaintPenalty, tt.wantPenalty)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,231 @@
+package broker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/resource"
+)
+
+// Claims are the objects that hold or book capacity on clusters
+type Claims struct {
+	Reservations []brokerv1alpha1.Reservation
+	Groups       []brokerv1alpha1.ReservationGroup
+}
+
+// ListClaims lists every Reservation and ReservationGroup
+func (d *DecisionEngine) ListClaims(ctx context.Context) (*Claims, error) {
+	reservationList := &brokerv1alpha1.ReservationList{}
+	if err := d.Client.List(ctx, reservationList); err != nil {
+		return nil, fmt.Errorf("failed to list reservations: %w", err)
+	}
+	groupList := &brokerv1alpha1.ReservationGroupList{}
+	if err := d.Client.List(ctx, groupList); err != nil {
+		return nil, fmt.Errorf("failed to list reservation groups: %w", err)
+	}
+	return &Claims{Reservations: reservationList.Items, Groups: groupList.Items}, nil
+}
+
+// Booking is capacity claimed on a cluster over [Start, End). A zero End never ends.
+type Booking struct {
+	Start     time.Time
+	End       time.Time
+	Resources corev1.ResourceList
+}
+
+// activeAt reports whether the booking claims capacity at instant t
+func (b Booking) activeAt(t time.Time) bool {
+	return !t.Before(b.Start) && (b.End.IsZero() || t.Before(b.End))
+}
+
+// RequestedWindow returns the interval a reservation asks for. It starts at
+// spec.StartTime, or now if that is unset or already passed, and ends at
+// spec.EndTime, or Duration after the requested start. A zero end means the
+// reservation is open-ended.
+func RequestedWindow(spec *brokerv1alpha1.ReservationSpec, now time.Time) (start, end time.Time) {
+	base := now
+	if spec.StartTime != nil {
+		base = spec.StartTime.Time
+	}
+
+	switch {
+	case spec.EndTime != nil:
+		end = spec.EndTime.Time
+	case spec.Duration != nil:
+		end = base.Add(spec.Duration.Duration)
+	}
+
+	start = base
+	if start.Before(now) {
+		start = now
+	}
+	return start, end
+}
+
+// StartsLater reports whether a reservation's window has not opened yet
+func StartsLater(spec *brokerv1alpha1.ReservationSpec, now time.Time) bool {
+	return spec.StartTime != nil && spec.StartTime.After(now)
+}
+
+// Capacity is what a cluster can hand out to reservations over time:
+// Allocatable - Allocated for every advertised resource
+func Capacity(cluster *brokerv1alpha1.ClusterAdvertisement) corev1.ResourceList {
+	capacity := resource.ToList(cluster.Spec.Resources.Allocatable)
+	resource.SubFrom(capacity, resource.ToList(cluster.Spec.Resources.Allocated))
+	return capacity
+}
+
+// ClusterBookings collects the capacity claimed on a cluster: reservations and
+// group members holding resources claim them from now until they expire,
+// Scheduled reservations claim them over their requested window. The
+// reservation with UID self is left out so it can be checked against everybody else.
+func ClusterBookings(
+	clusterID string,
+	claims *Claims,
+	self types.UID,
+	now time.Time,
+) []Booking {
+	var bookings []Booking
+	for i := range claims.Groups {
+		group := &claims.Groups[i]
+		if !resource.HoldsResources(group.Status.Phase) {
+			continue
+		}
+		for _, placement := range group.Status.Placements {
+			if placement.ClusterID != clusterID {
+				continue
+			}
+			booking := Booking{Start: now, Resources: resource.RequestedList(placement.LockedResources)}
+			if group.Status.ExpiresAt != nil {
+				booking.End = group.Status.ExpiresAt.Time
+			}
+			bookings = append(bookings, booking)
+		}
+	}
+
+	for i := range claims.Reservations {
+		reservation := &claims.Reservations[i]
+		if self != "" && reservation.UID == self {
+			continue
+		}
+
+		switch {
+		case resource.HoldsResources(reservation.Status.Phase):
+			for _, holding := range resource.Holdings(reservation) {
+				if holding.ClusterID != clusterID {
+					continue
+				}
+				booking := Booking{Start: now, Resources: resource.RequestedList(holding.LockedResources)}
+				if reservation.Status.ExpiresAt != nil {
+					booking.End = reservation.Status.ExpiresAt.Time
+				}
+				bookings = append(bookings, booking)
+			}
+
+		case reservation.Status.Phase == brokerv1alpha1.ReservationPhaseScheduled &&
+			reservation.Spec.TargetClusterID == clusterID:
+			start, end := RequestedWindow(&reservation.Spec, now)
+			bookings = append(bookings, Booking{
+				Start:     start,
+				End:       end,
+				Resources: resource.RequestedList(reservation.Spec.RequestedResources),
+			})
+		}
+	}
+	return bookings
+}
+
+// FitsTimeline reports whether candidate fits into capacity at every instant of
+// its window on top of the existing bookings. Usage only changes when a booking
+// starts, so it is enough to check the candidate's start and every booking start
+// inside its window.
+func FitsTimeline(capacity corev1.ResourceList, bookings []Booking, candidate Booking) bool {
+	instants := []time.Time{candidate.Start}
+	for _, booking := range bookings {
+		if booking.Start.After(candidate.Start) && (candidate.End.IsZero() || booking.Start.Before(candidate.End)) {
+			instants = append(instants, booking.Start)
+		}
+	}
+
+	for _, instant := range instants {
+		used := candidate.Resources.DeepCopy()
+		for _, booking := range bookings {
+			if booking.activeAt(instant) {
+				resource.AddTo(used, booking.Resources)
+			}
+		}
+		if !resource.Fits(capacity, used) {
+			return false
+		}
+	}
+	return true
+}
+
+// CanHost reports whether a cluster can take the reservation: immediate requests
+// need enough available resources now, and every request must fit the cluster's
+// booking timeline over its whole window, including the bookings recorded on the
+// ClusterAdvertisement (see RecordBooking). self is the UID of the reservation
+// being checked, so that its own booking is not counted twice.
+func (d *DecisionEngine) CanHost(
+	cluster *brokerv1alpha1.ClusterAdvertisement,
+	spec *brokerv1alpha1.ReservationSpec,
+	claims *Claims,
+	self types.UID,
+	now time.Time,
+) bool {
+	start, end := RequestedWindow(spec, now)
+	if !start.After(now) && !d.hasEnoughResources(cluster, spec.RequestedResources) {
+		return false
+	}
+
+	candidate := Booking{Start: start, End: end, Resources: resource.RequestedList(spec.RequestedResources)}
+	return FitsTimeline(Capacity(cluster), clusterTimeline(cluster, claims, self, now), candidate)
+}
+
+// CanResize reports whether a reservation holding resources on a cluster can
+// grow to its requested quantities for the rest of its lifetime
+func CanResize(
+	cluster *brokerv1alpha1.ClusterAdvertisement,
+	reservation *brokerv1alpha1.Reservation,
+	claims *Claims,
+	now time.Time,
+) bool {
+	resized := Booking{Start: now, Resources: resource.RequestedList(reservation.Spec.RequestedResources)}
+	if reservation.Status.ExpiresAt != nil {
+		resized.End = reservation.Status.ExpiresAt.Time
+	}
+	return FitsTimeline(Capacity(cluster), clusterTimeline(cluster, claims, reservation.UID, now), resized)
+}
+
+// CanExtend reports whether a reservation holding resources on a cluster (its
+// target, or one of its fragments) can keep them until newEnd without colliding with other bookings after its current expiry
+func CanExtend(
+	cluster *brokerv1alpha1.ClusterAdvertisement,
+	reservation *brokerv1alpha1.Reservation,
+	claims *Claims,
+	newEnd time.Time,
+	now time.Time,
+) bool {
+	start := now
+	if expiresAt := reservation.Status.ExpiresAt; expiresAt != nil && expiresAt.After(now) {
+		start = expiresAt.Time
+	}
+	if !newEnd.After(start) {
+		return true
+	}
+
+	locked := corev1.ResourceList{}
+	for _, holding := range resource.Holdings(reservation) {
+		if holding.ClusterID == cluster.Spec.ClusterID {
+			resource.AddTo(locked, resource.RequestedList(holding.LockedResources))
+		}
+	}
+
+	extension := Booking{Start: start, End: newEnd, Resources: locked}
+	return FitsTimeline(Capacity(cluster), clusterTimeline(cluster, claims, reservation.UID, now), extension)
+}
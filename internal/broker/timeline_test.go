@@ -0,0 +1,257 @@
+package broker
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+)
+
+func cpuList(cores string) corev1.ResourceList {
+	return corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cores)}
+}
+
+func TestFitsTimeline(t *testing.T) {
+	now := time.Now()
+	at := func(hours int) time.Time { return now.Add(time.Duration(hours) * time.Hour) }
+	capacity := cpuList("8")
+
+	tests := []struct {
+		name      string
+		bookings  []Booking
+		candidate Booking
+		want      bool
+	}{
+		{
+			name:      "empty timeline",
+			candidate: Booking{Start: at(0), End: at(2), Resources: cpuList("8")},
+			want:      true,
+		},
+		{
+			name:      "larger than the cluster",
+			candidate: Booking{Start: at(0), End: at(2), Resources: cpuList("9")},
+		},
+		{
+			name:      "overlapping booking starting inside the window",
+			bookings:  []Booking{{Start: at(1), End: at(3), Resources: cpuList("4")}},
+			candidate: Booking{Start: at(0), End: at(2), Resources: cpuList("6")},
+		},
+		{
+			name:      "overlapping booking active at the start",
+			bookings:  []Booking{{Start: at(0), End: at(3), Resources: cpuList("4")}},
+			candidate: Booking{Start: at(1), End: at(2), Resources: cpuList("6")},
+		},
+		{
+			name:      "booking ending when the window starts",
+			bookings:  []Booking{{Start: at(0), End: at(1), Resources: cpuList("8")}},
+			candidate: Booking{Start: at(1), End: at(2), Resources: cpuList("8")},
+			want:      true,
+		},
+		{
+			name:      "booking starting when the window ends",
+			bookings:  []Booking{{Start: at(2), End: at(3), Resources: cpuList("8")}},
+			candidate: Booking{Start: at(0), End: at(2), Resources: cpuList("8")},
+			want:      true,
+		},
+		{
+			name:      "open-ended candidate meets a later booking",
+			bookings:  []Booking{{Start: at(5), End: at(6), Resources: cpuList("4")}},
+			candidate: Booking{Start: at(0), Resources: cpuList("6")},
+		},
+		{
+			name:      "open-ended booking blocks a later window",
+			bookings:  []Booking{{Start: at(0), Resources: cpuList("4")}},
+			candidate: Booking{Start: at(5), End: at(6), Resources: cpuList("6")},
+		},
+		{
+			name: "consecutive bookings that never overlap each other",
+			bookings: []Booking{
+				{Start: at(0), End: at(1), Resources: cpuList("4")},
+				{Start: at(1), End: at(2), Resources: cpuList("4")},
+			},
+			candidate: Booking{Start: at(0), End: at(2), Resources: cpuList("4")},
+			want:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FitsTimeline(capacity, tt.bookings, tt.candidate); got != tt.want {
+				t.Errorf("FitsTimeline() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequestedWindow(t *testing.T) {
+	now := time.Now()
+	ptr := func(t time.Time) *metav1.Time { return &metav1.Time{Time: t} }
+
+	tests := []struct {
+		name      string
+		spec      brokerv1alpha1.ReservationSpec
+		wantStart time.Time
+		wantEnd   time.Time
+	}{
+		{name: "immediate and open-ended", wantStart: now},
+		{
+			name:      "immediate with a duration",
+			spec:      brokerv1alpha1.ReservationSpec{Duration: &metav1.Duration{Duration: time.Hour}},
+			wantStart: now,
+			wantEnd:   now.Add(time.Hour),
+		},
+		{
+			name: "future window with a duration",
+			spec: brokerv1alpha1.ReservationSpec{
+				StartTime: ptr(now.Add(time.Hour)),
+				Duration:  &metav1.Duration{Duration: time.Hour},
+			},
+			wantStart: now.Add(time.Hour),
+			wantEnd:   now.Add(2 * time.Hour),
+		},
+		{
+			name: "endTime wins over duration",
+			spec: brokerv1alpha1.ReservationSpec{
+				EndTime:  ptr(now.Add(3 * time.Hour)),
+				Duration: &metav1.Duration{Duration: time.Hour},
+			},
+			wantStart: now,
+			wantEnd:   now.Add(3 * time.Hour),
+		},
+		{
+			name: "past start is clamped to now, duration counts from it",
+			spec: brokerv1alpha1.ReservationSpec{
+				StartTime: ptr(now.Add(-time.Hour)),
+				Duration:  &metav1.Duration{Duration: 2 * time.Hour},
+			},
+			wantStart: now,
+			wantEnd:   now.Add(time.Hour),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end := RequestedWindow(&tt.spec, now)
+			if !start.Equal(tt.wantStart) || !end.Equal(tt.wantEnd) {
+				t.Errorf("RequestedWindow() = [%v, %v), want [%v, %v)", start, end, tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+}
+
+func TestCanHostRecordedBookings(t *testing.T) {
+	now := time.Now()
+	start := now.Add(time.Hour)
+
+	cluster := func() *brokerv1alpha1.ClusterAdvertisement {
+		return &brokerv1alpha1.ClusterAdvertisement{
+			Spec: brokerv1alpha1.ClusterAdvertisementSpec{
+				ClusterID: "cluster-a",
+				Resources: brokerv1alpha1.ResourceMetrics{
+					Allocatable: brokerv1alpha1.ResourceQuantities{CPU: resource.MustParse("8"), Memory: resource.MustParse("16Gi")},
+					Available:   brokerv1alpha1.ResourceQuantities{CPU: resource.MustParse("8"), Memory: resource.MustParse("16Gi")},
+				},
+			},
+		}
+	}
+	booked := func(phase brokerv1alpha1.ReservationPhase) brokerv1alpha1.Reservation {
+		return brokerv1alpha1.Reservation{
+			ObjectMeta: metav1.ObjectMeta{Name: "booked", UID: "booked"},
+			Spec: brokerv1alpha1.ReservationSpec{
+				TargetClusterID:    "cluster-a",
+				StartTime:          &metav1.Time{Time: start},
+				Duration:           &metav1.Duration{Duration: time.Hour},
+				RequestedResources: brokerv1alpha1.RequestedResourceQuantities{CPU: resource.MustParse("6")},
+			},
+			Status: brokerv1alpha1.ReservationStatus{Phase: phase},
+		}
+	}
+	spec := &brokerv1alpha1.ReservationSpec{
+		StartTime:          &metav1.Time{Time: start},
+		Duration:           &metav1.Duration{Duration: time.Hour},
+		RequestedResources: brokerv1alpha1.RequestedResourceQuantities{CPU: resource.MustParse("4")},
+	}
+
+	tests := []struct {
+		name   string
+		phase  brokerv1alpha1.ReservationPhase
+		exists bool
+		self   bool
+		want   bool
+	}{
+		// The cache has not seen the Scheduled status yet, only the recorded booking
+		{name: "booking in flight", phase: brokerv1alpha1.ReservationPhasePending, exists: true},
+		// Counted once, from the reservation
+		{name: "booking already Scheduled", phase: brokerv1alpha1.ReservationPhaseScheduled, exists: true},
+		{name: "booking of a failed reservation", phase: brokerv1alpha1.ReservationPhaseFailed, exists: true, want: true},
+		{name: "booking of a deleted reservation", want: true},
+		{name: "own booking", phase: brokerv1alpha1.ReservationPhasePending, exists: true, self: true, want: true},
+	}
+
+	engine := &DecisionEngine{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reservation := booked(tt.phase)
+			target := cluster()
+			RecordBooking(target, &reservation, now)
+
+			claims := &Claims{}
+			if tt.exists {
+				claims.Reservations = append(claims.Reservations, reservation)
+			}
+			var self types.UID
+			if tt.self {
+				self = reservation.UID
+			}
+			if got := engine.CanHost(target, spec, claims, self, now); got != tt.want {
+				t.Errorf("CanHost() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPruneBookings(t *testing.T) {
+	now := time.Now()
+	cluster := &brokerv1alpha1.ClusterAdvertisement{Spec: brokerv1alpha1.ClusterAdvertisementSpec{ClusterID: "cluster-a"}}
+	reservation := func(name string, phase brokerv1alpha1.ReservationPhase, targetClusterID string) brokerv1alpha1.Reservation {
+		return brokerv1alpha1.Reservation{
+			ObjectMeta: metav1.ObjectMeta{Name: name, UID: types.UID(name)},
+			Spec: brokerv1alpha1.ReservationSpec{
+				TargetClusterID: targetClusterID,
+				StartTime:       &metav1.Time{Time: now.Add(time.Hour)},
+			},
+			Status: brokerv1alpha1.ReservationStatus{Phase: phase},
+		}
+	}
+	claims := &Claims{Reservations: []brokerv1alpha1.Reservation{
+		reservation("scheduled", brokerv1alpha1.ReservationPhaseScheduled, "cluster-a"),
+		reservation("pending", brokerv1alpha1.ReservationPhasePending, "cluster-a"),
+		reservation("reserved", brokerv1alpha1.ReservationPhaseReserved, "cluster-a"),
+		reservation("moved", brokerv1alpha1.ReservationPhaseScheduled, "cluster-b"),
+		reservation("released", brokerv1alpha1.ReservationPhaseReleased, "cluster-a"),
+	}}
+	for i := range claims.Reservations {
+		RecordBooking(cluster, &claims.Reservations[i], now)
+	}
+	deleted := reservation("deleted", brokerv1alpha1.ReservationPhaseScheduled, "cluster-a")
+	RecordBooking(cluster, &deleted, now)
+
+	if !PruneBookings(cluster, claims, now) {
+		t.Fatal("PruneBookings() = false, want true")
+	}
+	var kept []string
+	for _, booking := range cluster.Spec.Bookings {
+		kept = append(kept, booking.Reservation)
+	}
+	if len(kept) != 2 || kept[0] != "/scheduled" || kept[1] != "/pending" {
+		t.Errorf("kept bookings = %v, want [/scheduled /pending]", kept)
+	}
+	if PruneBookings(cluster, claims, now) {
+		t.Error("second PruneBookings() = true, want false")
+	}
+}
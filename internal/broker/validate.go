@@ -0,0 +1,156 @@
+package broker
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/pricing"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/resource"
+)
+
+// ValidateReservationSpec checks a reservation's spec on its own, without
+// looking at the clusters or other reservations
+func ValidateReservationSpec(spec *brokerv1alpha1.ReservationSpec) error {
+	if spec.RequesterID == "" {
+		return errors.New("spec.requesterID must be set")
+	}
+	if spec.RequestedResources.CPU.Sign() <= 0 {
+		return errors.New("requested CPU must be greater than zero")
+	}
+	if spec.RequestedResources.Memory.Sign() <= 0 {
+		return errors.New("requested memory must be greater than zero")
+	}
+	if gpu := spec.RequestedResources.GPU; gpu != nil && gpu.Sign() < 0 {
+		return errors.New("requested GPU must not be negative")
+	}
+	if storage := spec.RequestedResources.Storage; storage != nil && storage.Sign() < 0 {
+		return errors.New("requested storage must not be negative")
+	}
+	for name, quantity := range spec.RequestedResources.Extended {
+		if resource.IsFixedResource(name) {
+			return fmt.Errorf("extended resource %q duplicates a fixed field, use requestedResources.%s instead",
+				name, name)
+		}
+		if quantity.Sign() < 0 {
+			return fmt.Errorf("requested %s must not be negative", name)
+		}
+	}
+	if spec.ScoringStrategy != "" {
+		if _, err := LookupScorer(spec.ScoringStrategy); err != nil {
+			return err
+		}
+	}
+	if timeout := spec.QueueTimeout; timeout != nil && timeout.Duration <= 0 {
+		return errors.New("queueTimeout must be greater than zero")
+	}
+	if grace := spec.HeartbeatGracePeriod; grace != nil && grace.Duration <= 0 {
+		return errors.New("heartbeatGracePeriod must be greater than zero")
+	}
+	if timeout := spec.ActivationTimeout; timeout != nil && timeout.Duration <= 0 {
+		return errors.New("activationTimeout must be greater than zero")
+	}
+	if spec.EndTime != nil {
+		if spec.Duration != nil {
+			return errors.New("set either endTime or duration, not both")
+		}
+		if spec.StartTime != nil && !spec.EndTime.After(spec.StartTime.Time) {
+			return errors.New("endTime must be after startTime")
+		}
+	}
+	if err := ValidateTolerations(spec.Tolerations); err != nil {
+		return err
+	}
+	if err := ValidateAffinity(spec.Affinity); err != nil {
+		return err
+	}
+	if policy := spec.SplitPolicy; policy != nil {
+		if policy.MaxFragments < 2 {
+			return errors.New("splitPolicy.maxFragments must be at least 2")
+		}
+		for name, quantity := range resource.RequestedList(policy.MinChunk) {
+			if quantity.Sign() < 0 {
+				return fmt.Errorf("splitPolicy.minChunk %s must not be negative", name)
+			}
+		}
+		if resource.Chunks(resource.RequestedList(spec.RequestedResources),
+			resource.RequestedList(policy.MinChunk)) == 0 {
+			return errors.New("splitPolicy.minChunk must set at least one requested resource and not exceed it")
+		}
+	}
+	return nil
+}
+
+// ValidateAdvertisement checks what a cluster reports about itself: its
+// identity, that quantities are not negative, that allocatable does not exceed
+// capacity and allocated does not exceed allocatable, its prices, labels and taints
+func ValidateAdvertisement(spec *brokerv1alpha1.ClusterAdvertisementSpec) error {
+	if spec.ClusterID == "" {
+		return fmt.Errorf("clusterID is required")
+	}
+	if errs := validation.IsDNS1123Subdomain(spec.ClusterID); len(errs) > 0 {
+		return fmt.Errorf("clusterID %q is not a valid object name: %s", spec.ClusterID, strings.Join(errs, "; "))
+	}
+
+	capacity := resource.ToList(spec.Resources.Capacity)
+	allocatable := resource.ToList(spec.Resources.Allocatable)
+	allocated := resource.ToList(spec.Resources.Allocated)
+	reported := []struct {
+		field string
+		list  corev1.ResourceList
+	}{
+		{"capacity", capacity},
+		{"allocatable", allocatable},
+		{"allocated", allocated},
+	}
+	for _, r := range reported {
+		for _, name := range resource.SortedNames(r.list) {
+			if quantity := r.list[name]; quantity.Sign() < 0 {
+				return fmt.Errorf("resources.%s.%s must not be negative", r.field, name)
+			}
+		}
+	}
+	for _, name := range resource.SortedNames(allocatable) {
+		quantity := allocatable[name]
+		if total, ok := capacity[name]; ok && quantity.Cmp(total) > 0 {
+			return fmt.Errorf("resources.allocatable.%s %s exceeds capacity %s", name, quantity.String(), total.String())
+		}
+	}
+	for _, name := range resource.SortedNames(allocated) {
+		quantity := allocated[name]
+		if total, ok := allocatable[name]; ok && quantity.Cmp(total) > 0 {
+			return fmt.Errorf("resources.allocated.%s %s exceeds allocatable %s", name, quantity.String(), total.String())
+		}
+	}
+
+	if spec.Cost != nil {
+		if _, err := pricing.ParsePrices(spec.Cost); err != nil {
+			return fmt.Errorf("cost: %w", err)
+		}
+	}
+
+	for key, value := range spec.Labels {
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return fmt.Errorf("label key %q: %s", key, strings.Join(errs, "; "))
+		}
+		if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+			return fmt.Errorf("label %q value %q: %s", key, value, strings.Join(errs, "; "))
+		}
+	}
+
+	for i, taint := range spec.Taints {
+		if taint.Key == "" {
+			return fmt.Errorf("taints[%d]: key is required", i)
+		}
+		switch taint.Effect {
+		case brokerv1alpha1.TaintEffectNoReserve, brokerv1alpha1.TaintEffectPreferNoReserve:
+		default:
+			return fmt.Errorf("taints[%d]: unknown effect %q", i, taint.Effect)
+		}
+	}
+	return nil
+}
@@ -20,6 +20,8 @@ import (
 	"context"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -38,11 +40,20 @@ type ClusterAdvertisementReconciler struct {
 	Scheme             *runtime.Scheme
 	DecisionEngine     *broker.DecisionEngine
 	StalenessThreshold time.Duration // Configurable staleness threshold
+
+	// RepairReservedDrift overwrites the stored Reserved counter with the ledger
+	// computed from Reservation objects once drift has persisted for DriftRepairGracePeriod
+	RepairReservedDrift bool
+	// DriftRepairGracePeriod gives in-flight reservations time to settle before
+	// drift is repaired (default 1 minute)
+	DriftRepairGracePeriod time.Duration
 }
 
 // +kubebuilder:rbac:groups=broker.fluidos.eu,resources=clusteradvertisements,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=broker.fluidos.eu,resources=clusteradvertisements/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=broker.fluidos.eu,resources=clusteradvertisements/finalizers,verbs=update
+// +kubebuilder:rbac:groups=broker.fluidos.eu,resources=reservations,verbs=get;list;watch
+// +kubebuilder:rbac:groups=broker.fluidos.eu,resources=reservationgroups,verbs=get;list;watch
 
 // Reconcile is part of the main kubernetes reconciliation loop
 func (r *ClusterAdvertisementReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
@@ -61,6 +72,40 @@ func (r *ClusterAdvertisementReconciler) Reconcile(ctx context.Context, req ctrl
 		return ctrl.Result{}, err
 	}
 
+	// Compare the stored Reserved counter with the ledger of Reservation and ReservationGroup objects
+	reservationList := &brokerv1alpha1.ReservationList{}
+	if err := r.List(ctx, reservationList); err != nil {
+		logger.Error(err, "Failed to list reservations")
+		return ctrl.Result{}, err
+	}
+	groupList := &brokerv1alpha1.ReservationGroupList{}
+	if err := r.List(ctx, groupList); err != nil {
+		logger.Error(err, "Failed to list reservation groups")
+		return ctrl.Result{}, err
+	}
+	ledger := resource.Ledger(clusterAdv.Spec.ClusterID, reservationList.Items, groupList.Items)
+	drift := resource.Drift(clusterAdv.Spec.Resources.Reserved, ledger)
+	previousDrift := meta.FindStatusCondition(clusterAdv.Status.Conditions,
+		brokerv1alpha1.ClusterAdvertisementConditionReservedDrift).DeepCopy()
+	repaired := false
+	if len(drift) > 0 && r.RepairReservedDrift && r.driftPersisted(previousDrift, drift) {
+		logger.Info("Repairing Reserved counter from reservation ledger",
+			"clusterID", clusterAdv.Spec.ClusterID,
+			"drift", resource.FormatList(drift),
+			"ledger", resource.FormatList(ledger))
+		repairedReserved := resource.FromList(ledger)
+		clusterAdv.Spec.Resources.Reserved = &repairedReserved
+		reservedDriftRepairs.WithLabelValues(clusterAdv.Spec.ClusterID).Inc()
+		repaired = true
+	}
+	recordReservedDrift(clusterAdv.Spec.ClusterID, drift, repaired)
+
+	// Forget bookings whose reservation has locked its resources, moved on or is gone
+	if broker.PruneBookings(clusterAdv,
+		&broker.Claims{Reservations: reservationList.Items, Groups: groupList.Items}, time.Now()) {
+		logger.Info("Pruned stale bookings", "clusterID", clusterAdv.Spec.ClusterID)
+	}
+
 	// Recalculate Available using single source of truth
 	resource.UpdateAvailableResources(&clusterAdv.Spec.Resources)
 
@@ -95,6 +140,7 @@ func (r *ClusterAdvertisementReconciler) Reconcile(ctx context.Context, req ctrl
 
 	// Update conditions
 	r.updateConditions(clusterAdv, isStale)
+	setReservedDriftCondition(clusterAdv, previousDrift, drift, repaired)
 
 	clusterAdv.Status.LastUpdateTime = metav1.Now()
 
@@ -105,15 +151,81 @@ func (r *ClusterAdvertisementReconciler) Reconcile(ctx context.Context, req ctrl
 
 	logger.Info("Updated ClusterAdvertisement",
 		"clusterID", clusterAdv.Spec.ClusterID,
-		"availableCPU", clusterAdv.Spec.Resources.Available.CPU.String(),
-		"availableMemory", clusterAdv.Spec.Resources.Available.Memory.String(),
+		"available", resource.FormatList(resource.ToList(clusterAdv.Spec.Resources.Available)),
 		"score", clusterAdv.Status.Score,
 		"active", clusterAdv.Status.Active)
 
+	// Come back once the drift grace period is over so it can be repaired
+	if len(drift) > 0 && !repaired && r.RepairReservedDrift {
+		return ctrl.Result{RequeueAfter: r.driftRepairGracePeriod()}, nil
+	}
+
 	// Requeue after 30 seconds to check for staleness
 	return ctrl.Result{RequeueAfter: 5 * time.Minute}, nil
 }
 
+func (r *ClusterAdvertisementReconciler) driftRepairGracePeriod() time.Duration {
+	if r.DriftRepairGracePeriod == 0 {
+		return 1 * time.Minute
+	}
+	return r.DriftRepairGracePeriod
+}
+
+// driftPersisted reports whether the same drift was already reported at least
+// one grace period ago, so that locks and releases still in flight are not "repaired"
+func (r *ClusterAdvertisementReconciler) driftPersisted(previous *metav1.Condition, drift corev1.ResourceList) bool {
+	return previous != nil &&
+		previous.Status == metav1.ConditionTrue &&
+		previous.Message == driftMessage(drift) &&
+		time.Since(previous.LastTransitionTime.Time) >= r.driftRepairGracePeriod()
+}
+
+func driftMessage(drift corev1.ResourceList) string {
+	return "Stored Reserved differs from the reservation ledger by " + resource.FormatList(drift)
+}
+
+// setReservedDriftCondition reports drift between the Reserved counter and the
+// ledger. A changed drift restarts the condition so the grace period starts over.
+func setReservedDriftCondition(
+	clusterAdv *brokerv1alpha1.ClusterAdvertisement,
+	previous *metav1.Condition,
+	drift corev1.ResourceList,
+	repaired bool,
+) {
+	condition := metav1.Condition{
+		Type:               brokerv1alpha1.ClusterAdvertisementConditionReservedDrift,
+		Status:             metav1.ConditionFalse,
+		Reason:             "LedgerConsistent",
+		Message:            "Reserved resources match the reservation ledger",
+		LastTransitionTime: metav1.Now(),
+	}
+	switch {
+	case repaired:
+		condition.Reason = "DriftRepaired"
+		condition.Message = "Reserved resources were repaired from the reservation ledger (was off by " +
+			resource.FormatList(drift) + ")"
+	case len(drift) > 0:
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "LedgerMismatch"
+		condition.Message = driftMessage(drift)
+		if previous != nil && previous.Message != condition.Message {
+			meta.RemoveStatusCondition(&clusterAdv.Status.Conditions, condition.Type)
+		}
+	}
+	meta.SetStatusCondition(&clusterAdv.Status.Conditions, condition)
+}
+
+// recordReservedDrift publishes the per-resource drift of a cluster as metrics
+func recordReservedDrift(clusterID string, drift corev1.ResourceList, repaired bool) {
+	reservedDrift.DeletePartialMatch(prometheus.Labels{"cluster_id": clusterID})
+	if repaired {
+		return
+	}
+	for name, quantity := range drift {
+		reservedDrift.WithLabelValues(clusterID, string(name)).Set(quantity.AsApproximateFloat64())
+	}
+}
+
 // updateConditions updates the status conditions for the cluster advertisement
 func (r *ClusterAdvertisementReconciler) updateConditions(clusterAdv *brokerv1alpha1.ClusterAdvertisement, isStale bool) {
 	now := metav1.Now()
@@ -151,10 +263,10 @@ func (r *ClusterAdvertisementReconciler) updateConditions(clusterAdv *brokerv1al
 	// Overcommitted condition - check if reserved > available
 	isOvercommitted := false
 	if clusterAdv.Spec.Resources.Reserved != nil {
-		if clusterAdv.Spec.Resources.Reserved.CPU.Cmp(clusterAdv.Spec.Resources.Available.CPU) > 0 ||
-			clusterAdv.Spec.Resources.Reserved.Memory.Cmp(clusterAdv.Spec.Resources.Available.Memory) > 0 {
-			isOvercommitted = true
-		}
+		isOvercommitted = !resource.Fits(
+			resource.ToList(clusterAdv.Spec.Resources.Available),
+			resource.ToList(*clusterAdv.Spec.Resources.Reserved),
+		)
 	}
 
 	overcommittedStatus := metav1.ConditionFalse
@@ -0,0 +1,152 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	apiresource "k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/broker"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/resource"
+)
+
+// The tests in this file's package that do not need a kube-apiserver run the
+// reconcilers against a fake client instead of envtest
+
+// newFakeReconciler returns a ReservationReconciler over a fake client holding objects
+func newFakeReconciler(t *testing.T, objects ...client.Object) *ReservationReconciler {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	if err := brokerv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(objects...).
+		WithStatusSubresource(
+			&brokerv1alpha1.Reservation{},
+			&brokerv1alpha1.ReservationGroup{},
+			&brokerv1alpha1.ClusterAdvertisement{},
+			&brokerv1alpha1.RequesterQuota{},
+		).
+		Build()
+	return &ReservationReconciler{
+		Client:         c,
+		Scheme:         scheme,
+		DecisionEngine: &broker.DecisionEngine{Client: c},
+	}
+}
+
+// fakeCluster is an active cluster with the given CPU and memory allocatable
+// and available
+func fakeCluster(clusterID, cpu, memory string) *brokerv1alpha1.ClusterAdvertisement {
+	quantities := brokerv1alpha1.ResourceQuantities{CPU: apiresource.MustParse(cpu), Memory: apiresource.MustParse(memory)}
+	return &brokerv1alpha1.ClusterAdvertisement{
+		ObjectMeta: metav1.ObjectMeta{Name: clusterID, Namespace: "default"},
+		Spec: brokerv1alpha1.ClusterAdvertisementSpec{
+			ClusterID: clusterID,
+			Resources: brokerv1alpha1.ResourceMetrics{
+				Capacity:    quantities,
+				Allocatable: quantities,
+				Available:   quantities,
+			},
+			Timestamp: metav1.Now(),
+		},
+		Status: brokerv1alpha1.ClusterAdvertisementStatus{Active: true},
+	}
+}
+
+// fakeReservation is a reservation of requester-cluster for cpu and memory
+func fakeReservation(name, cpu, memory string) *brokerv1alpha1.Reservation {
+	return &brokerv1alpha1.Reservation{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", UID: types.UID(name)},
+		Spec: brokerv1alpha1.ReservationSpec{
+			RequesterID: "requester-cluster",
+			RequestedResources: brokerv1alpha1.RequestedResourceQuantities{
+				CPU:    apiresource.MustParse(cpu),
+				Memory: apiresource.MustParse(memory),
+			},
+		},
+	}
+}
+
+// fakeReserved makes a reservation Reserved on a cluster since reservedAt, with
+// its requested resources locked there
+func fakeReserved(
+	t *testing.T,
+	reservation *brokerv1alpha1.Reservation,
+	cluster *brokerv1alpha1.ClusterAdvertisement,
+	reservedAt time.Time,
+) {
+	t.Helper()
+	reservation.Finalizers = []string{brokerv1alpha1.ReservationFinalizer}
+	reservation.Spec.TargetClusterID = cluster.Spec.ClusterID
+	reservation.Status.Phase = brokerv1alpha1.ReservationPhaseReserved
+	reservation.Status.ReservedAt = &metav1.Time{Time: reservedAt}
+	reservation.Status.LockedResources = reservation.Spec.RequestedResources.DeepCopy()
+	if reservation.Spec.Duration != nil {
+		reservation.Status.ExpiresAt = &metav1.Time{Time: reservedAt.Add(reservation.Spec.Duration.Duration)}
+		reservation.Status.ObservedDuration = reservation.Spec.Duration.DeepCopy()
+	}
+	if err := resource.AddReservation(cluster, reservation.Spec.RequestedResources); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// reconcileReservation runs one reconcile of a reservation and returns it as stored afterwards
+func reconcileReservation(
+	t *testing.T,
+	r *ReservationReconciler,
+	name string,
+) (reconcile.Result, *brokerv1alpha1.Reservation) {
+	t.Helper()
+	key := types.NamespacedName{Name: name, Namespace: "default"}
+	result, err := r.Reconcile(context.Background(), reconcile.Request{NamespacedName: key})
+	if err != nil {
+		t.Fatalf("Reconcile(%s) error = %v", name, err)
+	}
+	return result, getReservation(t, r, name)
+}
+
+// getReservation returns a reservation as stored
+func getReservation(t *testing.T, r *ReservationReconciler, name string) *brokerv1alpha1.Reservation {
+	t.Helper()
+	reservation := &brokerv1alpha1.Reservation{}
+	if err := r.Get(context.Background(), types.NamespacedName{Name: name, Namespace: "default"}, reservation); err != nil {
+		t.Fatal(err)
+	}
+	return reservation
+}
+
+// getCluster returns a ClusterAdvertisement as stored
+func getCluster(t *testing.T, r *ReservationReconciler, clusterID string) *brokerv1alpha1.ClusterAdvertisement {
+	t.Helper()
+	cluster := &brokerv1alpha1.ClusterAdvertisement{}
+	if err := r.Get(context.Background(), types.NamespacedName{Name: clusterID, Namespace: "default"}, cluster); err != nil {
+		t.Fatal(err)
+	}
+	return cluster
+}
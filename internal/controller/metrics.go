@@ -0,0 +1,47 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	// reservedDrift is the difference between the stored Reserved counter of a
+	// cluster and the ledger computed from Reservation objects, per resource
+	reservedDrift = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "broker_reserved_drift",
+			Help: "Stored Reserved minus the Reservation ledger for a cluster, per resource (0 means consistent)",
+		},
+		[]string{"cluster_id", "resource"},
+	)
+
+	// reservedDriftRepairs counts how often the Reserved counter was overwritten with the ledger
+	reservedDriftRepairs = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "broker_reserved_drift_repairs_total",
+			Help: "Number of times a cluster's Reserved counter was repaired from the Reservation ledger",
+		},
+		[]string{"cluster_id"},
+	)
+)
+
+func init() {
+	metrics.Registry.MustRegister(reservedDrift, reservedDriftRepairs)
+}
@@ -0,0 +1,144 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/broker"
+)
+
+// RequesterQuotaReconciler publishes the current usage of each requester in its quotas
+type RequesterQuotaReconciler struct {
+	client.Client
+	Scheme         *runtime.Scheme
+	DecisionEngine *broker.DecisionEngine
+}
+
+// +kubebuilder:rbac:groups=broker.fluidos.eu,resources=requesterquotas,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=broker.fluidos.eu,resources=requesterquotas/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=broker.fluidos.eu,resources=requesterquotas/finalizers,verbs=update
+// +kubebuilder:rbac:groups=broker.fluidos.eu,resources=reservations,verbs=get;list;watch
+// +kubebuilder:rbac:groups=broker.fluidos.eu,resources=reservationgroups,verbs=get;list;watch
+
+// Reconcile recomputes the usage of the quota's requester from the reservations
+// and reservation groups it holds
+func (r *RequesterQuotaReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	quota := &brokerv1alpha1.RequesterQuota{}
+	if err := r.Get(ctx, req.NamespacedName, quota); err != nil {
+		if client.IgnoreNotFound(err) == nil {
+			return ctrl.Result{}, nil
+		}
+		logger.Error(err, "Failed to get RequesterQuota")
+		return ctrl.Result{}, err
+	}
+
+	claims, err := r.DecisionEngine.ListClaims(ctx)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	usage := broker.RequesterUsage(quota.Spec.RequesterID, claims)
+
+	status := brokerv1alpha1.RequesterQuotaStatus{Used: broker.QuotaResources(usage.Total)}
+	clusterIDs := make([]string, 0, len(usage.Clusters))
+	for clusterID := range usage.Clusters {
+		clusterIDs = append(clusterIDs, clusterID)
+	}
+	sort.Strings(clusterIDs)
+	for _, clusterID := range clusterIDs {
+		status.Clusters = append(status.Clusters, brokerv1alpha1.ClusterQuotaUsage{
+			ClusterID: clusterID,
+			Used:      broker.QuotaResources(*usage.Clusters[clusterID]),
+		})
+	}
+
+	// Only write when the usage changed, so the quota does not churn every minute
+	if equality.Semantic.DeepEqual(quota.Status.Used, status.Used) &&
+		equality.Semantic.DeepEqual(quota.Status.Clusters, status.Clusters) {
+		return ctrl.Result{RequeueAfter: 1 * time.Minute}, nil
+	}
+
+	quota.Status.Used = status.Used
+	quota.Status.Clusters = status.Clusters
+	quota.Status.LastUpdateTime = metav1.Now()
+	if err := r.Status().Update(ctx, quota); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	logger.Info("Requester quota usage updated",
+		"requesterID", quota.Spec.RequesterID,
+		"reservations", usage.Total.Reservations)
+	return ctrl.Result{RequeueAfter: 1 * time.Minute}, nil
+}
+
+// quotaRequests maps a Reservation or ReservationGroup change to reconcile
+// requests for the quotas of its requester
+func (r *RequesterQuotaReconciler) quotaRequests(ctx context.Context, obj client.Object) []reconcile.Request {
+	var requesterID string
+	switch claim := obj.(type) {
+	case *brokerv1alpha1.Reservation:
+		requesterID = claim.Spec.RequesterID
+	case *brokerv1alpha1.ReservationGroup:
+		requesterID = claim.Spec.RequesterID
+	}
+
+	quotas, err := r.DecisionEngine.RequesterQuotas(ctx, requesterID)
+	if err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list requester quotas for usage update")
+		return nil
+	}
+
+	requests := make([]reconcile.Request, 0, len(quotas))
+	for _, quota := range quotas {
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Name: quota.Name, Namespace: quota.Namespace},
+		})
+	}
+	return requests
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *RequesterQuotaReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// Initialize decision engine if not set
+	if r.DecisionEngine == nil {
+		r.DecisionEngine = &broker.DecisionEngine{
+			Client: r.Client,
+		}
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&brokerv1alpha1.RequesterQuota{}).
+		Watches(&brokerv1alpha1.Reservation{}, handler.EnqueueRequestsFromMapFunc(r.quotaRequests)).
+		Watches(&brokerv1alpha1.ReservationGroup{}, handler.EnqueueRequestsFromMapFunc(r.quotaRequests)).
+		Named("requesterquota").
+		Complete(r)
+}
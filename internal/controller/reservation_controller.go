@@ -23,7 +23,9 @@ import (
 	"time"
 
 	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
@@ -31,6 +33,7 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
@@ -43,6 +46,36 @@ type ReservationReconciler struct {
 	client.Client
 	Scheme         *runtime.Scheme
 	DecisionEngine *broker.DecisionEngine
+
+	// MaxReservationLifetime caps how long a reservation may hold resources in
+	// total, renewals included. Zero means unlimited.
+	MaxReservationLifetime time.Duration
+
+	// ActivationTimeout releases Reserved reservations the requester has not
+	// activated within this time. Zero disables it unless a reservation sets
+	// spec.activationTimeout.
+	ActivationTimeout time.Duration
+
+	// RequesterHeartbeatGrace releases Active reservations whose requester has not
+	// renewed status.requesterHeartbeatTime within this time. Zero disables it
+	// unless a reservation sets spec.heartbeatGracePeriod.
+	RequesterHeartbeatGrace time.Duration
+
+	// FailoverGracePeriod is how long a target cluster must have been stale before
+	// reservations with failoverPolicy Reschedule are moved off it. Defaults to 5 minutes.
+	FailoverGracePeriod time.Duration
+
+	// AdmissionPolicy orders reservations of equal priority waiting for capacity:
+	// "fifo" (default) by how long they waited, "fair-share" by their requester's
+	// weighted share of recent usage
+	AdmissionPolicy string
+
+	// FairShareHalfLife is how quickly past usage stops counting under the
+	// fair-share admission policy. Zero means usage never decays.
+	FairShareHalfLife time.Duration
+
+	// queuePlacements remembers where queued reservations would be placed
+	queuePlacements placementCache
 }
 
 var (
@@ -54,6 +87,9 @@ var (
 // +kubebuilder:rbac:groups=broker.fluidos.eu,resources=reservations/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=broker.fluidos.eu,resources=reservations/finalizers,verbs=update
 // +kubebuilder:rbac:groups=broker.fluidos.eu,resources=clusteradvertisements,verbs=get;list;watch
+// +kubebuilder:rbac:groups=broker.fluidos.eu,resources=reservationgroups,verbs=get;list;watch
+// +kubebuilder:rbac:groups=broker.fluidos.eu,resources=requesterquotas,verbs=get;list;watch;create
+// +kubebuilder:rbac:groups=broker.fluidos.eu,resources=requesterquotas/status,verbs=get;update;patch
 
 // Reconcile is part of the main kubernetes reconciliation loop
 func (r *ReservationReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
@@ -81,6 +117,12 @@ func (r *ReservationReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 				return ctrl.Result{}, err
 			}
 
+			// Keep the usage in the requester's fair-share history
+			if err := r.DecisionEngine.SettleReservation(ctx, reservation, r.FairShareHalfLife, time.Now()); err != nil {
+				logger.Error(err, "Failed to record fair-share usage")
+				return ctrl.Result{}, err
+			}
+
 			// Remove finalizer
 			controllerutil.RemoveFinalizer(reservation, brokerv1alpha1.ReservationFinalizer)
 			if err := r.Update(ctx, reservation); err != nil {
@@ -98,13 +140,14 @@ func (r *ReservationReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		}
 	}
 
-	if err := validateReservationSpec(reservation); err != nil {
+	if err := broker.ValidateReservationSpec(&reservation.Spec); err != nil {
 		logger.Error(err, "invalid reservation spec",
 			"reservation", reservation.Name,
 			"requesterID", reservation.Spec.RequesterID)
 		reservation.Status.Phase = brokerv1alpha1.ReservationPhaseFailed
 		reservation.Status.Message = fmt.Sprintf("Invalid reservation specification: %v. "+
-			"Please check that requesterID is set and requested resources are positive values.", err)
+			"Please check that requesterID is set, requested resources are positive values "+
+			"and scoringStrategy is one of %v.", err, broker.RegisteredStrategies())
 		reservation.Status.LastUpdateTime = metav1.Now()
 		if err := r.Status().Update(ctx, reservation); err != nil {
 			return ctrl.Result{}, err
@@ -112,6 +155,12 @@ func (r *ReservationReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		return ctrl.Result{}, nil
 	}
 
+	// Dry runs are answered without locking anything until dryRun is unset
+	if reservation.Spec.DryRun && (reservation.Status.Phase == "" ||
+		reservation.Status.Phase == brokerv1alpha1.ReservationPhasePending) {
+		return r.handleDryRun(ctx, reservation, logger)
+	}
+
 	// Handle different phases
 	switch reservation.Status.Phase {
 	case "": // New reservation
@@ -120,13 +169,20 @@ func (r *ReservationReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	case brokerv1alpha1.ReservationPhasePending:
 		return r.handlePendingReservation(ctx, reservation, logger)
 
+	case brokerv1alpha1.ReservationPhaseQueued:
+		return r.handleQueuedReservation(ctx, reservation, logger)
+
+	case brokerv1alpha1.ReservationPhaseScheduled:
+		return r.handleScheduledReservation(ctx, reservation, logger)
+
 	case brokerv1alpha1.ReservationPhaseReserved:
 		return r.handleReservedReservation(ctx, reservation, logger)
 
 	case brokerv1alpha1.ReservationPhaseActive:
 		return r.handleActiveReservation(ctx, reservation, logger)
 
-	case brokerv1alpha1.ReservationPhaseFailed, brokerv1alpha1.ReservationPhaseReleased:
+	case brokerv1alpha1.ReservationPhaseFailed, brokerv1alpha1.ReservationPhaseReleased,
+		brokerv1alpha1.ReservationPhasePreempted:
 		// Terminal states - no action needed
 		return ctrl.Result{}, nil
 	}
@@ -141,36 +197,49 @@ func (r *ReservationReconciler) handlePendingReservation(
 	logger logr.Logger,
 ) (ctrl.Result, error) {
 
+	// Reservations ahead in the waiting queue get freed capacity first
+	ahead, err := r.placeableAhead(ctx, reservation)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if ahead != nil {
+		return r.queueReservation(ctx, reservation, fmt.Sprintf("Waiting behind queued reservation %s/%s "+
+			"(priority %d).", ahead.Namespace, ahead.Name, ahead.Spec.Priority), logger)
+	}
+
+	// Requests beyond the requester's quota are rejected or wait for usage to drop
+	if result, blocked, err := r.enforceQuota(ctx, reservation, logger); blocked || err != nil {
+		return result, err
+	}
+
 	// If TargetClusterID is already specified, use it
 	if reservation.Spec.TargetClusterID != "" {
-		return r.reserveInTargetCluster(ctx, reservation, logger)
+		return r.reserveInTargetCluster(ctx, reservation, false, logger)
 	}
 
 	// Otherwise, select best cluster based on decision engine
-	bestCluster, err := r.DecisionEngine.SelectBestCluster(
-		ctx,
-		reservation.Spec.RequesterID,
-		reservation.Spec.RequestedResources.CPU,
-		reservation.Spec.RequestedResources.Memory,
-		reservation.Spec.Priority,
-	)
+	bestCluster, decision, err := r.DecisionEngine.SelectBestCluster(ctx, &reservation.Spec)
 
 	if err != nil {
-		logger.Error(err, "failed to select cluster",
+		logger.Info("No cluster can satisfy the reservation yet", "reason", err.Error(),
 			"requesterID", reservation.Spec.RequesterID,
-			"requestedCPU", reservation.Spec.RequestedResources.CPU.String(),
-			"requestedMemory", reservation.Spec.RequestedResources.Memory.String())
-		reservation.Status.Phase = brokerv1alpha1.ReservationPhaseFailed
-		reservation.Status.Message = fmt.Sprintf("No suitable cluster found. Requested: %s CPU, %s Memory. "+
-			"Ensure clusters are registered, active, and have sufficient available resources.",
-			reservation.Spec.RequestedResources.CPU.String(),
-			reservation.Spec.RequestedResources.Memory.String())
-		reservation.Status.LastUpdateTime = metav1.Now()
-
-		if err := r.Status().Update(ctx, reservation); err != nil {
+			"requested", resource.FormatRequested(reservation.Spec.RequestedResources))
+		if err := r.recordDecision(ctx, reservation, decision); err != nil {
 			return ctrl.Result{}, err
 		}
-		return ctrl.Result{}, nil
+		startsLater := broker.StartsLater(&reservation.Spec, time.Now())
+		if reservation.Spec.SplitPolicy != nil && !startsLater {
+			split, result, err := r.splitReservation(ctx, reservation, logger)
+			if err != nil || split {
+				return result, err
+			}
+		}
+		if reservation.Spec.PreemptionPolicy == brokerv1alpha1.PreemptionPolicyPreemptLowerPriority && !startsLater {
+			return r.preemptForReservation(ctx, reservation, logger)
+		}
+		return r.queueReservation(ctx, reservation, fmt.Sprintf("No suitable cluster found. Requested: %s. "+
+			"Waiting in queue until a registered, active cluster has sufficient available resources.",
+			resource.FormatRequested(reservation.Spec.RequestedResources)), logger)
 	}
 
 	// Update reservation with selected cluster
@@ -180,16 +249,58 @@ func (r *ReservationReconciler) handlePendingReservation(
 		return ctrl.Result{}, err
 	}
 
-	return r.reserveInTargetCluster(ctx, reservation, logger)
+	// Written to status together with the outcome of the lock
+	reservation.Status.Decision = decision.Summary()
+
+	return r.reserveInTargetCluster(ctx, reservation, true, logger)
+}
+
+// recordDecision stores why no cluster could take the reservation. A queued
+// reservation is re-evaluated on every advertisement change, so its status is
+// only written when the explanation changed; in other phases it is written
+// with the phase change that follows.
+func (r *ReservationReconciler) recordDecision(
+	ctx context.Context,
+	reservation *brokerv1alpha1.Reservation,
+	decision *broker.Decision,
+) error {
+	var summary *brokerv1alpha1.PlacementDecision
+	if decision != nil {
+		summary = decision.Summary()
+	}
+	if equality.Semantic.DeepEqual(reservation.Status.Decision, summary) {
+		return nil
+	}
+	reservation.Status.Decision = summary
+	if reservation.Status.Phase != brokerv1alpha1.ReservationPhaseQueued {
+		return nil
+	}
+	reservation.Status.LastUpdateTime = metav1.Now()
+	return r.Status().Update(ctx, reservation)
 }
 
-// reserveInTargetCluster attempts to reserve resources in the target cluster
+// reserveInTargetCluster attempts to reserve resources in the target cluster.
+// brokerSelected is set when the broker picked the target itself, in which case
+// the choice is undone if the cluster has filled up in the meantime.
 func (r *ReservationReconciler) reserveInTargetCluster(
 	ctx context.Context,
 	reservation *brokerv1alpha1.Reservation,
+	brokerSelected bool,
 	logger logr.Logger,
 ) (ctrl.Result, error) {
 
+	// Taints are checked on admission; a Scheduled reservation was admitted when it was booked
+	if reservation.Status.Phase != brokerv1alpha1.ReservationPhaseScheduled {
+		if result, rejected, err := r.checkTaints(ctx, reservation, brokerSelected, logger); rejected || err != nil {
+			return result, err
+		}
+	}
+
+	// Future windows are only booked now, resources are locked when the window opens
+	if broker.StartsLater(&reservation.Spec, time.Now()) {
+		return r.bookInTargetCluster(ctx, reservation, brokerSelected, logger)
+	}
+
 	var lockedCluster *brokerv1alpha1.ClusterAdvertisement
 
 	lockErr := retry.RetryOnConflict(retry.DefaultRetry, func() error {
@@ -198,21 +309,19 @@ func (r *ReservationReconciler) reserveInTargetCluster(
 			return err
 		}
 
-		if !resource.CanReserve(
-			clusterAdv,
-			reservation.Spec.RequestedResources.CPU,
-			reservation.Spec.RequestedResources.Memory,
-		) {
+		fits, err := r.canHost(ctx, clusterAdv, reservation)
+		if err != nil {
+			return err
+		}
+		if !fits {
 			return errInsufficientResources
 		}
 
-		if err := resource.AddReservation(
-			clusterAdv,
-			reservation.Spec.RequestedResources.CPU,
-			reservation.Spec.RequestedResources.Memory,
-		); err != nil {
+		if err := resource.AddReservation(clusterAdv, reservation.Spec.RequestedResources); err != nil {
 			return err
 		}
+		// A booked window turns into locked resources
+		broker.DropBooking(clusterAdv, reservation.UID)
 
 		lockedCluster = clusterAdv
 		return r.Update(ctx, clusterAdv)
@@ -230,36 +339,99 @@ func (r *ReservationReconciler) reserveInTargetCluster(
 		}
 		return ctrl.Result{}, nil
 	case errors.Is(lockErr, errInsufficientResources):
-		reservation.Status.Phase = brokerv1alpha1.ReservationPhaseFailed
-		reservation.Status.Message = fmt.Sprintf("Insufficient resources in cluster '%s'. "+
-			"Requested: %s CPU, %s Memory. "+
-			"The cluster may have insufficient available capacity or resources may have been allocated to other reservations.",
+		message := fmt.Sprintf("Insufficient resources in cluster '%s'. "+
+			"Requested: %s. Waiting in queue until capacity is released.",
 			reservation.Spec.TargetClusterID,
-			reservation.Spec.RequestedResources.CPU.String(),
-			reservation.Spec.RequestedResources.Memory.String())
-		reservation.Status.LastUpdateTime = metav1.Now()
-		if err := r.Status().Update(ctx, reservation); err != nil {
-			return ctrl.Result{}, err
+			resource.FormatRequested(reservation.Spec.RequestedResources))
+		if reservation.Status.Phase == brokerv1alpha1.ReservationPhaseScheduled {
+			// The booking is given up while the reservation waits
+			if err := r.dropBooking(ctx, reservation); err != nil {
+				return ctrl.Result{}, err
+			}
 		}
-		return ctrl.Result{}, nil
+		if brokerSelected {
+			// Let the next attempt consider every cluster again
+			reservation.Spec.TargetClusterID = ""
+			if err := r.Update(ctx, reservation); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+		if reservation.Spec.PreemptionPolicy == brokerv1alpha1.PreemptionPolicyPreemptLowerPriority {
+			return r.preemptForReservation(ctx, reservation, logger)
+		}
+		return r.queueReservation(ctx, reservation, message, logger)
 	case lockErr != nil:
 		logger.Error(lockErr, "failed to lock resources in cluster",
 			"targetClusterID", reservation.Spec.TargetClusterID,
-			"requestedCPU", reservation.Spec.RequestedResources.CPU.String(),
-			"requestedMemory", reservation.Spec.RequestedResources.Memory.String())
+			"requested", resource.FormatRequested(reservation.Spec.RequestedResources))
 		return ctrl.Result{}, lockErr
 	}
 
+	return r.markReserved(ctx, reservation, lockedCluster, logger)
+}
+
+// checkTaints rejects a target cluster with a NoReserve taint the reservation
+// does not tolerate. An explicitly requested target fails the reservation; a
+// target the broker picked is dropped and the reservation queued, since the
+// taint was added after the cluster was selected.
+func (r *ReservationReconciler) checkTaints(
+	ctx context.Context,
+	reservation *brokerv1alpha1.Reservation,
+	brokerSelected bool,
+	logger logr.Logger,
+) (result ctrl.Result, rejected bool, err error) {
+	clusterAdv, err := r.findClusterByID(ctx, reservation.Spec.TargetClusterID)
+	if errors.Is(err, errTargetClusterNotFound) {
+		// Reported by the lock
+		return ctrl.Result{}, false, nil
+	}
+	if err != nil {
+		return ctrl.Result{}, false, err
+	}
+
+	taint := broker.UntoleratedTaint(clusterAdv, reservation.Spec.Tolerations, brokerv1alpha1.TaintEffectNoReserve)
+	if taint == nil {
+		return ctrl.Result{}, false, nil
+	}
+
+	logger.Info("Target cluster has a taint the reservation does not tolerate",
+		"targetClusterID", reservation.Spec.TargetClusterID,
+		"taint", broker.FormatTaint(*taint))
+	message := fmt.Sprintf("Cluster '%s' has the taint %s, which the reservation does not tolerate.",
+		reservation.Spec.TargetClusterID, broker.FormatTaint(*taint))
+
+	if !brokerSelected {
+		result, err := r.failReservation(ctx, reservation, message+" Add a matching toleration or choose another cluster.")
+		return result, true, err
+	}
+
+	reservation.Spec.TargetClusterID = ""
+	if err := r.Update(ctx, reservation); err != nil {
+		return ctrl.Result{}, true, err
+	}
+	result, err = r.queueReservation(ctx, reservation, message+" Waiting in queue for another cluster.", logger)
+	return result, true, err
+}
+
+// markReserved records that the reservation's resources are locked in lockedCluster
+func (r *ReservationReconciler) markReserved(
+	ctx context.Context,
+	reservation *brokerv1alpha1.Reservation,
+	lockedCluster *brokerv1alpha1.ClusterAdvertisement,
+	logger logr.Logger,
+) (ctrl.Result, error) {
+
 	// Mark as reserved
 	now := metav1.Now()
 	reservation.Status.Phase = brokerv1alpha1.ReservationPhaseReserved
 	reservation.Status.Message = fmt.Sprintf("Resources locked in cluster %s", reservation.Spec.TargetClusterID)
 	reservation.Status.ReservedAt = &now
+	reservation.Status.LockedResources = reservation.Spec.RequestedResources.DeepCopy()
+	reservation.Status.EstimatedCost = r.DecisionEngine.EstimateCost(lockedCluster, &reservation.Spec)
 
-	// Set expiration if duration is specified
+	reservation.Status.ExpiresAt = r.reservationExpiry(reservation, now.Time)
 	if reservation.Spec.Duration != nil {
-		expiresAt := metav1.NewTime(now.Add(reservation.Spec.Duration.Duration))
-		reservation.Status.ExpiresAt = &expiresAt
+		reservation.Status.ObservedDuration = reservation.Spec.Duration.DeepCopy()
 	}
 
 	reservation.Status.LastUpdateTime = now
@@ -271,18 +443,32 @@ func (r *ReservationReconciler) reserveInTargetCluster(
 	logger.Info(fmt.Sprintf("✅ Resources Locked Successfully\n"+
 		"  └─ Reservation: %s\n"+
 		"  └─ Target Cluster: %s\n"+
-		"  └─ Locked: cpu=%s, memory=%s\n"+
-		"  └─ Remaining Available: cpu=%s, memory=%s",
+		"  └─ Locked: %s\n"+
+		"  └─ Remaining Available: %s",
 		reservation.Name,
 		reservation.Spec.TargetClusterID,
-		reservation.Spec.RequestedResources.CPU.String(),
-		reservation.Spec.RequestedResources.Memory.String(),
-		lockedCluster.Spec.Resources.Available.CPU.String(),
-		lockedCluster.Spec.Resources.Available.Memory.String()))
+		resource.FormatRequested(reservation.Spec.RequestedResources),
+		resource.FormatList(resource.ToList(lockedCluster.Spec.Resources.Available))))
 
 	return ctrl.Result{RequeueAfter: 1 * time.Minute}, nil
 }
 
+// reservationExpiry returns when a reservation locked at now expires: at the end
+// of its requested window, within the maximum lifetime. Nil means it does not expire.
+func (r *ReservationReconciler) reservationExpiry(reservation *brokerv1alpha1.Reservation, now time.Time) *metav1.Time {
+	_, end := broker.RequestedWindow(&reservation.Spec, now)
+	if maxLifetime := r.MaxReservationLifetime; maxLifetime > 0 {
+		if limit := now.Add(maxLifetime); end.IsZero() || end.After(limit) {
+			end = limit
+		}
+	}
+	if end.IsZero() {
+		return nil
+	}
+	expiresAt := metav1.NewTime(end)
+	return &expiresAt
+}
+
 // handleReservedReservation manages a reserved reservation
 func (r *ReservationReconciler) handleReservedReservation(
 	ctx context.Context,
@@ -292,15 +478,48 @@ func (r *ReservationReconciler) handleReservedReservation(
 
 	if reservationHasCondition(reservation, brokerv1alpha1.ReservationConditionRequesterActive) {
 		logger.Info("Requester confirmed activation, promoting reservation to Active")
+		now := metav1.Now()
 		reservation.Status.Phase = brokerv1alpha1.ReservationPhaseActive
 		reservation.Status.Message = "Requester confirmed activation"
-		reservation.Status.LastUpdateTime = metav1.Now()
+		reservation.Status.ActivatedAt = &now
+		reservation.Status.LastUpdateTime = now
 		if err := r.Status().Update(ctx, reservation); err != nil {
 			return ctrl.Result{}, err
 		}
 		return ctrl.Result{RequeueAfter: 1 * time.Minute}, nil
 	}
 
+	// Don't hold capacity for requesters that never show up
+	activationDeadline := r.activationDeadline(reservation)
+	if activationDeadline != nil && !time.Now().Before(*activationDeadline) {
+		logger.Info("Requester did not activate the reservation in time, releasing resources",
+			"reservedAt", reservation.Status.ReservedAt)
+
+		if err := r.releaseResources(ctx, reservation, logger); err != nil {
+			logger.Error(err, "Failed to release resources on activation timeout")
+			return ctrl.Result{}, err
+		}
+
+		reservation.Status.Phase = brokerv1alpha1.ReservationPhaseReleased
+		reservation.Status.Reason = brokerv1alpha1.ReservationReasonActivationTimedOut
+		reservation.Status.Message = fmt.Sprintf("Requester did not confirm activation within %s, resources released",
+			activationDeadline.Sub(reservation.Status.ReservedAt.Time))
+		reservation.Status.LastUpdateTime = metav1.Now()
+
+		if err := r.Status().Update(ctx, reservation); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, nil
+	}
+
+	if err := r.handleResize(ctx, reservation, logger); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if err := r.handleRenewal(ctx, reservation, logger); err != nil {
+		return ctrl.Result{}, err
+	}
+
 	// Check if expired
 	if reservation.Status.ExpiresAt != nil && time.Now().After(reservation.Status.ExpiresAt.Time) {
 		logger.Info("Reservation expired, releasing resources")
@@ -321,8 +540,54 @@ func (r *ReservationReconciler) handleReservedReservation(
 		return ctrl.Result{}, nil
 	}
 
-	// Still valid, check again in 1 minute
-	return ctrl.Result{RequeueAfter: 1 * time.Minute}, nil
+	// Move off a cluster that has been stale for too long, if the reservation opted in
+	// Split reservations are not moved
+	var failoverAt *time.Time
+	if reservation.Spec.FailoverPolicy == brokerv1alpha1.FailoverPolicyReschedule &&
+		len(reservation.Status.Fragments) == 0 {
+		moved, retryAt, err := r.failoverFromStaleCluster(ctx, reservation, logger)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if moved {
+			return ctrl.Result{RequeueAfter: 1 * time.Minute}, nil
+		}
+		failoverAt = retryAt
+	}
+
+	// Still valid, check again in 1 minute or when a deadline passes
+	return ctrl.Result{RequeueAfter: requeueBefore(1*time.Minute, activationDeadline, failoverAt)}, nil
+}
+
+// requeueBefore shortens interval so that the next reconcile happens no later
+// than the earliest of the given deadlines
+func requeueBefore(interval time.Duration, deadlines ...*time.Time) time.Duration {
+	for _, deadline := range deadlines {
+		if deadline == nil {
+			continue
+		}
+		if remaining := time.Until(*deadline); remaining < interval {
+			interval = remaining
+		}
+	}
+	if interval <= 0 {
+		interval = time.Second
+	}
+	return interval
+}
+
+// activationDeadline returns when a Reserved reservation is released if the
+// requester has not activated it, or nil if it may wait indefinitely
+func (r *ReservationReconciler) activationDeadline(reservation *brokerv1alpha1.Reservation) *time.Time {
+	timeout := r.ActivationTimeout
+	if reservation.Spec.ActivationTimeout != nil {
+		timeout = reservation.Spec.ActivationTimeout.Duration
+	}
+	if timeout <= 0 || reservation.Status.ReservedAt == nil {
+		return nil
+	}
+	deadline := reservation.Status.ReservedAt.Add(timeout)
+	return &deadline
 }
 
 // handleActiveReservation manages an active reservation
@@ -346,6 +611,46 @@ func (r *ReservationReconciler) handleActiveReservation(
 		return ctrl.Result{}, nil
 	}
 
+	// A requester that stopped sending heartbeats is assumed to be gone
+	heartbeatDeadline := r.heartbeatDeadline(reservation)
+	if heartbeatDeadline != nil && !time.Now().Before(*heartbeatDeadline) {
+		lastSeen := requesterLastSeen(reservation)
+		logger.Info("Requester heartbeat lapsed, releasing resources",
+			"requesterID", reservation.Spec.RequesterID,
+			"lastSeen", lastSeen)
+
+		if err := r.releaseResources(ctx, reservation, logger); err != nil {
+			logger.Error(err, "Failed to release resources of lost requester")
+			return ctrl.Result{}, err
+		}
+
+		now := metav1.Now()
+		reservation.Status.Phase = brokerv1alpha1.ReservationPhaseReleased
+		reservation.Status.Reason = brokerv1alpha1.ReservationReasonRequesterLost
+		reservation.Status.Message = fmt.Sprintf("No heartbeat from requester %s since %s, resources released",
+			reservation.Spec.RequesterID, lastSeen.Format(time.RFC3339))
+		reservation.Status.LastUpdateTime = now
+		meta.SetStatusCondition(&reservation.Status.Conditions, metav1.Condition{
+			Type:    brokerv1alpha1.ReservationConditionRequesterLost,
+			Status:  metav1.ConditionTrue,
+			Reason:  "HeartbeatExpired",
+			Message: reservation.Status.Message,
+		})
+
+		if err := r.Status().Update(ctx, reservation); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, nil
+	}
+
+	if err := r.handleResize(ctx, reservation, logger); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if err := r.handleRenewal(ctx, reservation, logger); err != nil {
+		return ctrl.Result{}, err
+	}
+
 	// Check if expired
 	if reservation.Status.ExpiresAt != nil && time.Now().After(reservation.Status.ExpiresAt.Time) {
 		logger.Info("Active reservation expired, releasing resources")
@@ -366,7 +671,38 @@ func (r *ReservationReconciler) handleActiveReservation(
 		return ctrl.Result{}, nil
 	}
 
-	return ctrl.Result{RequeueAfter: 1 * time.Minute}, nil
+	return ctrl.Result{RequeueAfter: requeueBefore(1*time.Minute, heartbeatDeadline)}, nil
+}
+
+// heartbeatDeadline returns when an Active reservation is released if the
+// requester sends no further heartbeat, or nil if heartbeats are not required
+func (r *ReservationReconciler) heartbeatDeadline(reservation *brokerv1alpha1.Reservation) *time.Time {
+	grace := r.RequesterHeartbeatGrace
+	if reservation.Spec.HeartbeatGracePeriod != nil {
+		grace = reservation.Spec.HeartbeatGracePeriod.Duration
+	}
+	lastSeen := requesterLastSeen(reservation)
+	if grace <= 0 || lastSeen.IsZero() {
+		return nil
+	}
+	deadline := lastSeen.Add(grace)
+	return &deadline
+}
+
+// requesterLastSeen is the latest sign of life from the requester: its last
+// heartbeat, or the activation itself before the first heartbeat arrives
+func requesterLastSeen(reservation *brokerv1alpha1.Reservation) time.Time {
+	var lastSeen time.Time
+	for _, t := range []*metav1.Time{
+		reservation.Status.RequesterHeartbeatTime,
+		reservation.Status.ActivatedAt,
+		reservation.Status.ReservedAt,
+	} {
+		if t != nil && t.After(lastSeen) {
+			lastSeen = t.Time
+		}
+	}
+	return lastSeen
 }
 
 // releaseResources releases reserved resources when reservation is deleted
@@ -375,9 +711,22 @@ func (r *ReservationReconciler) releaseResources(
 	reservation *brokerv1alpha1.Reservation,
 	logger logr.Logger,
 ) error {
+	// A Scheduled reservation only holds a booking
+	if reservation.Status.Phase == brokerv1alpha1.ReservationPhaseScheduled {
+		return r.dropBooking(ctx, reservation)
+	}
+
 	// Only release if reservation was actually reserved
-	if reservation.Status.Phase != brokerv1alpha1.ReservationPhaseReserved &&
-		reservation.Status.Phase != brokerv1alpha1.ReservationPhaseActive {
+	if !resource.HoldsResources(reservation.Status.Phase) {
+		return nil
+	}
+
+	// A split reservation holds a fragment on each of several clusters
+	if fragments := reservation.Status.Fragments; len(fragments) > 0 {
+		if err := r.unlockFragments(ctx, fragments); err != nil {
+			return fmt.Errorf("failed to release fragments: %w", err)
+		}
+		logger.Info("Successfully released resources", "fragments", formatFragments(fragments))
 		return nil
 	}
 
@@ -400,12 +749,9 @@ func (r *ReservationReconciler) releaseResources(
 		return nil
 	}
 
-	// Release the resources
-	err := resource.RemoveReservation(
-		targetCluster,
-		reservation.Spec.RequestedResources.CPU,
-		reservation.Spec.RequestedResources.Memory,
-	)
+	// Release what was actually locked, which may differ from the spec after a resize
+	locked := resource.LockedResources(reservation)
+	err := resource.RemoveReservation(targetCluster, locked)
 	if err != nil {
 		return fmt.Errorf("failed to remove reservation: %w", err)
 	}
@@ -417,8 +763,7 @@ func (r *ReservationReconciler) releaseResources(
 
 	logger.Info("Successfully released resources",
 		"cluster", reservation.Spec.TargetClusterID,
-		"cpu", reservation.Spec.RequestedResources.CPU.String(),
-		"memory", reservation.Spec.RequestedResources.Memory.String())
+		"released", resource.FormatRequested(locked))
 
 	return nil
 }
@@ -426,9 +771,18 @@ func (r *ReservationReconciler) releaseResources(
 func (r *ReservationReconciler) findClusterByID(
 	ctx context.Context,
 	clusterID string,
+) (*brokerv1alpha1.ClusterAdvertisement, error) {
+	return getClusterByID(ctx, r.Client, clusterID)
+}
+
+// getClusterByID fetches the latest ClusterAdvertisement of a cluster
+func getClusterByID(
+	ctx context.Context,
+	c client.Client,
+	clusterID string,
 ) (*brokerv1alpha1.ClusterAdvertisement, error) {
 	clusterList := &brokerv1alpha1.ClusterAdvertisementList{}
-	if err := r.List(ctx, clusterList); err != nil {
+	if err := c.List(ctx, clusterList); err != nil {
 		return nil, err
 	}
 
@@ -439,7 +793,7 @@ func (r *ReservationReconciler) findClusterByID(
 		}
 
 		cluster := &brokerv1alpha1.ClusterAdvertisement{}
-		if err := r.Get(ctx, types.NamespacedName{Name: item.Name, Namespace: item.Namespace}, cluster); err != nil {
+		if err := c.Get(ctx, types.NamespacedName{Name: item.Name, Namespace: item.Namespace}, cluster); err != nil {
 			if apierrors.IsNotFound(err) {
 				return nil, fmt.Errorf("%w: %s", errTargetClusterNotFound, clusterID)
 			}
@@ -461,19 +815,6 @@ func reservationHasCondition(reservation *brokerv1alpha1.Reservation, conditionT
 	return false
 }
 
-func validateReservationSpec(reservation *brokerv1alpha1.Reservation) error {
-	if reservation.Spec.RequesterID == "" {
-		return errors.New("spec.requesterID must be set")
-	}
-	if reservation.Spec.RequestedResources.CPU.Sign() <= 0 {
-		return errors.New("requested CPU must be greater than zero")
-	}
-	if reservation.Spec.RequestedResources.Memory.Sign() <= 0 {
-		return errors.New("requested memory must be greater than zero")
-	}
-	return nil
-}
-
 // SetupWithManager sets up the controller with the Manager.
 func (r *ReservationReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	// Initialize decision engine if not set
@@ -485,6 +826,8 @@ func (r *ReservationReconciler) SetupWithManager(mgr ctrl.Manager) error {
 
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&brokerv1alpha1.Reservation{}).
+		Watches(&brokerv1alpha1.ClusterAdvertisement{},
+			handler.EnqueueRequestsFromMapFunc(r.queuedReservationRequests)).
 		Named("reservation").
 		Complete(r)
 }
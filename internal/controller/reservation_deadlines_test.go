@@ -0,0 +1,165 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+)
+
+func TestActivationTimeout(t *testing.T) {
+	tests := []struct {
+		name         string
+		reservedFor  time.Duration
+		timeout      time.Duration
+		specTimeout  *metav1.Duration
+		wantReleased bool
+	}{
+		{name: "within the timeout", reservedFor: time.Minute, timeout: 10 * time.Minute},
+		{name: "timed out", reservedFor: 11 * time.Minute, timeout: 10 * time.Minute, wantReleased: true},
+		{
+			name:         "reservation sets a shorter timeout",
+			reservedFor:  2 * time.Minute,
+			timeout:      10 * time.Minute,
+			specTimeout:  &metav1.Duration{Duration: time.Minute},
+			wantReleased: true,
+		},
+		{name: "no timeout", reservedFor: 24 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cluster := fakeCluster("cluster-a", "8", "16Gi")
+			reservation := fakeReservation("idle", "4", "8Gi")
+			reservation.Spec.ActivationTimeout = tt.specTimeout
+			fakeReserved(t, reservation, cluster, time.Now().Add(-tt.reservedFor))
+
+			r := newFakeReconciler(t, cluster, reservation)
+			r.ActivationTimeout = tt.timeout
+			result, got := reconcileReservation(t, r, "idle")
+			available := getCluster(t, r, "cluster-a").Spec.Resources.Available
+
+			if !tt.wantReleased {
+				if got.Status.Phase != brokerv1alpha1.ReservationPhaseReserved {
+					t.Fatalf("phase = %s, want Reserved", got.Status.Phase)
+				}
+				if available.CPU.String() != "4" {
+					t.Errorf("available cpu = %s, want 4", available.CPU.String())
+				}
+				if tt.timeout > 0 && result.RequeueAfter > time.Minute {
+					t.Errorf("requeue after %s, want at most a minute", result.RequeueAfter)
+				}
+				return
+			}
+			if got.Status.Phase != brokerv1alpha1.ReservationPhaseReleased ||
+				got.Status.Reason != brokerv1alpha1.ReservationReasonActivationTimedOut {
+				t.Fatalf("phase = %s reason = %s, want Released with reason ActivationTimedOut",
+					got.Status.Phase, got.Status.Reason)
+			}
+			if available.CPU.String() != "8" || available.Memory.String() != "16Gi" {
+				t.Errorf("available = cpu %s memory %s, want everything back",
+					available.CPU.String(), available.Memory.String())
+			}
+		})
+	}
+}
+
+func TestHeartbeatDeadline(t *testing.T) {
+	tests := []struct {
+		name         string
+		activatedFor time.Duration
+		heartbeatAgo *time.Duration
+		grace        time.Duration
+		specGrace    *metav1.Duration
+		wantReleased bool
+	}{
+		{name: "activated recently", activatedFor: time.Minute, grace: 5 * time.Minute},
+		{name: "no heartbeat since activation", activatedFor: 10 * time.Minute, grace: 5 * time.Minute, wantReleased: true},
+		{
+			name:         "recent heartbeat",
+			activatedFor: 10 * time.Minute,
+			heartbeatAgo: ptr(time.Minute),
+			grace:        5 * time.Minute,
+		},
+		{
+			name:         "heartbeat lapsed",
+			activatedFor: 10 * time.Minute,
+			heartbeatAgo: ptr(6 * time.Minute),
+			grace:        5 * time.Minute,
+			wantReleased: true,
+		},
+		{
+			name:         "reservation sets a longer grace period",
+			activatedFor: 10 * time.Minute,
+			heartbeatAgo: ptr(6 * time.Minute),
+			grace:        5 * time.Minute,
+			specGrace:    &metav1.Duration{Duration: 10 * time.Minute},
+		},
+		{name: "heartbeats not required", activatedFor: 24 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Now()
+			cluster := fakeCluster("cluster-a", "8", "16Gi")
+			reservation := fakeReservation("active", "4", "8Gi")
+			reservation.Spec.HeartbeatGracePeriod = tt.specGrace
+			fakeReserved(t, reservation, cluster, now.Add(-tt.activatedFor))
+			reservation.Status.Phase = brokerv1alpha1.ReservationPhaseActive
+			reservation.Status.ActivatedAt = reservation.Status.ReservedAt.DeepCopy()
+			if tt.heartbeatAgo != nil {
+				reservation.Status.RequesterHeartbeatTime = &metav1.Time{Time: now.Add(-*tt.heartbeatAgo)}
+			}
+
+			r := newFakeReconciler(t, cluster, reservation)
+			r.RequesterHeartbeatGrace = tt.grace
+			_, got := reconcileReservation(t, r, "active")
+			available := getCluster(t, r, "cluster-a").Spec.Resources.Available
+
+			if !tt.wantReleased {
+				if got.Status.Phase != brokerv1alpha1.ReservationPhaseActive {
+					t.Fatalf("phase = %s, want Active", got.Status.Phase)
+				}
+				if available.CPU.String() != "4" {
+					t.Errorf("available cpu = %s, want 4", available.CPU.String())
+				}
+				return
+			}
+			if got.Status.Phase != brokerv1alpha1.ReservationPhaseReleased ||
+				got.Status.Reason != brokerv1alpha1.ReservationReasonRequesterLost {
+				t.Fatalf("phase = %s reason = %s, want Released with reason RequesterLost",
+					got.Status.Phase, got.Status.Reason)
+			}
+			if !meta.IsStatusConditionTrue(got.Status.Conditions, brokerv1alpha1.ReservationConditionRequesterLost) {
+				t.Error("RequesterLost condition is not set")
+			}
+			if available.CPU.String() != "8" || available.Memory.String() != "16Gi" {
+				t.Errorf("available = cpu %s memory %s, want everything back",
+					available.CPU.String(), available.Memory.String())
+			}
+		})
+	}
+}
+
+func ptr[T any](value T) *T {
+	return &value
+}
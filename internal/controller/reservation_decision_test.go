@@ -0,0 +1,85 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+)
+
+func TestReservationDecision(t *testing.T) {
+	tests := []struct {
+		name           string
+		cpu            string
+		wantPhase      brokerv1alpha1.ReservationPhase
+		wantSelected   string
+		wantCandidates []string
+		wantFilters    []brokerv1alpha1.PlacementFilter
+	}{
+		{
+			name:           "placed",
+			cpu:            "6",
+			wantPhase:      brokerv1alpha1.ReservationPhaseReserved,
+			wantSelected:   "large",
+			wantCandidates: []string{"large", "requester-cluster", "small"},
+			wantFilters: []brokerv1alpha1.PlacementFilter{
+				"", brokerv1alpha1.PlacementFilterOwnCluster, brokerv1alpha1.PlacementFilterInsufficientResources,
+			},
+		},
+		{
+			name:           "queued",
+			cpu:            "12",
+			wantPhase:      brokerv1alpha1.ReservationPhaseQueued,
+			wantCandidates: []string{"large", "requester-cluster", "small"},
+			wantFilters: []brokerv1alpha1.PlacementFilter{
+				brokerv1alpha1.PlacementFilterInsufficientResources,
+				brokerv1alpha1.PlacementFilterOwnCluster,
+				brokerv1alpha1.PlacementFilterInsufficientResources,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newFakeReconciler(t,
+				fakeCluster("small", "4", "16Gi"), fakeCluster("large", "8", "16Gi"),
+				fakeCluster("requester-cluster", "16", "32Gi"), fakeReservation("new", tt.cpu, "4Gi"))
+
+			_, got := reconcileReservation(t, r, "new")
+			if got.Status.Phase != tt.wantPhase {
+				t.Fatalf("phase = %s, want %s: %s", got.Status.Phase, tt.wantPhase, got.Status.Message)
+			}
+			decision := got.Status.Decision
+			if decision == nil {
+				t.Fatal("status.decision = nil")
+			}
+			if decision.SelectedClusterID != tt.wantSelected {
+				t.Errorf("selectedClusterID = %q, want %q", decision.SelectedClusterID, tt.wantSelected)
+			}
+			if len(decision.Candidates) != len(tt.wantCandidates) {
+				t.Fatalf("candidates = %+v, want %v", decision.Candidates, tt.wantCandidates)
+			}
+			for i, candidate := range decision.Candidates {
+				if candidate.ClusterID != tt.wantCandidates[i] || candidate.FilteredBy != tt.wantFilters[i] {
+					t.Errorf("candidate %d = %s filtered by %q, want %s filtered by %q",
+						i, candidate.ClusterID, candidate.FilteredBy, tt.wantCandidates[i], tt.wantFilters[i])
+				}
+			}
+		})
+	}
+}
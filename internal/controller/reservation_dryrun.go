@@ -0,0 +1,137 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/broker"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/resource"
+)
+
+// dryRunRecheckInterval is how often the answer to a dry run is refreshed
+const dryRunRecheckInterval = 1 * time.Minute
+
+// handleDryRun reports where a reservation would be placed and what it would
+// cost without locking anything or touching a ClusterAdvertisement. The
+// reservation stays Pending and the answer is refreshed until dryRun is unset.
+func (r *ReservationReconciler) handleDryRun(
+	ctx context.Context,
+	reservation *brokerv1alpha1.Reservation,
+	logger logr.Logger,
+) (ctrl.Result, error) {
+
+	status := reservation.Status.DeepCopy()
+	status.Phase = brokerv1alpha1.ReservationPhasePending
+	status.EstimatedCost = nil
+	status.Decision = nil
+
+	var outcome []string
+	quota, exceeded, err := r.exceededQuota(ctx, reservation)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if quota != nil {
+		outcome = append(outcome, fmt.Sprintf("Requester %s would exceed RequesterQuota %s/%s (%s).",
+			reservation.Spec.RequesterID, quota.Namespace, quota.Name, exceeded))
+	}
+
+	bestCluster, decision, selectErr := r.DecisionEngine.SelectBestCluster(ctx, &reservation.Spec)
+	if decision != nil {
+		status.Decision = decision.Summary()
+	}
+
+	switch {
+	case reservation.Spec.TargetClusterID != "":
+		placement, cost, err := r.dryRunTarget(ctx, reservation)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		outcome = append(outcome, placement)
+		status.EstimatedCost = cost
+	case selectErr == nil:
+		outcome = append(outcome, fmt.Sprintf("Would be placed on cluster %s.", bestCluster.Spec.ClusterID))
+		status.EstimatedCost = r.DecisionEngine.EstimateCost(bestCluster, &reservation.Spec)
+	case reservation.Spec.SplitPolicy != nil && !broker.StartsLater(&reservation.Spec, time.Now()):
+		if fragments, err := r.DecisionEngine.PlanSplit(ctx, &reservation.Spec); err == nil {
+			outcome = append(outcome, fmt.Sprintf("Would be split across %s.", formatFragments(fragments)))
+			break
+		}
+		fallthrough
+	default:
+		outcome = append(outcome, fmt.Sprintf("No cluster can take it right now (%v); "+
+			"it would wait in the queue.", selectErr))
+	}
+	status.Message = "Dry run: " + strings.Join(outcome, " ")
+
+	if !equality.Semantic.DeepEqual(&reservation.Status, status) {
+		status.LastUpdateTime = metav1.Now()
+		reservation.Status = *status
+		if err := r.Status().Update(ctx, reservation); err != nil {
+			return ctrl.Result{}, err
+		}
+		logger.Info("Dry run evaluated",
+			"requesterID", reservation.Spec.RequesterID,
+			"requested", resource.FormatRequested(reservation.Spec.RequestedResources),
+			"outcome", status.Message)
+	}
+
+	return ctrl.Result{RequeueAfter: dryRunRecheckInterval}, nil
+}
+
+// dryRunTarget checks an explicitly requested target cluster the way
+// reserveInTargetCluster would, and returns what it would cost there
+func (r *ReservationReconciler) dryRunTarget(
+	ctx context.Context,
+	reservation *brokerv1alpha1.Reservation,
+) (string, *brokerv1alpha1.CostEstimate, error) {
+	clusterID := reservation.Spec.TargetClusterID
+	clusterAdv, err := r.findClusterByID(ctx, clusterID)
+	if errors.Is(err, errTargetClusterNotFound) {
+		return fmt.Sprintf("Target cluster '%s' not found; the reservation would fail.", clusterID), nil, nil
+	}
+	if err != nil {
+		return "", nil, err
+	}
+
+	if taint := broker.UntoleratedTaint(clusterAdv, reservation.Spec.Tolerations,
+		brokerv1alpha1.TaintEffectNoReserve); taint != nil {
+		return fmt.Sprintf("Target cluster %s has taint %s the reservation does not tolerate; "+
+			"the reservation would fail.", clusterID, broker.FormatTaint(*taint)), nil, nil
+	}
+
+	cost := r.DecisionEngine.EstimateCost(clusterAdv, &reservation.Spec)
+	fits, err := r.canHost(ctx, clusterAdv, reservation)
+	if err != nil {
+		return "", nil, err
+	}
+	if !fits {
+		return fmt.Sprintf("Insufficient resources in target cluster %s; it would wait in the queue.", clusterID),
+			cost, nil
+	}
+	return fmt.Sprintf("Would be placed on target cluster %s.", clusterID), cost, nil
+}
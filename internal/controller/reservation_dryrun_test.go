@@ -0,0 +1,136 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/pricing"
+)
+
+func TestHandleDryRun(t *testing.T) {
+	tests := []struct {
+		name        string
+		cpu         string
+		target      string
+		wantMessage string
+		wantCost    string
+	}{
+		{name: "selected cluster", cpu: "2", wantMessage: "Would be placed on cluster priced.", wantCost: "1.0000"},
+		{name: "target cluster", cpu: "2", target: "free", wantMessage: "Would be placed on target cluster free."},
+		{
+			name:        "full target cluster",
+			cpu:         "6",
+			target:      "free",
+			wantMessage: "Insufficient resources in target cluster free; it would wait in the queue.",
+		},
+		{
+			name:        "missing target cluster",
+			cpu:         "2",
+			target:      "missing",
+			wantMessage: "Target cluster 'missing' not found; the reservation would fail.",
+		},
+		{name: "no cluster fits", cpu: "16", wantMessage: "it would wait in the queue."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// priced has the most room, so the spread strategy selects it
+			priced := fakeCluster("priced", "8", "16Gi")
+			priced.Spec.Cost = &brokerv1alpha1.CostInfo{CPUCost: "0.5", Currency: "USD"}
+			reservation := fakeReservation("what-if", tt.cpu, "4Gi")
+			reservation.Spec.DryRun = true
+			reservation.Spec.TargetClusterID = tt.target
+			reservation.Spec.Duration = &metav1.Duration{Duration: time.Hour}
+			r := newFakeReconciler(t, priced, fakeCluster("free", "4", "8Gi"), reservation)
+			exchange, err := pricing.NewExchangeTable("USD", "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			r.DecisionEngine.Exchange = exchange
+			before := map[string]string{
+				"priced": getCluster(t, r, "priced").ResourceVersion,
+				"free":   getCluster(t, r, "free").ResourceVersion,
+			}
+
+			result, got := reconcileReservation(t, r, "what-if")
+			if got.Status.Phase != brokerv1alpha1.ReservationPhasePending {
+				t.Errorf("phase = %s, want Pending", got.Status.Phase)
+			}
+			if !strings.HasPrefix(got.Status.Message, "Dry run: ") || !strings.Contains(got.Status.Message, tt.wantMessage) {
+				t.Errorf("message = %q, want a dry run answer containing %q", got.Status.Message, tt.wantMessage)
+			}
+			if got.Spec.TargetClusterID != tt.target || got.Status.LockedResources != nil || got.Status.ReservedAt != nil {
+				t.Errorf("dry run placed the reservation: target %q, locked %v", got.Spec.TargetClusterID,
+					got.Status.LockedResources)
+			}
+			if result.RequeueAfter != dryRunRecheckInterval {
+				t.Errorf("RequeueAfter = %v, want %v", result.RequeueAfter, dryRunRecheckInterval)
+			}
+			if got.Status.Decision == nil {
+				t.Error("status.decision = nil, want the ranked candidates")
+			}
+			var gotCost string
+			if got.Status.EstimatedCost != nil {
+				gotCost = got.Status.EstimatedCost.TotalCost
+			}
+			if gotCost != tt.wantCost {
+				t.Errorf("estimated total cost = %q, want %q", gotCost, tt.wantCost)
+			}
+			for clusterID, resourceVersion := range before {
+				if cluster := getCluster(t, r, clusterID); cluster.ResourceVersion != resourceVersion {
+					t.Errorf("dry run changed ClusterAdvertisement %s: available %s", clusterID,
+						cluster.Spec.Resources.Available.CPU.String())
+				}
+			}
+
+			// Answering again changes nothing
+			if _, again := reconcileReservation(t, r, "what-if"); again.ResourceVersion != got.ResourceVersion {
+				t.Errorf("repeated dry run rewrote the reservation: %q", again.Status.Message)
+			}
+		})
+	}
+}
+
+func TestDryRunUnset(t *testing.T) {
+	reservation := fakeReservation("what-if", "2", "4Gi")
+	reservation.Spec.DryRun = true
+	r := newFakeReconciler(t, fakeCluster("cluster-a", "4", "8Gi"), reservation)
+	if _, got := reconcileReservation(t, r, "what-if"); got.Status.Phase != brokerv1alpha1.ReservationPhasePending {
+		t.Fatalf("phase = %s, want Pending", got.Status.Phase)
+	}
+
+	stored := getReservation(t, r, "what-if")
+	stored.Spec.DryRun = false
+	if err := r.Update(context.Background(), stored); err != nil {
+		t.Fatal(err)
+	}
+	_, got := reconcileReservation(t, r, "what-if")
+	if got.Status.Phase != brokerv1alpha1.ReservationPhaseReserved || got.Spec.TargetClusterID != "cluster-a" {
+		t.Fatalf("phase = %s on %q, want Reserved on cluster-a: %s", got.Status.Phase, got.Spec.TargetClusterID,
+			got.Status.Message)
+	}
+	if available := getCluster(t, r, "cluster-a").Spec.Resources.Available; available.CPU.String() != "2" {
+		t.Errorf("available cpu = %s, want 2", available.CPU.String())
+	}
+}
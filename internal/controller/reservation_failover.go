@@ -0,0 +1,193 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/resource"
+)
+
+const (
+	// defaultFailoverGracePeriod is used when FailoverGracePeriod is not configured
+	defaultFailoverGracePeriod = 5 * time.Minute
+
+	// maxPlacementHistory bounds status.placementHistory
+	maxPlacementHistory = 10
+
+	// placementReasonClusterStale is recorded when a reservation leaves a stale cluster
+	placementReasonClusterStale = "ClusterStale"
+)
+
+func (r *ReservationReconciler) failoverGracePeriod() time.Duration {
+	if r.FailoverGracePeriod > 0 {
+		return r.FailoverGracePeriod
+	}
+	return defaultFailoverGracePeriod
+}
+
+// failoverFromStaleCluster moves a Reserved reservation to another cluster once
+// its target has been stale for longer than the failover grace period. The
+// resources are locked on the new cluster before they are released on the old
+// one. If the reservation stays, retryAt tells when failover becomes due.
+func (r *ReservationReconciler) failoverFromStaleCluster(
+	ctx context.Context,
+	reservation *brokerv1alpha1.Reservation,
+	logger logr.Logger,
+) (moved bool, retryAt *time.Time, err error) {
+
+	fromClusterID := reservation.Spec.TargetClusterID
+	staleSince, err := r.staleSince(ctx, fromClusterID)
+	if err != nil || staleSince == nil {
+		return false, nil, err
+	}
+	if due := staleSince.Add(r.failoverGracePeriod()); time.Now().Before(due) {
+		return false, &due, nil
+	}
+
+	// Place what is actually locked for the rest of the reservation's lifetime
+	locked := resource.LockedResources(reservation)
+	placement := reservation.Spec.DeepCopy()
+	placement.TargetClusterID = ""
+	placement.RequestedResources = locked
+	placement.StartTime = nil
+	placement.Duration = nil
+	placement.EndTime = reservation.Status.ExpiresAt.DeepCopy()
+
+	newCluster, decision, err := r.DecisionEngine.SelectBestCluster(ctx, placement)
+	if err != nil {
+		logger.Info("Target cluster is stale but no other cluster can take the reservation",
+			"targetClusterID", fromClusterID, "reason", err.Error())
+		return false, nil, nil
+	}
+	toClusterID := newCluster.Spec.ClusterID
+
+	var lockedCluster *brokerv1alpha1.ClusterAdvertisement
+	lockErr := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		clusterAdv, err := r.findClusterByID(ctx, toClusterID)
+		if err != nil {
+			return err
+		}
+		// Re-check the window with the other reservations' bookings, like the first lock
+		claims, err := r.DecisionEngine.ListClaims(ctx)
+		if err != nil {
+			return err
+		}
+		if !r.DecisionEngine.CanHost(clusterAdv, placement, claims, reservation.UID, time.Now()) {
+			return errInsufficientResources
+		}
+		if err := resource.AddReservation(clusterAdv, locked); err != nil {
+			return err
+		}
+		lockedCluster = clusterAdv
+		return r.Update(ctx, clusterAdv)
+	})
+	if errors.Is(lockErr, errInsufficientResources) || errors.Is(lockErr, errTargetClusterNotFound) {
+		// The chosen cluster changed in the meantime, try again on the next reconcile
+		return false, nil, nil
+	}
+	if lockErr != nil {
+		return false, nil, lockErr
+	}
+
+	reservation.Spec.TargetClusterID = toClusterID
+	if err := r.Update(ctx, reservation); err != nil {
+		if rollbackErr := r.unlockFrom(ctx, toClusterID, locked); rollbackErr != nil {
+			logger.Error(rollbackErr, "failed to roll back failover lock, the Reserved ledger check will report the drift",
+				"targetClusterID", toClusterID)
+		}
+		return false, nil, err
+	}
+
+	if err := r.unlockFrom(ctx, fromClusterID, locked); err != nil {
+		// The stale cluster keeps a stale Reserved counter; the ledger check reports it
+		logger.Error(err, "failed to release resources on stale cluster", "clusterID", fromClusterID)
+	}
+
+	now := metav1.Now()
+	reservation.Status.PlacementHistory = append(reservation.Status.PlacementHistory, brokerv1alpha1.PlacementEvent{
+		Time:          now,
+		FromClusterID: fromClusterID,
+		ToClusterID:   toClusterID,
+		Reason:        placementReasonClusterStale,
+	})
+	if extra := len(reservation.Status.PlacementHistory) - maxPlacementHistory; extra > 0 {
+		reservation.Status.PlacementHistory = reservation.Status.PlacementHistory[extra:]
+	}
+	reservation.Status.Message = fmt.Sprintf("Moved from stale cluster %s to %s", fromClusterID, toClusterID)
+	reservation.Status.Decision = decision.Summary()
+	reservation.Status.EstimatedCost = r.DecisionEngine.EstimateCost(lockedCluster, &reservation.Spec)
+	reservation.Status.LastUpdateTime = now
+	if err := r.Status().Update(ctx, reservation); err != nil {
+		return false, nil, err
+	}
+
+	logger.Info("Reservation failed over from stale cluster",
+		"from", fromClusterID,
+		"to", toClusterID,
+		"locked", resource.FormatRequested(locked))
+	return true, nil, nil
+}
+
+// staleSince returns when a cluster became stale, or nil if it is not stale.
+// A cluster that is no longer registered counts as stale since forever.
+func (r *ReservationReconciler) staleSince(ctx context.Context, clusterID string) (*time.Time, error) {
+	clusterAdv, err := r.findClusterByID(ctx, clusterID)
+	if errors.Is(err, errTargetClusterNotFound) {
+		return &time.Time{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	stale := meta.FindStatusCondition(clusterAdv.Status.Conditions, brokerv1alpha1.ClusterAdvertisementConditionStale)
+	if stale == nil || stale.Status != metav1.ConditionTrue {
+		return nil, nil
+	}
+	return &stale.LastTransitionTime.Time, nil
+}
+
+// unlockFrom gives quantities back to a cluster's Reserved counter. A cluster
+// that is no longer registered has nothing to give back to.
+func (r *ReservationReconciler) unlockFrom(
+	ctx context.Context,
+	clusterID string,
+	quantities brokerv1alpha1.RequestedResourceQuantities,
+) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		clusterAdv, err := r.findClusterByID(ctx, clusterID)
+		if errors.Is(err, errTargetClusterNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := resource.RemoveReservation(clusterAdv, quantities); err != nil {
+			return err
+		}
+		return r.Update(ctx, clusterAdv)
+	})
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+)
+
+func TestFailoverFromStaleCluster(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name string
+		// free adds a cluster without bookings
+		free          bool
+		wantClusterID string
+	}{
+		{name: "only a cluster with a conflicting booking", wantClusterID: "stale"},
+		{name: "cluster without bookings", free: true, wantClusterID: "free"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stale := fakeCluster("stale", "8", "16Gi")
+			stale.Status.Active = false
+			stale.Status.Conditions = []metav1.Condition{{
+				Type:               brokerv1alpha1.ClusterAdvertisementConditionStale,
+				Status:             metav1.ConditionTrue,
+				Reason:             "NoAdvertisement",
+				LastTransitionTime: metav1.NewTime(now.Add(-time.Hour)),
+			}}
+			reservation := fakeReservation("moving", "4", "8Gi")
+			reservation.Spec.Duration = &metav1.Duration{Duration: 3 * time.Hour}
+			reservation.Spec.FailoverPolicy = brokerv1alpha1.FailoverPolicyReschedule
+			fakeReserved(t, reservation, stale, now.Add(-time.Hour))
+
+			// Has room now, but is booked before the reservation expires
+			booked := fakeCluster("booked", "8", "16Gi")
+			booking := fakeReservation("booking", "6", "12Gi")
+			booking.Finalizers = []string{brokerv1alpha1.ReservationFinalizer}
+			booking.Spec.TargetClusterID = "booked"
+			booking.Spec.StartTime = &metav1.Time{Time: now.Add(time.Hour)}
+			booking.Spec.Duration = &metav1.Duration{Duration: time.Hour}
+			booking.Status.Phase = brokerv1alpha1.ReservationPhaseScheduled
+
+			objects := []client.Object{stale, reservation, booked, booking}
+			if tt.free {
+				objects = append(objects, fakeCluster("free", "8", "16Gi"))
+			}
+			r := newFakeReconciler(t, objects...)
+			_, got := reconcileReservation(t, r, "moving")
+
+			if got.Spec.TargetClusterID != tt.wantClusterID {
+				t.Fatalf("target cluster = %s, want %s: %s", got.Spec.TargetClusterID, tt.wantClusterID, got.Status.Message)
+			}
+			if available := getCluster(t, r, "booked").Spec.Resources.Available; available.CPU.String() != "8" {
+				t.Errorf("booked cluster available cpu = %s, want 8", available.CPU.String())
+			}
+			if tt.wantClusterID == "stale" {
+				return
+			}
+			if len(got.Status.PlacementHistory) != 1 || got.Status.PlacementHistory[0].FromClusterID != "stale" {
+				t.Errorf("placement history = %+v, want one move from stale", got.Status.PlacementHistory)
+			}
+			if available := getCluster(t, r, tt.wantClusterID).Spec.Resources.Available; available.CPU.String() != "4" {
+				t.Errorf("new cluster available cpu = %s, want 4", available.CPU.String())
+			}
+			if available := getCluster(t, r, "stale").Spec.Resources.Available; available.CPU.String() != "8" {
+				t.Errorf("stale cluster available cpu = %s, want 8", available.CPU.String())
+			}
+		})
+	}
+}
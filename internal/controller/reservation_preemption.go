@@ -0,0 +1,215 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/broker"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/resource"
+)
+
+// errPreemptionNoRoom means the victims' resources do not make room for the
+// preemptor on the current ClusterAdvertisement
+var errPreemptionNoRoom = errors.New("preemption does not free enough resources")
+
+// preemptForReservation makes room for a reservation that cannot fit by evicting
+// lower-priority Reserved reservations. The victims' resources are handed over to
+// the reservation in a single ClusterAdvertisement update, which fails without
+// evicting anybody if the reservation still does not fit; only then are the
+// victims marked Preempted. If no cluster can make room the reservation is queued.
+func (r *ReservationReconciler) preemptForReservation(
+	ctx context.Context,
+	reservation *brokerv1alpha1.Reservation,
+	logger logr.Logger,
+) (ctrl.Result, error) {
+
+	plan, err := r.DecisionEngine.PlanPreemption(ctx, &reservation.Spec)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if plan == nil {
+		return r.queueReservation(ctx, reservation, fmt.Sprintf("No suitable cluster found and no "+
+			"lower-priority reservations can be preempted. Requested: %s. Waiting in queue for capacity.",
+			resource.FormatRequested(reservation.Spec.RequestedResources)), logger)
+	}
+
+	brokerSelected := reservation.Spec.TargetClusterID == ""
+	if brokerSelected {
+		reservation.Spec.TargetClusterID = plan.Cluster.Spec.ClusterID
+		if err := r.Update(ctx, reservation); err != nil {
+			logger.Error(err, "Failed to update reservation with target cluster")
+			return ctrl.Result{}, err
+		}
+	}
+
+	victimNames := make([]string, 0, len(plan.Victims))
+	for _, victim := range plan.Victims {
+		victimNames = append(victimNames, victim.Namespace+"/"+victim.Name)
+	}
+
+	// Take the victims off the Reserved counter and lock the reservation in one update
+	var lockedCluster *brokerv1alpha1.ClusterAdvertisement
+	lockErr := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		clusterAdv, err := r.findClusterByID(ctx, plan.Cluster.Spec.ClusterID)
+		if err != nil {
+			return err
+		}
+
+		// The same check the plan was made with, against the latest cluster and claims
+		claims, err := r.DecisionEngine.ListClaims(ctx)
+		if err != nil {
+			return err
+		}
+		if !r.DecisionEngine.CanHostWithout(clusterAdv, &reservation.Spec, claims, plan.Victims,
+			reservation.UID, time.Now()) {
+			return errPreemptionNoRoom
+		}
+
+		for _, victim := range plan.Victims {
+			if err := resource.RemoveReservation(clusterAdv, resource.LockedResources(victim)); err != nil {
+				return err
+			}
+		}
+		if err := resource.AddReservation(clusterAdv, reservation.Spec.RequestedResources); err != nil {
+			return err
+		}
+		if err := r.Update(ctx, clusterAdv); err != nil {
+			return err
+		}
+		lockedCluster = clusterAdv
+		return nil
+	})
+	if lockErr != nil {
+		// Nobody was evicted, the reservation gives up the cluster it planned on
+		if err := r.clearPreemptionTarget(ctx, reservation, brokerSelected); err != nil {
+			return ctrl.Result{}, err
+		}
+		if errors.Is(lockErr, errPreemptionNoRoom) {
+			// Capacity changed between planning and locking
+			return r.queueReservation(ctx, reservation, fmt.Sprintf("Preemption did not free enough resources. "+
+				"Requested: %s. Waiting in queue for capacity.",
+				resource.FormatRequested(reservation.Spec.RequestedResources)), logger)
+		}
+		logger.Error(lockErr, "failed to hand over preempted resources",
+			"targetClusterID", plan.Cluster.Spec.ClusterID,
+			"victims", victimNames)
+		return ctrl.Result{}, lockErr
+	}
+
+	// The victims no longer hold resources; a victim that changed since planning
+	// undoes the whole preemption
+	var preempted []*brokerv1alpha1.Reservation
+	for _, victim := range plan.Victims {
+		previous := victim.Status.DeepCopy()
+		victim.Status.Phase = brokerv1alpha1.ReservationPhasePreempted
+		victim.Status.Message = fmt.Sprintf("Preempted by higher-priority reservation %s/%s (priority %d > %d). "+
+			"Resources in cluster '%s' were reassigned.",
+			reservation.Namespace, reservation.Name, reservation.Spec.Priority, victim.Spec.Priority,
+			victim.Spec.TargetClusterID)
+		victim.Status.LastUpdateTime = metav1.Now()
+		if err := r.Status().Update(ctx, victim); err != nil {
+			logger.Error(err, "Failed to preempt reservation, rolling back", "victim", victim.Name)
+			victim.Status = *previous
+			if rollbackErr := r.rollbackPreemption(ctx, reservation, plan, preempted); rollbackErr != nil {
+				logger.Error(rollbackErr, "Failed to roll back preemption",
+					"targetClusterID", plan.Cluster.Spec.ClusterID,
+					"victims", victimNames)
+			} else if clearErr := r.clearPreemptionTarget(ctx, reservation, brokerSelected); clearErr != nil {
+				logger.Error(clearErr, "Failed to clear target cluster after rollback")
+			}
+			return ctrl.Result{}, err
+		}
+		preempted = append(preempted, victim)
+	}
+
+	logger.Info("Preempted lower-priority reservations",
+		"targetClusterID", plan.Cluster.Spec.ClusterID,
+		"victims", strings.Join(victimNames, ", "))
+
+	return r.markReserved(ctx, reservation, lockedCluster, logger)
+}
+
+// rollbackPreemption undoes a preemption whose victims could not all be marked:
+// the victims already marked Preempted are Reserved again, and the
+// ClusterAdvertisement gets the victims' resources back from the reservation
+func (r *ReservationReconciler) rollbackPreemption(
+	ctx context.Context,
+	reservation *brokerv1alpha1.Reservation,
+	plan *broker.PreemptionPlan,
+	preempted []*brokerv1alpha1.Reservation,
+) error {
+	for _, victim := range preempted {
+		err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			current := &brokerv1alpha1.Reservation{}
+			if err := r.Get(ctx, client.ObjectKeyFromObject(victim), current); err != nil {
+				return err
+			}
+			if current.Status.Phase != brokerv1alpha1.ReservationPhasePreempted {
+				return nil
+			}
+			current.Status.Phase = brokerv1alpha1.ReservationPhaseReserved
+			current.Status.Message = fmt.Sprintf("Resources locked in cluster %s", current.Spec.TargetClusterID)
+			current.Status.LastUpdateTime = metav1.Now()
+			return r.Status().Update(ctx, current)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		clusterAdv, err := r.findClusterByID(ctx, plan.Cluster.Spec.ClusterID)
+		if err != nil {
+			return err
+		}
+		if err := resource.RemoveReservation(clusterAdv, reservation.Spec.RequestedResources); err != nil {
+			return err
+		}
+		for _, victim := range plan.Victims {
+			if err := resource.AddReservation(clusterAdv, resource.LockedResources(victim)); err != nil {
+				return err
+			}
+		}
+		return r.Update(ctx, clusterAdv)
+	})
+}
+
+// clearPreemptionTarget drops the target cluster the broker picked for a
+// preemption that did not happen
+func (r *ReservationReconciler) clearPreemptionTarget(
+	ctx context.Context,
+	reservation *brokerv1alpha1.Reservation,
+	brokerSelected bool,
+) error {
+	if !brokerSelected {
+		return nil
+	}
+	reservation.Spec.TargetClusterID = ""
+	return r.Update(ctx, reservation)
+}
@@ -0,0 +1,58 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+)
+
+func TestPreemptForReservation(t *testing.T) {
+	cluster := fakeCluster("cluster-a", "8", "16Gi")
+	cluster.Spec.Resources.Available.CPU = resource.MustParse("0")
+	cluster.Spec.Resources.Available.Memory = resource.MustParse("8Gi")
+	cluster.Spec.Resources.Reserved = &brokerv1alpha1.ResourceQuantities{
+		CPU: resource.MustParse("8"), Memory: resource.MustParse("8Gi"),
+	}
+
+	victim := fakeReservation("low", "8", "8Gi")
+	victim.Finalizers = []string{brokerv1alpha1.ReservationFinalizer}
+	victim.Spec.TargetClusterID = "cluster-a"
+	victim.Spec.Priority = 1
+	victim.Status.Phase = brokerv1alpha1.ReservationPhaseReserved
+
+	preemptor := fakeReservation("high", "4", "4Gi")
+	preemptor.Spec.TargetClusterID = "cluster-a"
+	preemptor.Spec.Priority = 5
+	preemptor.Spec.PreemptionPolicy = brokerv1alpha1.PreemptionPolicyPreemptLowerPriority
+
+	r := newFakeReconciler(t, cluster, victim, preemptor)
+	_, got := reconcileReservation(t, r, "high")
+	if got.Status.Phase != brokerv1alpha1.ReservationPhaseReserved {
+		t.Fatalf("preemptor phase = %s, want Reserved: %s", got.Status.Phase, got.Status.Message)
+	}
+	if evicted := getReservation(t, r, "low"); evicted.Status.Phase != brokerv1alpha1.ReservationPhasePreempted {
+		t.Errorf("victim phase = %s, want Preempted", evicted.Status.Phase)
+	}
+	locked := getCluster(t, r, "cluster-a")
+	if available := locked.Spec.Resources.Available.CPU; available.Cmp(resource.MustParse("4")) != 0 {
+		t.Errorf("available cpu = %s, want 4", available.String())
+	}
+}
@@ -0,0 +1,366 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/broker"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/resource"
+)
+
+// queueRecheckInterval is how often a queued reservation is re-evaluated when no
+// ClusterAdvertisement change has triggered it in the meantime
+const queueRecheckInterval = 1 * time.Minute
+
+// handleQueuedReservation re-evaluates a reservation waiting for capacity
+func (r *ReservationReconciler) handleQueuedReservation(
+	ctx context.Context,
+	reservation *brokerv1alpha1.Reservation,
+	logger logr.Logger,
+) (ctrl.Result, error) {
+
+	if deadline := queueDeadline(reservation); deadline != nil && !time.Now().Before(*deadline) {
+		logger.Info("Queue timeout exceeded, giving up",
+			"queuedAt", reservation.Status.QueuedAt,
+			"queueTimeout", reservation.Spec.QueueTimeout.Duration.String())
+		reservation.Status.Phase = brokerv1alpha1.ReservationPhaseFailed
+		reservation.Status.Message = fmt.Sprintf("No capacity became available within the queue timeout of %s. "+
+			"Requested: %s.",
+			reservation.Spec.QueueTimeout.Duration.String(),
+			resource.FormatRequested(reservation.Spec.RequestedResources))
+		reservation.Status.LastUpdateTime = metav1.Now()
+		if err := r.Status().Update(ctx, reservation); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, nil
+	}
+
+	if _, end := broker.RequestedWindow(&reservation.Spec, time.Now()); !end.IsZero() && !time.Now().Before(end) {
+		return r.failReservation(ctx, reservation, fmt.Sprintf("The requested window ended at %s "+
+			"before capacity became available.", end.Format(time.RFC3339)))
+	}
+
+	return r.handlePendingReservation(ctx, reservation, logger)
+}
+
+// queueReservation moves a reservation into the Queued phase. A reservation that
+// is already queued keeps its original QueuedAt so it does not lose its place.
+func (r *ReservationReconciler) queueReservation(
+	ctx context.Context,
+	reservation *brokerv1alpha1.Reservation,
+	message string,
+	logger logr.Logger,
+) (ctrl.Result, error) {
+
+	if reservation.Status.Phase != brokerv1alpha1.ReservationPhaseQueued {
+		now := metav1.Now()
+		reservation.Status.Phase = brokerv1alpha1.ReservationPhaseQueued
+		reservation.Status.QueuedAt = &now
+		reservation.Status.Message = message
+		reservation.Status.LastUpdateTime = now
+		if err := r.Status().Update(ctx, reservation); err != nil {
+			return ctrl.Result{}, err
+		}
+		logger.Info("Reservation queued",
+			"requesterID", reservation.Spec.RequesterID,
+			"priority", reservation.Spec.Priority,
+			"requested", resource.FormatRequested(reservation.Spec.RequestedResources))
+	} else if reservation.Status.Message != message {
+		reservation.Status.Message = message
+		reservation.Status.LastUpdateTime = metav1.Now()
+		if err := r.Status().Update(ctx, reservation); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	return ctrl.Result{RequeueAfter: queueRequeueAfter(reservation)}, nil
+}
+
+// placeableAhead returns the first reservation ahead of this one in the waiting
+// queue that could be placed right now on a cluster this one could use, or nil.
+// A reservation yields to it so that freed capacity goes to the queue in
+// priority order; reservations that would land on other clusters do not hold it
+// up. Every candidate is checked against one snapshot of the clusters, claims
+// and quotas.
+func (r *ReservationReconciler) placeableAhead(
+	ctx context.Context,
+	reservation *brokerv1alpha1.Reservation,
+) (*brokerv1alpha1.Reservation, error) {
+	snapshot, err := r.DecisionEngine.TakeSnapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	before := r.queueOrder(snapshot)
+
+	var ahead []*brokerv1alpha1.Reservation
+	for i := range snapshot.Claims.Reservations {
+		item := &snapshot.Claims.Reservations[i]
+		if item.UID == reservation.UID || item.Status.Phase != brokerv1alpha1.ReservationPhaseQueued {
+			continue
+		}
+		if before(item, reservation) {
+			ahead = append(ahead, item)
+		}
+	}
+	if len(ahead) == 0 {
+		return nil, nil
+	}
+	sort.Slice(ahead, func(i, j int) bool { return before(ahead[i], ahead[j]) })
+
+	now := time.Now()
+	wanted := r.candidateClusters(snapshot, reservation, now)
+	if len(wanted) == 0 {
+		return nil, nil
+	}
+	placements := r.queuePlacements.forSnapshot(snapshot, now)
+	for _, candidate := range ahead {
+		if candidate.Spec.TargetClusterID != "" && !wanted[candidate.Spec.TargetClusterID] {
+			continue
+		}
+		for _, clusterID := range placements.get(candidate, func() []string {
+			return r.placement(snapshot, candidate, now)
+		}) {
+			if wanted[clusterID] {
+				return candidate, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// candidateClusters returns the clusters a reservation could take capacity from
+// in the snapshot: its target cluster, every cluster that could host it whole,
+// or the clusters a split would use
+func (r *ReservationReconciler) candidateClusters(
+	snapshot *broker.Snapshot,
+	reservation *brokerv1alpha1.Reservation,
+	now time.Time,
+) map[string]bool {
+	if reservation.Spec.TargetClusterID != "" {
+		return map[string]bool{reservation.Spec.TargetClusterID: true}
+	}
+
+	candidates := map[string]bool{}
+	if _, decision, _ := r.DecisionEngine.SelectBestClusterIn(snapshot, &reservation.Spec); decision != nil {
+		for _, candidate := range decision.Candidates {
+			if candidate.FilteredBy == "" {
+				candidates[candidate.Cluster.Spec.ClusterID] = true
+			}
+		}
+	}
+	if len(candidates) == 0 && reservation.Spec.SplitPolicy != nil && !broker.StartsLater(&reservation.Spec, now) {
+		fragments, _ := r.DecisionEngine.PlanSplitIn(snapshot, &reservation.Spec)
+		for _, fragment := range fragments {
+			candidates[fragment.ClusterID] = true
+		}
+	}
+	return candidates
+}
+
+// placement returns the clusters a reservation would take capacity from if it
+// were placed in the snapshot: its target cluster, the best cluster, or the
+// clusters of a split if it allows that. It is empty if the reservation cannot
+// be placed, including when it is beyond its requester's quota.
+func (r *ReservationReconciler) placement(
+	snapshot *broker.Snapshot,
+	reservation *brokerv1alpha1.Reservation,
+	now time.Time,
+) []string {
+	if quota, _ := quotaExceeded(reservation, snapshot.RequesterQuotas(reservation.Spec.RequesterID),
+		snapshot.Claims); quota != nil {
+		return nil
+	}
+
+	if reservation.Spec.TargetClusterID != "" {
+		cluster := snapshot.Cluster(reservation.Spec.TargetClusterID)
+		if cluster == nil ||
+			!r.DecisionEngine.CanHost(cluster, &reservation.Spec, snapshot.Claims, reservation.UID, now) {
+			return nil
+		}
+		return []string{cluster.Spec.ClusterID}
+	}
+
+	if best, _, err := r.DecisionEngine.SelectBestClusterIn(snapshot, &reservation.Spec); err == nil {
+		return []string{best.Spec.ClusterID}
+	}
+	if reservation.Spec.SplitPolicy != nil && !broker.StartsLater(&reservation.Spec, now) {
+		fragments, err := r.DecisionEngine.PlanSplitIn(snapshot, &reservation.Spec)
+		if err != nil {
+			return nil
+		}
+		clusterIDs := make([]string, 0, len(fragments))
+		for _, fragment := range fragments {
+			clusterIDs = append(clusterIDs, fragment.ClusterID)
+		}
+		return clusterIDs
+	}
+	return nil
+}
+
+// placementCache keeps where queued reservations would be placed, so that a
+// burst of new reservations does not place every queued one again and again.
+// The placements hold as long as the snapshot version does not change, and are
+// recomputed at least every queueRecheckInterval because booking windows move
+// with time.
+type placementCache struct {
+	mu         sync.Mutex
+	version    string
+	computedAt time.Time
+	placements map[string][]string
+}
+
+// forSnapshot returns the cache for a snapshot, emptied if the snapshot's
+// capacity state differs from the one the placements were computed on
+func (c *placementCache) forSnapshot(snapshot *broker.Snapshot, now time.Time) *placementCache {
+	version := snapshot.Version()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.placements == nil || c.version != version || now.Sub(c.computedAt) >= queueRecheckInterval {
+		c.version = version
+		c.computedAt = now
+		c.placements = map[string][]string{}
+	}
+	return c
+}
+
+// get returns the placement of a reservation, computing it with place the first
+// time it is asked for in this version of the reservation
+func (c *placementCache) get(reservation *brokerv1alpha1.Reservation, place func() []string) []string {
+	key := string(reservation.UID) + "@" + reservation.ResourceVersion
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if clusterIDs, ok := c.placements[key]; ok {
+		return clusterIDs
+	}
+	clusterIDs := place()
+	c.placements[key] = clusterIDs
+	return clusterIDs
+}
+
+// queuedReservationRequests maps a ClusterAdvertisement change to reconcile
+// requests for every queued reservation. Releasing a reservation updates its
+// cluster's advertisement, so this also wakes the queue on release.
+func (r *ReservationReconciler) queuedReservationRequests(ctx context.Context, _ client.Object) []reconcile.Request {
+	reservationList := &brokerv1alpha1.ReservationList{}
+	if err := r.List(ctx, reservationList); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list reservations for queue re-evaluation")
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for i := range reservationList.Items {
+		item := &reservationList.Items[i]
+		if item.Status.Phase != brokerv1alpha1.ReservationPhaseQueued {
+			continue
+		}
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Name: item.Name, Namespace: item.Namespace},
+		})
+	}
+	return requests
+}
+
+// queueOrder returns how the waiting queue is ordered under the admission policy
+func (r *ReservationReconciler) queueOrder(
+	snapshot *broker.Snapshot,
+) func(a, b *brokerv1alpha1.Reservation) bool {
+	if r.AdmissionPolicy != broker.AdmissionFairShare {
+		return queuedBefore
+	}
+
+	shares := broker.FairShares(snapshot, r.FairShareHalfLife, time.Now())
+	return func(a, b *brokerv1alpha1.Reservation) bool {
+		return fairShareBefore(a, b, shares)
+	}
+}
+
+// fairShareBefore reports whether a is ahead of b in the waiting queue under the
+// fair-share admission policy: higher priority first, then the requester with
+// the lower weighted share of recent usage, then in FIFO order
+func fairShareBefore(a, b *brokerv1alpha1.Reservation, shares map[string]float64) bool {
+	if a.Spec.Priority != b.Spec.Priority {
+		return a.Spec.Priority > b.Spec.Priority
+	}
+	if aShare, bShare := shares[a.Spec.RequesterID], shares[b.Spec.RequesterID]; aShare != bShare {
+		return aShare < bShare
+	}
+	return queuedBefore(a, b)
+}
+
+// queuedBefore reports whether a is ahead of b in the waiting queue: higher
+// priority first, then the one waiting longest, then by namespace and name
+func queuedBefore(a, b *brokerv1alpha1.Reservation) bool {
+	if a.Spec.Priority != b.Spec.Priority {
+		return a.Spec.Priority > b.Spec.Priority
+	}
+	if aSince, bSince := queuedSince(a), queuedSince(b); !aSince.Equal(bSince) {
+		return aSince.Before(bSince)
+	}
+	if a.Namespace != b.Namespace {
+		return a.Namespace < b.Namespace
+	}
+	return a.Name < b.Name
+}
+
+// queuedSince is when a reservation started waiting. Reservations that have not
+// been queued yet count from their creation.
+func queuedSince(reservation *brokerv1alpha1.Reservation) time.Time {
+	if reservation.Status.QueuedAt != nil {
+		return reservation.Status.QueuedAt.Time
+	}
+	return reservation.CreationTimestamp.Time
+}
+
+// queueDeadline returns when a queued reservation gives up, or nil if it waits indefinitely
+func queueDeadline(reservation *brokerv1alpha1.Reservation) *time.Time {
+	if reservation.Spec.QueueTimeout == nil || reservation.Status.QueuedAt == nil {
+		return nil
+	}
+	deadline := reservation.Status.QueuedAt.Add(reservation.Spec.QueueTimeout.Duration)
+	return &deadline
+}
+
+// queueRequeueAfter returns when a queued reservation should be looked at again
+// even without a ClusterAdvertisement change
+func queueRequeueAfter(reservation *brokerv1alpha1.Reservation) time.Duration {
+	deadline := queueDeadline(reservation)
+	if deadline == nil {
+		return queueRecheckInterval
+	}
+	if remaining := time.Until(*deadline); remaining < queueRecheckInterval {
+		if remaining <= 0 {
+			return time.Second
+		}
+		return remaining
+	}
+	return queueRecheckInterval
+}
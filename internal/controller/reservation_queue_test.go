@@ -0,0 +1,121 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/broker"
+)
+
+func TestPlaceableAhead(t *testing.T) {
+	queued := fakeReservation("queued-on-b", "2", "2Gi")
+	queued.Spec.TargetClusterID = "cluster-b"
+	queued.Spec.Priority = 10
+	queued.Status.Phase = brokerv1alpha1.ReservationPhaseQueued
+	queued.Status.QueuedAt = &metav1.Time{Time: time.Now().Add(-time.Hour)}
+
+	tests := []struct {
+		name      string
+		target    string
+		wantAhead bool
+	}{
+		{name: "competing for the same cluster", target: "cluster-b", wantAhead: true},
+		{name: "another cluster", target: "cluster-a"},
+		{name: "any cluster, including the one the queued reservation needs", wantAhead: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reservation := fakeReservation("new", "2", "2Gi")
+			reservation.Spec.TargetClusterID = tt.target
+			r := newFakeReconciler(t,
+				fakeCluster("cluster-a", "4", "8Gi"), fakeCluster("cluster-b", "4", "8Gi"),
+				queued.DeepCopy(), reservation)
+
+			ahead, err := r.placeableAhead(context.Background(), reservation)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := ahead != nil; got != tt.wantAhead {
+				t.Errorf("placeableAhead() = %v, want a reservation ahead: %v", ahead, tt.wantAhead)
+			}
+		})
+	}
+}
+
+func TestPlaceableAheadNotPlaceable(t *testing.T) {
+	// The queued reservation does not fit anywhere, so it holds up nobody
+	queued := fakeReservation("queued-too-large", "16", "2Gi")
+	queued.Spec.Priority = 10
+	queued.Status.Phase = brokerv1alpha1.ReservationPhaseQueued
+	queued.Status.QueuedAt = &metav1.Time{Time: time.Now().Add(-time.Hour)}
+	reservation := fakeReservation("new", "2", "2Gi")
+	r := newFakeReconciler(t, fakeCluster("cluster-a", "4", "8Gi"), queued, reservation)
+
+	_, stored := reconcileReservation(t, r, "new")
+	if stored.Status.Phase != brokerv1alpha1.ReservationPhaseReserved {
+		t.Errorf("phase = %s, want Reserved: %s", stored.Status.Phase, stored.Status.Message)
+	}
+}
+
+func TestPlacementCache(t *testing.T) {
+	snapshot := func(resourceVersion string) *broker.Snapshot {
+		cluster := fakeCluster("cluster-a", "4", "8Gi")
+		cluster.ResourceVersion = resourceVersion
+		return &broker.Snapshot{
+			Clusters: []brokerv1alpha1.ClusterAdvertisement{*cluster},
+			Claims:   &broker.Claims{},
+		}
+	}
+	reservation := fakeReservation("queued", "2", "2Gi")
+	now := time.Now()
+	computed := 0
+	place := func() []string {
+		computed++
+		return []string{"cluster-a"}
+	}
+
+	cache := &placementCache{}
+	cache.forSnapshot(snapshot("1"), now).get(reservation, place)
+	cache.forSnapshot(snapshot("1"), now.Add(time.Second)).get(reservation, place)
+	if computed != 1 {
+		t.Errorf("placement computed %d times for one capacity state, want 1", computed)
+	}
+
+	cache.forSnapshot(snapshot("2"), now.Add(time.Second)).get(reservation, place)
+	if computed != 2 {
+		t.Errorf("placement computed %d times after a cluster changed, want 2", computed)
+	}
+
+	cache.forSnapshot(snapshot("2"), now.Add(queueRecheckInterval+time.Second)).get(reservation, place)
+	if computed != 3 {
+		t.Errorf("placement computed %d times after the recheck interval, want 3", computed)
+	}
+
+	// Reservations still waiting do not change the capacity state
+	waiting := snapshot("2")
+	waiting.Claims.Reservations = append(waiting.Claims.Reservations, *fakeReservation("pending", "1", "1Gi"))
+	if waiting.Version() != snapshot("2").Version() {
+		t.Error("a Pending reservation changed the snapshot version")
+	}
+}
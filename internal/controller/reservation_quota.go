@@ -0,0 +1,132 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/broker"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/resource"
+)
+
+// exceededQuota returns the first RequesterQuota the reservation would exceed
+// on top of what its requester already holds, and which limits it exceeds.
+// Per-cluster limits are checked for an explicit target only; the decision
+// engine skips clusters whose limits are used up when it picks one itself.
+func (r *ReservationReconciler) exceededQuota(
+	ctx context.Context,
+	reservation *brokerv1alpha1.Reservation,
+) (*brokerv1alpha1.RequesterQuota, string, error) {
+	quotas, err := r.DecisionEngine.RequesterQuotas(ctx, reservation.Spec.RequesterID)
+	if err != nil || len(quotas) == 0 {
+		return nil, "", err
+	}
+	claims, err := r.DecisionEngine.ListClaims(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	quota, exceeded := quotaExceeded(reservation, quotas, claims)
+	return quota, exceeded, nil
+}
+
+// quotaExceeded is exceededQuota evaluated against already listed quotas and claims
+func quotaExceeded(
+	reservation *brokerv1alpha1.Reservation,
+	quotas []brokerv1alpha1.RequesterQuota,
+	claims *broker.Claims,
+) (*brokerv1alpha1.RequesterQuota, string) {
+	if len(quotas) == 0 {
+		return nil, ""
+	}
+
+	usage := broker.RequesterUsage(reservation.Spec.RequesterID, claims)
+	usage.Add([]brokerv1alpha1.ReservationFragment{{
+		ClusterID:       reservation.Spec.TargetClusterID,
+		LockedResources: reservation.Spec.RequestedResources,
+	}}, 1)
+
+	var clusterIDs []string
+	if reservation.Spec.TargetClusterID != "" {
+		clusterIDs = append(clusterIDs, reservation.Spec.TargetClusterID)
+	}
+	for i := range quotas {
+		if exceeded := broker.ExceededQuota(&quotas[i], usage, clusterIDs); exceeded != "" {
+			return &quotas[i], exceeded
+		}
+	}
+	return nil, ""
+}
+
+// resizeQuotaExceeded returns the first RequesterQuota the reservation would
+// exceed if it grew from its locked quantities to the requested ones. The
+// reservation is already counted, so only the difference is added.
+func resizeQuotaExceeded(
+	reservation *brokerv1alpha1.Reservation,
+	quotas []brokerv1alpha1.RequesterQuota,
+	claims *broker.Claims,
+) (*brokerv1alpha1.RequesterQuota, string) {
+	if len(quotas) == 0 {
+		return nil, ""
+	}
+
+	growth := resource.RequestedList(reservation.Spec.RequestedResources)
+	resource.SubFrom(growth, resource.RequestedList(resource.LockedResources(reservation)))
+	usage := broker.RequesterUsage(reservation.Spec.RequesterID, claims)
+	usage.Grow(reservation.Spec.TargetClusterID, growth)
+
+	clusterIDs := []string{reservation.Spec.TargetClusterID}
+	for i := range quotas {
+		if exceeded := broker.ExceededQuota(&quotas[i], usage, clusterIDs); exceeded != "" {
+			return &quotas[i], exceeded
+		}
+	}
+	return nil, ""
+}
+
+// enforceQuota keeps a reservation that would exceed a quota of its requester
+// from being placed: it fails if the quota's exceed policy is Reject and waits
+// in the queue otherwise. blocked is false if the reservation is within quota.
+func (r *ReservationReconciler) enforceQuota(
+	ctx context.Context,
+	reservation *brokerv1alpha1.Reservation,
+	logger logr.Logger,
+) (result ctrl.Result, blocked bool, err error) {
+	quota, exceeded, err := r.exceededQuota(ctx, reservation)
+	if err != nil || quota == nil {
+		return ctrl.Result{}, false, err
+	}
+
+	logger.Info("Reservation would exceed requester quota",
+		"requesterID", reservation.Spec.RequesterID,
+		"quota", quota.Namespace+"/"+quota.Name,
+		"exceeded", exceeded)
+	message := fmt.Sprintf("Requester %s would exceed RequesterQuota %s/%s (%s).",
+		reservation.Spec.RequesterID, quota.Namespace, quota.Name, exceeded)
+
+	if quota.Spec.ExceedPolicy == brokerv1alpha1.QuotaExceedPolicyReject {
+		reservation.Status.Reason = brokerv1alpha1.ReservationReasonQuotaExceeded
+		result, err := r.failReservation(ctx, reservation, message)
+		return result, true, err
+	}
+	result, err = r.queueReservation(ctx, reservation, message+" Waiting in queue until usage drops.", logger)
+	return result, true, err
+}
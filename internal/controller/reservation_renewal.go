@@ -0,0 +1,178 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/broker"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/resource"
+)
+
+// Reasons of the Renewed condition
+const (
+	renewalReasonRenewed              = "Renewed"
+	renewalReasonInvalid              = "InvalidRenewal"
+	renewalReasonLifetimeExceeded     = "MaxLifetimeExceeded"
+	renewalReasonInsufficientCapacity = "InsufficientCapacity"
+)
+
+// handleRenewal applies a pending renewal request to a Reserved or Active
+// reservation. A renewal is requested by changing spec.duration, which moves
+// ExpiresAt by the difference, or by setting the renew annotation, which
+// extends ExpiresAt by the annotated duration.
+func (r *ReservationReconciler) handleRenewal(
+	ctx context.Context,
+	reservation *brokerv1alpha1.Reservation,
+	logger logr.Logger,
+) error {
+
+	extension, annotated := reservation.Annotations[brokerv1alpha1.ReservationRenewAnnotation]
+	durationChanged := durationRenewalRequested(reservation)
+	if !annotated && !durationChanged {
+		return nil
+	}
+
+	// The annotation is one-shot: drop it before acting so it is never applied twice
+	if annotated {
+		delete(reservation.Annotations, brokerv1alpha1.ReservationRenewAnnotation)
+		if err := r.Update(ctx, reservation); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	reservedAt := now
+	if reservation.Status.ReservedAt != nil {
+		reservedAt = reservation.Status.ReservedAt.Time
+	}
+
+	var expiresAt time.Time
+	if reservation.Status.ExpiresAt != nil {
+		expiresAt = reservation.Status.ExpiresAt.Time
+	}
+
+	newEnd := expiresAt
+	if durationChanged {
+		if reservation.Status.ExpiresAt != nil && reservation.Status.ObservedDuration != nil {
+			newEnd = newEnd.Add(reservation.Spec.Duration.Duration - reservation.Status.ObservedDuration.Duration)
+		} else {
+			newEnd = reservedAt.Add(reservation.Spec.Duration.Duration)
+		}
+	}
+	if annotated {
+		extendBy, err := time.ParseDuration(extension)
+		if err != nil || extendBy <= 0 {
+			return r.rejectRenewal(ctx, reservation, renewalReasonInvalid,
+				fmt.Sprintf("The %s annotation must be a positive duration such as 2h, got %q.",
+					brokerv1alpha1.ReservationRenewAnnotation, extension))
+		}
+		if newEnd.IsZero() {
+			return r.rejectRenewal(ctx, reservation, renewalReasonInvalid,
+				"The reservation does not expire, there is nothing to extend.")
+		}
+		if newEnd.Before(now) {
+			newEnd = now
+		}
+		newEnd = newEnd.Add(extendBy)
+	}
+
+	if r.MaxReservationLifetime > 0 && newEnd.Sub(reservedAt) > r.MaxReservationLifetime {
+		return r.rejectRenewal(ctx, reservation, renewalReasonLifetimeExceeded,
+			fmt.Sprintf("Renewing until %s would keep the reservation for %s, more than the maximum lifetime of %s.",
+				newEnd.Format(time.RFC3339), newEnd.Sub(reservedAt).Round(time.Second), r.MaxReservationLifetime))
+	}
+
+	if expiresAt.IsZero() || newEnd.After(expiresAt) {
+		claims, err := r.DecisionEngine.ListClaims(ctx)
+		if err != nil {
+			return err
+		}
+		// Every cluster holding part of the reservation has to keep it
+		for _, holding := range resource.Holdings(reservation) {
+			clusterAdv, err := r.findClusterByID(ctx, holding.ClusterID)
+			if err != nil {
+				return err
+			}
+			if !broker.CanExtend(clusterAdv, reservation, claims, newEnd, now) {
+				return r.rejectRenewal(ctx, reservation, renewalReasonInsufficientCapacity,
+					fmt.Sprintf("Cluster '%s' cannot keep the resources until %s, the capacity is booked by other reservations.",
+						holding.ClusterID, newEnd.Format(time.RFC3339)))
+			}
+		}
+	}
+
+	renewedUntil := metav1.NewTime(newEnd)
+	reservation.Status.ExpiresAt = &renewedUntil
+	if reservation.Spec.Duration != nil {
+		reservation.Status.ObservedDuration = reservation.Spec.Duration.DeepCopy()
+	}
+	reservation.Status.Message = fmt.Sprintf("Reservation renewed until %s", newEnd.Format(time.RFC3339))
+	reservation.Status.LastUpdateTime = metav1.Now()
+	meta.SetStatusCondition(&reservation.Status.Conditions, metav1.Condition{
+		Type:    brokerv1alpha1.ReservationConditionRenewed,
+		Status:  metav1.ConditionTrue,
+		Reason:  renewalReasonRenewed,
+		Message: reservation.Status.Message,
+	})
+	if err := r.Status().Update(ctx, reservation); err != nil {
+		return err
+	}
+
+	logger.Info("Reservation renewed",
+		"targetClusterID", reservation.Spec.TargetClusterID,
+		"expiresAt", newEnd.Format(time.RFC3339))
+	return nil
+}
+
+// rejectRenewal records why a renewal request was refused. A refused
+// spec.duration change is retried on every reconcile, so the status is only
+// written when the outcome changes.
+func (r *ReservationReconciler) rejectRenewal(
+	ctx context.Context,
+	reservation *brokerv1alpha1.Reservation,
+	reason, message string,
+) error {
+	changed := meta.SetStatusCondition(&reservation.Status.Conditions, metav1.Condition{
+		Type:    brokerv1alpha1.ReservationConditionRenewed,
+		Status:  metav1.ConditionFalse,
+		Reason:  reason,
+		Message: message,
+	})
+	if !changed {
+		return nil
+	}
+	reservation.Status.LastUpdateTime = metav1.Now()
+	return r.Status().Update(ctx, reservation)
+}
+
+// durationRenewalRequested reports whether spec.duration differs from the
+// duration ExpiresAt was computed from
+func durationRenewalRequested(reservation *brokerv1alpha1.Reservation) bool {
+	if reservation.Spec.Duration == nil {
+		return false
+	}
+	observed := reservation.Status.ObservedDuration
+	return observed == nil || observed.Duration != reservation.Spec.Duration.Duration
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+)
+
+func TestHandleRenewal(t *testing.T) {
+	now := time.Now()
+	reservedAt := now.Add(-30 * time.Minute)
+
+	tests := []struct {
+		name        string
+		annotation  string
+		duration    time.Duration
+		maxLifetime time.Duration
+		// booked is a reservation Scheduled on the same cluster starting then
+		booked      time.Duration
+		wantReason  string
+		wantExpires time.Time
+	}{
+		{
+			name:        "annotation extends the expiry",
+			annotation:  "2h",
+			duration:    time.Hour,
+			wantReason:  renewalReasonRenewed,
+			wantExpires: reservedAt.Add(3 * time.Hour),
+		},
+		{
+			name:        "longer duration moves the expiry",
+			duration:    4 * time.Hour,
+			wantReason:  renewalReasonRenewed,
+			wantExpires: reservedAt.Add(4 * time.Hour),
+		},
+		{
+			name:        "invalid annotation",
+			annotation:  "soon",
+			wantReason:  renewalReasonInvalid,
+			wantExpires: reservedAt.Add(time.Hour),
+		},
+		{
+			name:        "beyond the maximum lifetime",
+			annotation:  "2h",
+			maxLifetime: 2 * time.Hour,
+			wantReason:  renewalReasonLifetimeExceeded,
+			wantExpires: reservedAt.Add(time.Hour),
+		},
+		{
+			name:        "capacity booked after the current expiry",
+			annotation:  "2h",
+			booked:      time.Hour,
+			wantReason:  renewalReasonInsufficientCapacity,
+			wantExpires: reservedAt.Add(time.Hour),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cluster := fakeCluster("cluster-a", "8", "16Gi")
+			reservation := fakeReservation("renewed", "6", "12Gi")
+			reservation.Spec.Duration = &metav1.Duration{Duration: time.Hour}
+			fakeReserved(t, reservation, cluster, reservedAt)
+			if tt.duration != 0 {
+				reservation.Spec.Duration = &metav1.Duration{Duration: tt.duration}
+			}
+			if tt.annotation != "" {
+				reservation.Annotations = map[string]string{brokerv1alpha1.ReservationRenewAnnotation: tt.annotation}
+			}
+
+			objects := []client.Object{cluster, reservation}
+			if tt.booked != 0 {
+				booked := fakeReservation("booked", "6", "12Gi")
+				booked.Finalizers = []string{brokerv1alpha1.ReservationFinalizer}
+				booked.Spec.TargetClusterID = "cluster-a"
+				booked.Spec.StartTime = &metav1.Time{Time: now.Add(tt.booked)}
+				booked.Spec.Duration = &metav1.Duration{Duration: time.Hour}
+				booked.Status.Phase = brokerv1alpha1.ReservationPhaseScheduled
+				objects = append(objects, booked)
+			}
+
+			r := newFakeReconciler(t, objects...)
+			r.MaxReservationLifetime = tt.maxLifetime
+			_, got := reconcileReservation(t, r, "renewed")
+
+			if _, annotated := got.Annotations[brokerv1alpha1.ReservationRenewAnnotation]; annotated {
+				t.Error("renew annotation was not dropped")
+			}
+			condition := meta.FindStatusCondition(got.Status.Conditions, brokerv1alpha1.ReservationConditionRenewed)
+			if condition == nil || condition.Reason != tt.wantReason {
+				t.Fatalf("Renewed condition = %+v, want reason %s", condition, tt.wantReason)
+			}
+			if got.Status.ExpiresAt == nil || !got.Status.ExpiresAt.Time.Equal(tt.wantExpires.Truncate(time.Second)) {
+				t.Errorf("expiresAt = %v, want %v", got.Status.ExpiresAt, tt.wantExpires.Truncate(time.Second))
+			}
+			if got.Status.Phase != brokerv1alpha1.ReservationPhaseReserved {
+				t.Errorf("phase = %s, want Reserved", got.Status.Phase)
+			}
+		})
+	}
+}
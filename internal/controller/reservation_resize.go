@@ -0,0 +1,207 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/broker"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/resource"
+)
+
+// Reasons of the ResizeFailed condition
+const (
+	resizeReasonResized              = "Resized"
+	resizeReasonInsufficientCapacity = "InsufficientCapacity"
+	resizeReasonQuotaExceeded        = "QuotaExceeded"
+	resizeReasonSplit                = "SplitReservation"
+)
+
+// handleResize applies a change of spec.requestedResources to a reservation that
+// already holds resources. The difference to the locked quantities is applied to
+// the target ClusterAdvertisement in a single update; growing is only allowed if
+// the cluster has the capacity for the rest of the reservation's lifetime.
+func (r *ReservationReconciler) handleResize(
+	ctx context.Context,
+	reservation *brokerv1alpha1.Reservation,
+	logger logr.Logger,
+) error {
+
+	locked := resource.LockedResources(reservation)
+	lockedList := resource.RequestedList(locked)
+	requestedList := resource.RequestedList(reservation.Spec.RequestedResources)
+	if resource.Equal(lockedList, requestedList) {
+		return nil
+	}
+
+	if len(reservation.Status.Fragments) > 0 {
+		return r.failResize(ctx, reservation, resizeReasonSplit,
+			fmt.Sprintf("Split reservations cannot be resized. The locked fragments are kept: %s.",
+				formatFragments(reservation.Status.Fragments)))
+	}
+
+	grows := !resource.Fits(lockedList, requestedList)
+
+	// Growing counts towards the requester's quotas like a new reservation would
+	if grows {
+		quotas, err := r.DecisionEngine.RequesterQuotas(ctx, reservation.Spec.RequesterID)
+		if err != nil {
+			return err
+		}
+		claims, err := r.DecisionEngine.ListClaims(ctx)
+		if err != nil {
+			return err
+		}
+		if quota, exceeded := resizeQuotaExceeded(reservation, quotas, claims); quota != nil {
+			logger.Info("Resize would exceed requester quota",
+				"requesterID", reservation.Spec.RequesterID,
+				"quota", quota.Namespace+"/"+quota.Name,
+				"exceeded", exceeded)
+			return r.failResize(ctx, reservation, resizeReasonQuotaExceeded,
+				fmt.Sprintf("Growing the reservation from %s to %s would exceed RequesterQuota %s/%s (%s). "+
+					"The previously locked resources are kept.",
+					resource.FormatRequested(locked),
+					resource.FormatRequested(reservation.Spec.RequestedResources),
+					quota.Namespace, quota.Name, exceeded))
+		}
+	}
+
+	var checkCapacity func(*brokerv1alpha1.ClusterAdvertisement) error
+	if grows {
+		checkCapacity = func(clusterAdv *brokerv1alpha1.ClusterAdvertisement) error {
+			// What is available once the currently locked quantities are given back
+			available := resource.ToList(clusterAdv.Spec.Resources.Available)
+			resource.AddTo(available, lockedList)
+			if !resource.Fits(available, requestedList) {
+				return errInsufficientResources
+			}
+
+			claims, err := r.DecisionEngine.ListClaims(ctx)
+			if err != nil {
+				return err
+			}
+			if !broker.CanResize(clusterAdv, reservation, claims, time.Now()) {
+				return errInsufficientResources
+			}
+			return nil
+		}
+	}
+
+	lockErr := r.swapLocked(ctx, reservation.Spec.TargetClusterID, locked, reservation.Spec.RequestedResources,
+		checkCapacity)
+
+	if errors.Is(lockErr, errInsufficientResources) {
+		return r.failResize(ctx, reservation, resizeReasonInsufficientCapacity,
+			fmt.Sprintf("Cluster '%s' cannot grow the reservation from %s to %s. "+
+				"The previously locked resources are kept.",
+				reservation.Spec.TargetClusterID,
+				resource.FormatRequested(locked),
+				resource.FormatRequested(reservation.Spec.RequestedResources)))
+	}
+	if lockErr != nil {
+		logger.Error(lockErr, "failed to resize reservation",
+			"targetClusterID", reservation.Spec.TargetClusterID,
+			"locked", resource.FormatRequested(locked),
+			"requested", resource.FormatRequested(reservation.Spec.RequestedResources))
+		return lockErr
+	}
+
+	resized := *reservation.Spec.RequestedResources.DeepCopy()
+	reservation.Status.LockedResources = resized.DeepCopy()
+	reservation.Status.Message = fmt.Sprintf("Resized to %s in cluster %s",
+		resource.FormatRequested(resized), reservation.Spec.TargetClusterID)
+	reservation.Status.LastUpdateTime = metav1.Now()
+	meta.SetStatusCondition(&reservation.Status.Conditions, metav1.Condition{
+		Type:    brokerv1alpha1.ReservationConditionResizeFailed,
+		Status:  metav1.ConditionFalse,
+		Reason:  resizeReasonResized,
+		Message: reservation.Status.Message,
+	})
+	if err := r.Status().Update(ctx, reservation); err != nil {
+		// Undo the cluster update so the next attempt starts from the recorded quantities
+		if rollbackErr := r.swapLocked(ctx, reservation.Spec.TargetClusterID, resized, locked, nil); rollbackErr != nil {
+			logger.Error(rollbackErr, "failed to roll back resize, the Reserved ledger check will report the drift",
+				"targetClusterID", reservation.Spec.TargetClusterID)
+		}
+		return err
+	}
+
+	logger.Info("Reservation resized",
+		"targetClusterID", reservation.Spec.TargetClusterID,
+		"from", resource.FormatRequested(locked),
+		"to", resource.FormatRequested(resized))
+	return nil
+}
+
+// failResize records why a resize was refused. The refusal is retried on every
+// reconcile, so the status is only written when the outcome changes.
+func (r *ReservationReconciler) failResize(
+	ctx context.Context,
+	reservation *brokerv1alpha1.Reservation,
+	reason, message string,
+) error {
+	changed := meta.SetStatusCondition(&reservation.Status.Conditions, metav1.Condition{
+		Type:    brokerv1alpha1.ReservationConditionResizeFailed,
+		Status:  metav1.ConditionTrue,
+		Reason:  reason,
+		Message: message,
+	})
+	if !changed {
+		return nil
+	}
+	reservation.Status.LastUpdateTime = metav1.Now()
+	return r.Status().Update(ctx, reservation)
+}
+
+// swapLocked replaces the quantities from with to in the Reserved counter of a
+// cluster in a single update. check, if set, may veto the swap.
+func (r *ReservationReconciler) swapLocked(
+	ctx context.Context,
+	clusterID string,
+	from, to brokerv1alpha1.RequestedResourceQuantities,
+	check func(*brokerv1alpha1.ClusterAdvertisement) error,
+) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		clusterAdv, err := r.findClusterByID(ctx, clusterID)
+		if err != nil {
+			return err
+		}
+
+		if check != nil {
+			if err := check(clusterAdv); err != nil {
+				return err
+			}
+		}
+
+		if err := resource.RemoveReservation(clusterAdv, from); err != nil {
+			return err
+		}
+		if err := resource.AddReservation(clusterAdv, to); err != nil {
+			return err
+		}
+		return r.Update(ctx, clusterAdv)
+	})
+}
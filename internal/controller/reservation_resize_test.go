@@ -0,0 +1,176 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	apiresource "k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/resource"
+)
+
+func TestHandleResize(t *testing.T) {
+	tests := []struct {
+		name         string
+		cpu          string
+		wantFailed   metav1.ConditionStatus
+		wantReason   string
+		wantLocked   string
+		wantReserved string
+	}{
+		{
+			name:         "grow",
+			cpu:          "6",
+			wantFailed:   metav1.ConditionFalse,
+			wantReason:   resizeReasonResized,
+			wantLocked:   "6",
+			wantReserved: "6",
+		},
+		{
+			name:         "shrink",
+			cpu:          "2",
+			wantFailed:   metav1.ConditionFalse,
+			wantReason:   resizeReasonResized,
+			wantLocked:   "2",
+			wantReserved: "2",
+		},
+		{
+			name:         "grow beyond the cluster",
+			cpu:          "10",
+			wantFailed:   metav1.ConditionTrue,
+			wantReason:   resizeReasonInsufficientCapacity,
+			wantLocked:   "4",
+			wantReserved: "4",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cluster := fakeCluster("cluster-a", "8", "16Gi")
+			reservation := fakeReservation("resized", "4", "4Gi")
+			fakeReserved(t, reservation, cluster, time.Now())
+			reservation.Spec.RequestedResources.CPU = apiresource.MustParse(tt.cpu)
+
+			r := newFakeReconciler(t, cluster, reservation)
+			_, got := reconcileReservation(t, r, "resized")
+
+			condition := meta.FindStatusCondition(got.Status.Conditions, brokerv1alpha1.ReservationConditionResizeFailed)
+			if condition == nil || condition.Status != tt.wantFailed || condition.Reason != tt.wantReason {
+				t.Fatalf("ResizeFailed condition = %+v, want %s with reason %s", condition, tt.wantFailed, tt.wantReason)
+			}
+			if locked := resource.LockedResources(got).CPU; locked.Cmp(apiresource.MustParse(tt.wantLocked)) != 0 {
+				t.Errorf("locked cpu = %s, want %s", locked.String(), tt.wantLocked)
+			}
+			reserved := getCluster(t, r, "cluster-a").Spec.Resources.Reserved
+			if reserved == nil || reserved.CPU.Cmp(apiresource.MustParse(tt.wantReserved)) != 0 {
+				t.Errorf("cluster reserved = %v, want cpu %s", reserved, tt.wantReserved)
+			}
+		})
+	}
+}
+
+func TestHandleResizeQuota(t *testing.T) {
+	cpuLimit := apiresource.MustParse("6")
+	tests := []struct {
+		name       string
+		cpu        string
+		memory     string
+		wantReason string
+		wantLocked string
+	}{
+		// Only the growth counts on top of the 4 cores already locked
+		{name: "growth within the quota", cpu: "6", memory: "8Gi", wantReason: resizeReasonResized, wantLocked: "6"},
+		{name: "growth beyond the quota", cpu: "8", memory: "4Gi", wantReason: resizeReasonQuotaExceeded, wantLocked: "4"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cluster := fakeCluster("cluster-a", "16", "32Gi")
+			reservation := fakeReservation("resized", "4", "4Gi")
+			fakeReserved(t, reservation, cluster, time.Now())
+			reservation.Spec.RequestedResources.CPU = apiresource.MustParse(tt.cpu)
+			reservation.Spec.RequestedResources.Memory = apiresource.MustParse(tt.memory)
+			quota := &brokerv1alpha1.RequesterQuota{
+				ObjectMeta: metav1.ObjectMeta{Name: "requester-cluster", Namespace: "default"},
+				Spec: brokerv1alpha1.RequesterQuotaSpec{
+					RequesterID: "requester-cluster",
+					Hard:        brokerv1alpha1.QuotaResources{CPU: &cpuLimit},
+				},
+			}
+
+			r := newFakeReconciler(t, cluster, reservation, quota)
+			_, got := reconcileReservation(t, r, "resized")
+
+			condition := meta.FindStatusCondition(got.Status.Conditions, brokerv1alpha1.ReservationConditionResizeFailed)
+			if condition == nil || condition.Reason != tt.wantReason {
+				t.Fatalf("ResizeFailed condition = %+v, want reason %s", condition, tt.wantReason)
+			}
+			if locked := resource.LockedResources(got).CPU; locked.Cmp(apiresource.MustParse(tt.wantLocked)) != 0 {
+				t.Errorf("locked cpu = %s, want %s", locked.String(), tt.wantLocked)
+			}
+			if got.Status.Phase != brokerv1alpha1.ReservationPhaseReserved {
+				t.Errorf("phase = %s, want Reserved", got.Status.Phase)
+			}
+		})
+	}
+}
+
+func TestReleaseLockedResources(t *testing.T) {
+	cluster := fakeCluster("cluster-a", "8", "16Gi")
+	reservation := fakeReservation("resized", "4", "4Gi")
+	fakeReserved(t, reservation, cluster, time.Now())
+	r := newFakeReconciler(t, cluster, reservation)
+
+	// A resize the cluster cannot take leaves the spec ahead of what is locked
+	stored := getReservation(t, r, "resized")
+	stored.Spec.RequestedResources.CPU = apiresource.MustParse("10")
+	if err := r.Update(context.Background(), stored); err != nil {
+		t.Fatal(err)
+	}
+	if _, got := reconcileReservation(t, r, "resized"); !reservationHasCondition(got, brokerv1alpha1.ReservationConditionResizeFailed) {
+		t.Fatal("resize beyond the cluster did not fail")
+	}
+
+	// Deleting gives back the locked quantities, not the requested ones
+	if err := r.Delete(context.Background(), getReservation(t, r, "resized")); err != nil {
+		t.Fatal(err)
+	}
+	key := types.NamespacedName{Name: "resized", Namespace: "default"}
+	if _, err := r.Reconcile(context.Background(), reconcile.Request{NamespacedName: key}); err != nil {
+		t.Fatalf("Reconcile() of the deleted reservation error = %v", err)
+	}
+	if err := r.Get(context.Background(), key, &brokerv1alpha1.Reservation{}); !apierrors.IsNotFound(err) {
+		t.Errorf("reservation still exists after release: %v", err)
+	}
+
+	released := getCluster(t, r, "cluster-a").Spec.Resources
+	if released.Reserved == nil || !released.Reserved.CPU.IsZero() {
+		t.Errorf("cluster reserved = %v, want cpu 0", released.Reserved)
+	}
+	if released.Available.CPU.Cmp(apiresource.MustParse("8")) != 0 {
+		t.Errorf("cluster available cpu = %s, want 8", released.Available.CPU.String())
+	}
+}
@@ -0,0 +1,186 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/broker"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/resource"
+)
+
+// handleScheduledReservation waits for a booked window to open and then locks the resources
+func (r *ReservationReconciler) handleScheduledReservation(
+	ctx context.Context,
+	reservation *brokerv1alpha1.Reservation,
+	logger logr.Logger,
+) (ctrl.Result, error) {
+
+	now := time.Now()
+	start, end := broker.RequestedWindow(&reservation.Spec, now)
+
+	if !end.IsZero() && !now.Before(end) {
+		if err := r.dropBooking(ctx, reservation); err != nil {
+			return ctrl.Result{}, err
+		}
+		return r.failReservation(ctx, reservation, fmt.Sprintf("The booked window ended at %s "+
+			"before resources could be locked.", end.Format(time.RFC3339)))
+	}
+
+	if broker.StartsLater(&reservation.Spec, now) {
+		return ctrl.Result{RequeueAfter: time.Until(start)}, nil
+	}
+
+	logger.Info("Booked window opened, locking resources",
+		"targetClusterID", reservation.Spec.TargetClusterID,
+		"startTime", reservation.Spec.StartTime)
+	return r.reserveInTargetCluster(ctx, reservation, false, logger)
+}
+
+// bookInTargetCluster books capacity on the target cluster for a future window.
+// Nothing is locked yet; the booking only counts against the cluster's timeline.
+// It is recorded on the ClusterAdvertisement, so that two reservations booking
+// the same cluster at once conflict and the second is checked against the first.
+func (r *ReservationReconciler) bookInTargetCluster(
+	ctx context.Context,
+	reservation *brokerv1alpha1.Reservation,
+	brokerSelected bool,
+	logger logr.Logger,
+) (ctrl.Result, error) {
+
+	bookErr := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		clusterAdv, err := r.findClusterByID(ctx, reservation.Spec.TargetClusterID)
+		if err != nil {
+			return err
+		}
+
+		fits, err := r.canHost(ctx, clusterAdv, reservation)
+		if err != nil {
+			return err
+		}
+		if !fits {
+			return errInsufficientResources
+		}
+
+		broker.RecordBooking(clusterAdv, reservation, time.Now())
+		return r.Update(ctx, clusterAdv)
+	})
+
+	start, end := broker.RequestedWindow(&reservation.Spec, time.Now())
+	window := fmt.Sprintf("from %s", start.Format(time.RFC3339))
+	if !end.IsZero() {
+		window += fmt.Sprintf(" until %s", end.Format(time.RFC3339))
+	}
+
+	switch {
+	case errors.Is(bookErr, errTargetClusterNotFound):
+		return r.failReservation(ctx, reservation, fmt.Sprintf("Target cluster '%s' not found. "+
+			"The cluster may have been removed or is not registered with the broker.",
+			reservation.Spec.TargetClusterID))
+	case errors.Is(bookErr, errInsufficientResources):
+		message := fmt.Sprintf("Cluster '%s' has no capacity for %s %s. Waiting in queue until capacity is released.",
+			reservation.Spec.TargetClusterID,
+			resource.FormatRequested(reservation.Spec.RequestedResources), window)
+		if brokerSelected {
+			reservation.Spec.TargetClusterID = ""
+			if err := r.Update(ctx, reservation); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+		return r.queueReservation(ctx, reservation, message, logger)
+	case bookErr != nil:
+		logger.Error(bookErr, "failed to book capacity in cluster",
+			"targetClusterID", reservation.Spec.TargetClusterID,
+			"requested", resource.FormatRequested(reservation.Spec.RequestedResources))
+		return ctrl.Result{}, bookErr
+	}
+
+	reservation.Status.Phase = brokerv1alpha1.ReservationPhaseScheduled
+	reservation.Status.Message = fmt.Sprintf("Capacity booked in cluster %s %s", reservation.Spec.TargetClusterID, window)
+	reservation.Status.LastUpdateTime = metav1.Now()
+	if err := r.Status().Update(ctx, reservation); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	logger.Info("Capacity booked for future window",
+		"targetClusterID", reservation.Spec.TargetClusterID,
+		"requested", resource.FormatRequested(reservation.Spec.RequestedResources),
+		"window", window)
+
+	return ctrl.Result{RequeueAfter: time.Until(start)}, nil
+}
+
+// dropBooking removes the booking a reservation recorded on its target cluster
+func (r *ReservationReconciler) dropBooking(
+	ctx context.Context,
+	reservation *brokerv1alpha1.Reservation,
+) error {
+	if reservation.Spec.TargetClusterID == "" {
+		return nil
+	}
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		clusterAdv, err := r.findClusterByID(ctx, reservation.Spec.TargetClusterID)
+		if errors.Is(err, errTargetClusterNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !broker.DropBooking(clusterAdv, reservation.UID) {
+			return nil
+		}
+		return r.Update(ctx, clusterAdv)
+	})
+}
+
+// canHost checks a cluster against the reservation's requested window,
+// taking every other reservation's booking into account
+func (r *ReservationReconciler) canHost(
+	ctx context.Context,
+	clusterAdv *brokerv1alpha1.ClusterAdvertisement,
+	reservation *brokerv1alpha1.Reservation,
+) (bool, error) {
+	claims, err := r.DecisionEngine.ListClaims(ctx)
+	if err != nil {
+		return false, err
+	}
+	return r.DecisionEngine.CanHost(clusterAdv, &reservation.Spec, claims, reservation.UID, time.Now()), nil
+}
+
+// failReservation moves a reservation to the terminal Failed phase
+func (r *ReservationReconciler) failReservation(
+	ctx context.Context,
+	reservation *brokerv1alpha1.Reservation,
+	message string,
+) (ctrl.Result, error) {
+	reservation.Status.Phase = brokerv1alpha1.ReservationPhaseFailed
+	reservation.Status.Message = message
+	reservation.Status.LastUpdateTime = metav1.Now()
+	if err := r.Status().Update(ctx, reservation); err != nil {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
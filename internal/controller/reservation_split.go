@@ -0,0 +1,148 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/resource"
+)
+
+// splitReservation satisfies a reservation no single cluster can host with
+// fragments on several clusters. The fragments are locked one by one; if any
+// lock fails, the ones taken so far are rolled back and split is false so the
+// caller can fall back to preemption or the queue.
+func (r *ReservationReconciler) splitReservation(
+	ctx context.Context,
+	reservation *brokerv1alpha1.Reservation,
+	logger logr.Logger,
+) (split bool, result ctrl.Result, err error) {
+
+	fragments, err := r.DecisionEngine.PlanSplit(ctx, &reservation.Spec)
+	if err != nil {
+		logger.Info("Reservation cannot be split either", "reason", err.Error())
+		return false, ctrl.Result{}, nil
+	}
+
+	locked := make([]brokerv1alpha1.ReservationFragment, 0, len(fragments))
+	for _, fragment := range fragments {
+		lockErr := r.lockFragment(ctx, reservation, fragment)
+		if lockErr == nil {
+			locked = append(locked, fragment)
+			continue
+		}
+
+		if rollbackErr := r.unlockFragments(ctx, locked); rollbackErr != nil {
+			logger.Error(rollbackErr, "failed to roll back fragment locks, the Reserved ledger check will report the drift")
+		}
+		if !errors.Is(lockErr, errInsufficientResources) && !errors.Is(lockErr, errTargetClusterNotFound) {
+			return false, ctrl.Result{}, lockErr
+		}
+		logger.Info("Fragment could not be locked, rolled back", "clusterID", fragment.ClusterID,
+			"reason", lockErr.Error())
+		return false, ctrl.Result{}, nil
+	}
+
+	now := metav1.Now()
+	reservation.Status.Phase = brokerv1alpha1.ReservationPhaseReserved
+	reservation.Status.Message = fmt.Sprintf("Resources split across %d clusters: %s",
+		len(locked), formatFragments(locked))
+	reservation.Status.Fragments = locked
+	reservation.Status.LockedResources = reservation.Spec.RequestedResources.DeepCopy()
+	reservation.Status.ReservedAt = &now
+	reservation.Status.ExpiresAt = r.reservationExpiry(reservation, now.Time)
+	if reservation.Spec.Duration != nil {
+		reservation.Status.ObservedDuration = reservation.Spec.Duration.DeepCopy()
+	}
+	reservation.Status.LastUpdateTime = now
+
+	if err := r.Status().Update(ctx, reservation); err != nil {
+		// Without the fragments in status nobody would release the locks
+		if rollbackErr := r.unlockFragments(ctx, locked); rollbackErr != nil {
+			logger.Error(rollbackErr, "failed to roll back fragment locks, the Reserved ledger check will report the drift")
+		}
+		return false, ctrl.Result{}, err
+	}
+
+	logger.Info("Reservation split across clusters",
+		"fragments", formatFragments(locked),
+		"requested", resource.FormatRequested(reservation.Spec.RequestedResources))
+	return true, ctrl.Result{RequeueAfter: 1 * time.Minute}, nil
+}
+
+// lockFragment locks one fragment of a split reservation on its cluster, after
+// checking that the cluster can still host it over the reservation's window
+func (r *ReservationReconciler) lockFragment(
+	ctx context.Context,
+	reservation *brokerv1alpha1.Reservation,
+	fragment brokerv1alpha1.ReservationFragment,
+) error {
+	spec := reservation.Spec
+	spec.RequestedResources = fragment.LockedResources
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		clusterAdv, err := r.findClusterByID(ctx, fragment.ClusterID)
+		if err != nil {
+			return err
+		}
+		claims, err := r.DecisionEngine.ListClaims(ctx)
+		if err != nil {
+			return err
+		}
+		if !r.DecisionEngine.CanHost(clusterAdv, &spec, claims, reservation.UID, time.Now()) {
+			return errInsufficientResources
+		}
+		if err := resource.AddReservation(clusterAdv, fragment.LockedResources); err != nil {
+			return err
+		}
+		return r.Update(ctx, clusterAdv)
+	})
+}
+
+// unlockFragments gives the locked resources of the given fragments back to their clusters
+func (r *ReservationReconciler) unlockFragments(
+	ctx context.Context,
+	fragments []brokerv1alpha1.ReservationFragment,
+) error {
+	var errs []error
+	for _, fragment := range fragments {
+		if err := r.unlockFrom(ctx, fragment.ClusterID, fragment.LockedResources); err != nil {
+			errs = append(errs, fmt.Errorf("fragment on cluster %s: %w", fragment.ClusterID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// formatFragments renders fragments for logs and status messages,
+// e.g. "cluster-a (cpu=4, memory=8Gi), cluster-b (cpu=2, memory=4Gi)"
+func formatFragments(fragments []brokerv1alpha1.ReservationFragment) string {
+	parts := make([]string, 0, len(fragments))
+	for _, fragment := range fragments {
+		parts = append(parts, fmt.Sprintf("%s (%s)", fragment.ClusterID, resource.FormatRequested(fragment.LockedResources)))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,377 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/broker"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/resource"
+)
+
+// ReservationGroupReconciler reconciles a ReservationGroup object
+type ReservationGroupReconciler struct {
+	client.Client
+	Scheme         *runtime.Scheme
+	DecisionEngine *broker.DecisionEngine
+
+	// FairShareHalfLife is how quickly the usage of deleted groups stops counting
+	// under the fair-share admission policy. Zero means usage never decays.
+	FairShareHalfLife time.Duration
+}
+
+// +kubebuilder:rbac:groups=broker.fluidos.eu,resources=reservationgroups,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=broker.fluidos.eu,resources=reservationgroups/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=broker.fluidos.eu,resources=reservationgroups/finalizers,verbs=update
+// +kubebuilder:rbac:groups=broker.fluidos.eu,resources=clusteradvertisements,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=broker.fluidos.eu,resources=reservations,verbs=get;list;watch
+// +kubebuilder:rbac:groups=broker.fluidos.eu,resources=requesterquotas,verbs=get;list;watch;create
+// +kubebuilder:rbac:groups=broker.fluidos.eu,resources=requesterquotas/status,verbs=get;update;patch
+
+// Reconcile is part of the main kubernetes reconciliation loop
+func (r *ReservationGroupReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+	logger.Info("Reconciling ReservationGroup", "name", req.Name, "namespace", req.Namespace)
+
+	// Fetch the ReservationGroup instance
+	group := &brokerv1alpha1.ReservationGroup{}
+	if err := r.Get(ctx, req.NamespacedName, group); err != nil {
+		if client.IgnoreNotFound(err) == nil {
+			logger.Info("ReservationGroup not found, may have been deleted")
+			return ctrl.Result{}, nil
+		}
+		logger.Error(err, "Failed to get ReservationGroup")
+		return ctrl.Result{}, err
+	}
+
+	// Handle deletion with finalizer
+	if group.DeletionTimestamp != nil {
+		if controllerutil.ContainsFinalizer(group, brokerv1alpha1.ReservationGroupFinalizer) {
+			if resource.HoldsResources(group.Status.Phase) {
+				if err := r.unlockPlacements(ctx, group.Status.Placements); err != nil {
+					logger.Error(err, "Failed to release group resources")
+					return ctrl.Result{}, err
+				}
+			}
+
+			// Keep the usage in the requester's fair-share history
+			if err := r.DecisionEngine.SettleGroup(ctx, group, r.FairShareHalfLife, time.Now()); err != nil {
+				logger.Error(err, "Failed to record fair-share usage")
+				return ctrl.Result{}, err
+			}
+
+			controllerutil.RemoveFinalizer(group, brokerv1alpha1.ReservationGroupFinalizer)
+			if err := r.Update(ctx, group); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+		return ctrl.Result{}, nil
+	}
+
+	// Add finalizer if not present
+	if !controllerutil.ContainsFinalizer(group, brokerv1alpha1.ReservationGroupFinalizer) {
+		controllerutil.AddFinalizer(group, brokerv1alpha1.ReservationGroupFinalizer)
+		if err := r.Update(ctx, group); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	if err := validateReservationGroupSpec(group); err != nil {
+		logger.Error(err, "invalid reservation group spec", "group", group.Name)
+		return r.setGroupPhase(ctx, group, brokerv1alpha1.ReservationPhaseFailed,
+			fmt.Sprintf("Invalid reservation group specification: %v.", err))
+	}
+
+	switch group.Status.Phase {
+	case "", brokerv1alpha1.ReservationPhasePending:
+		return r.handlePendingGroup(ctx, group, logger)
+
+	case brokerv1alpha1.ReservationPhaseReserved:
+		return r.handleReservedGroup(ctx, group, logger)
+	}
+
+	// Failed and Released are terminal
+	return ctrl.Result{}, nil
+}
+
+// handlePendingGroup places and locks every member of a new group. If any lock
+// fails, the locks taken so far are rolled back and the group fails.
+func (r *ReservationGroupReconciler) handlePendingGroup(
+	ctx context.Context,
+	group *brokerv1alpha1.ReservationGroup,
+	logger logr.Logger,
+) (ctrl.Result, error) {
+
+	clusters, err := r.DecisionEngine.SelectClustersForGroup(ctx, group)
+	if err != nil {
+		logger.Info("Cannot place reservation group", "reason", err.Error(),
+			"members", len(group.Spec.Members))
+		return r.setGroupPhase(ctx, group, brokerv1alpha1.ReservationPhaseFailed,
+			fmt.Sprintf("No placement satisfies every member of the group: %v. Nothing was locked.", err))
+	}
+
+	quota, exceeded, err := r.exceededQuota(ctx, group, clusters)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if quota != nil {
+		logger.Info("Reservation group would exceed requester quota",
+			"requesterID", group.Spec.RequesterID,
+			"quota", quota.Namespace+"/"+quota.Name,
+			"exceeded", exceeded)
+		return r.setGroupPhase(ctx, group, brokerv1alpha1.ReservationPhaseFailed,
+			fmt.Sprintf("Requester %s would exceed RequesterQuota %s/%s (%s). Nothing was locked.",
+				group.Spec.RequesterID, quota.Namespace, quota.Name, exceeded))
+	}
+
+	placements := make([]brokerv1alpha1.MemberPlacement, 0, len(group.Spec.Members))
+	for i, member := range group.Spec.Members {
+		clusterID := clusters[i].Spec.ClusterID
+		lockErr := r.lockMember(ctx, group, &group.Spec.Members[i], clusterID, placements)
+		if lockErr == nil {
+			placements = append(placements, brokerv1alpha1.MemberPlacement{
+				Name:            member.Name,
+				ClusterID:       clusterID,
+				LockedResources: *member.RequestedResources.DeepCopy(),
+			})
+			continue
+		}
+
+		if rollbackErr := r.unlockPlacements(ctx, placements); rollbackErr != nil {
+			logger.Error(rollbackErr, "failed to roll back group locks, the Reserved ledger check will report the drift")
+		}
+		if !errors.Is(lockErr, errInsufficientResources) && !errors.Is(lockErr, errTargetClusterNotFound) {
+			return ctrl.Result{}, lockErr
+		}
+		logger.Info("Group member could not be locked, rolled back", "member", member.Name,
+			"clusterID", clusterID, "reason", lockErr.Error())
+		return r.setGroupPhase(ctx, group, brokerv1alpha1.ReservationPhaseFailed,
+			fmt.Sprintf("Could not lock member %q on cluster '%s' (%v). All partial locks were rolled back.",
+				member.Name, clusterID, lockErr))
+	}
+
+	now := metav1.Now()
+	group.Status.Phase = brokerv1alpha1.ReservationPhaseReserved
+	group.Status.Message = fmt.Sprintf("All %d members locked", len(placements))
+	group.Status.Placements = placements
+	group.Status.ReservedAt = &now
+	if group.Spec.Duration != nil {
+		expiresAt := metav1.NewTime(now.Add(group.Spec.Duration.Duration))
+		group.Status.ExpiresAt = &expiresAt
+	}
+	group.Status.LastUpdateTime = now
+
+	if err := r.Status().Update(ctx, group); err != nil {
+		// Without the placements in status nobody would release the locks
+		if rollbackErr := r.unlockPlacements(ctx, placements); rollbackErr != nil {
+			logger.Error(rollbackErr, "failed to roll back group locks, the Reserved ledger check will report the drift")
+		}
+		return ctrl.Result{}, err
+	}
+
+	logger.Info("Reservation group locked", "members", len(placements))
+	return ctrl.Result{RequeueAfter: 1 * time.Minute}, nil
+}
+
+// handleReservedGroup releases the group when it expires
+func (r *ReservationGroupReconciler) handleReservedGroup(
+	ctx context.Context,
+	group *brokerv1alpha1.ReservationGroup,
+	logger logr.Logger,
+) (ctrl.Result, error) {
+
+	if group.Status.ExpiresAt != nil && time.Now().After(group.Status.ExpiresAt.Time) {
+		logger.Info("Reservation group expired, releasing resources")
+		if err := r.unlockPlacements(ctx, group.Status.Placements); err != nil {
+			logger.Error(err, "Failed to release group resources on expiration")
+			return ctrl.Result{}, err
+		}
+		return r.setGroupPhase(ctx, group, brokerv1alpha1.ReservationPhaseReleased,
+			"Reservation group expired and resources released")
+	}
+
+	return ctrl.Result{RequeueAfter: 1 * time.Minute}, nil
+}
+
+// exceededQuota returns the first RequesterQuota the group would exceed if its
+// members were placed on the given clusters, and which limits it exceeds
+func (r *ReservationGroupReconciler) exceededQuota(
+	ctx context.Context,
+	group *brokerv1alpha1.ReservationGroup,
+	clusters []*brokerv1alpha1.ClusterAdvertisement,
+) (*brokerv1alpha1.RequesterQuota, string, error) {
+	quotas, err := r.DecisionEngine.RequesterQuotas(ctx, group.Spec.RequesterID)
+	if err != nil || len(quotas) == 0 {
+		return nil, "", err
+	}
+	claims, err := r.DecisionEngine.ListClaims(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	usage := broker.RequesterUsage(group.Spec.RequesterID, claims)
+	clusterIDs := make([]string, 0, len(clusters))
+	for i, member := range group.Spec.Members {
+		clusterID := clusters[i].Spec.ClusterID
+		usage.Add([]brokerv1alpha1.ReservationFragment{{
+			ClusterID:       clusterID,
+			LockedResources: member.RequestedResources,
+		}}, 1)
+		clusterIDs = append(clusterIDs, clusterID)
+	}
+
+	for i := range quotas {
+		if exceeded := broker.ExceededQuota(&quotas[i], usage, clusterIDs); exceeded != "" {
+			return &quotas[i], exceeded, nil
+		}
+	}
+	return nil, "", nil
+}
+
+// lockMember locks a member's resources on a cluster. The cluster is checked
+// with CanHost over the group's lifetime, against every other claim and the
+// members locked before.
+func (r *ReservationGroupReconciler) lockMember(
+	ctx context.Context,
+	group *brokerv1alpha1.ReservationGroup,
+	member *brokerv1alpha1.ReservationGroupMember,
+	clusterID string,
+	locked []brokerv1alpha1.MemberPlacement,
+) error {
+	spec := broker.MemberSpec(group, member)
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		clusterAdv, err := getClusterByID(ctx, r.Client, clusterID)
+		if err != nil {
+			return err
+		}
+		claims, err := r.DecisionEngine.ListClaims(ctx)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		if !r.DecisionEngine.CanHost(clusterAdv, spec, broker.WithPlacements(claims, group, locked, now), "", now) {
+			return errInsufficientResources
+		}
+		if err := resource.AddReservation(clusterAdv, member.RequestedResources); err != nil {
+			return err
+		}
+		return r.Update(ctx, clusterAdv)
+	})
+}
+
+// unlockPlacements gives the locked resources of the given placements back to
+// their clusters. Clusters that are no longer registered are skipped.
+func (r *ReservationGroupReconciler) unlockPlacements(
+	ctx context.Context,
+	placements []brokerv1alpha1.MemberPlacement,
+) error {
+	var errs []error
+	for _, placement := range placements {
+		err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			clusterAdv, err := getClusterByID(ctx, r.Client, placement.ClusterID)
+			if errors.Is(err, errTargetClusterNotFound) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if err := resource.RemoveReservation(clusterAdv, placement.LockedResources); err != nil {
+				return err
+			}
+			return r.Update(ctx, clusterAdv)
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("member %q on cluster %s: %w", placement.Name, placement.ClusterID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (r *ReservationGroupReconciler) setGroupPhase(
+	ctx context.Context,
+	group *brokerv1alpha1.ReservationGroup,
+	phase brokerv1alpha1.ReservationPhase,
+	message string,
+) (ctrl.Result, error) {
+	group.Status.Phase = phase
+	group.Status.Message = message
+	group.Status.LastUpdateTime = metav1.Now()
+	if err := r.Status().Update(ctx, group); err != nil {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
+
+func validateReservationGroupSpec(group *brokerv1alpha1.ReservationGroup) error {
+	if len(group.Spec.Members) == 0 {
+		return errors.New("spec.members must not be empty")
+	}
+
+	names := map[string]bool{}
+	pinned := map[string]string{}
+	for i := range group.Spec.Members {
+		member := &group.Spec.Members[i]
+		if member.Name == "" {
+			return fmt.Errorf("member %d has no name", i)
+		}
+		if names[member.Name] {
+			return fmt.Errorf("member name %q is used twice", member.Name)
+		}
+		names[member.Name] = true
+
+		if group.Spec.DistinctClusters && member.TargetClusterID != "" {
+			if other, ok := pinned[member.TargetClusterID]; ok {
+				return fmt.Errorf("members %q and %q are pinned to the same cluster %s but distinctClusters is set",
+					other, member.Name, member.TargetClusterID)
+			}
+			pinned[member.TargetClusterID] = member.Name
+		}
+
+		if err := broker.ValidateReservationSpec(broker.MemberSpec(group, member)); err != nil {
+			return fmt.Errorf("member %q: %w", member.Name, err)
+		}
+	}
+	return nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ReservationGroupReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// Initialize decision engine if not set
+	if r.DecisionEngine == nil {
+		r.DecisionEngine = &broker.DecisionEngine{
+			Client: r.Client,
+		}
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&brokerv1alpha1.ReservationGroup{}).
+		Named("reservationgroup").
+		Complete(r)
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/errors"
+	apiresource "k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/broker"
+)
+
+var _ = Describe("ReservationGroup Controller", func() {
+	Context("When reconciling a resource", func() {
+		const resourceName = "test-resource"
+
+		ctx := context.Background()
+
+		typeNamespacedName := types.NamespacedName{
+			Name:      resourceName,
+			Namespace: "default", // TODO(user):Modify as needed
+		}
+		reservationgroup := &brokerv1alpha1.ReservationGroup{}
+
+		BeforeEach(func() {
+			By("creating the custom resource for the Kind ReservationGroup")
+			err := k8sClient.Get(ctx, typeNamespacedName, reservationgroup)
+			if err != nil && errors.IsNotFound(err) {
+				resource := &brokerv1alpha1.ReservationGroup{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      resourceName,
+						Namespace: "default",
+					},
+					Spec: brokerv1alpha1.ReservationGroupSpec{
+						RequesterID: "requester-cluster",
+						Members: []brokerv1alpha1.ReservationGroupMember{{
+							Name: "member-0",
+							RequestedResources: brokerv1alpha1.RequestedResourceQuantities{
+								CPU:    apiresource.MustParse("1"),
+								Memory: apiresource.MustParse("1Gi"),
+							},
+						}},
+					},
+				}
+				Expect(k8sClient.Create(ctx, resource)).To(Succeed())
+			}
+		})
+
+		AfterEach(func() {
+			// TODO(user): Cleanup logic after each test, like removing the resource instance.
+			resource := &brokerv1alpha1.ReservationGroup{}
+			err := k8sClient.Get(ctx, typeNamespacedName, resource)
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Cleanup the specific resource instance ReservationGroup")
+			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+		})
+		It("should successfully reconcile the resource", func() {
+			By("Reconciling the created resource")
+			controllerReconciler := &ReservationGroupReconciler{
+				Client:         k8sClient,
+				Scheme:         k8sClient.Scheme(),
+				DecisionEngine: &broker.DecisionEngine{Client: k8sClient},
+			}
+
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Failing the group since no cluster is advertised")
+			group := &brokerv1alpha1.ReservationGroup{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, group)).To(Succeed())
+			Expect(group.Status.Phase).To(Equal(brokerv1alpha1.ReservationPhaseFailed))
+			Expect(group.Status.Message).To(ContainSubstring("Nothing was locked"))
+			Expect(group.Status.Placements).To(BeEmpty())
+		})
+	})
+})
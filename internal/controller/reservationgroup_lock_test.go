@@ -0,0 +1,102 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	apiresource "k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+)
+
+func TestLockGroupMembers(t *testing.T) {
+	now := time.Now()
+
+	// Books 6 of the 8 cores of cluster-a an hour from now
+	booking := fakeReservation("booking", "6", "1Gi")
+	booking.Finalizers = []string{brokerv1alpha1.ReservationFinalizer}
+	booking.Spec.TargetClusterID = "cluster-a"
+	booking.Spec.StartTime = &metav1.Time{Time: now.Add(time.Hour)}
+	booking.Spec.Duration = &metav1.Duration{Duration: time.Hour}
+	booking.Status.Phase = brokerv1alpha1.ReservationPhaseScheduled
+
+	member := func(name string) brokerv1alpha1.ReservationGroupMember {
+		return brokerv1alpha1.ReservationGroupMember{
+			Name: name,
+			RequestedResources: brokerv1alpha1.RequestedResourceQuantities{
+				CPU: apiresource.MustParse("2"), Memory: apiresource.MustParse("1Gi"),
+			},
+		}
+	}
+	group := &brokerv1alpha1.ReservationGroup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "gang", Namespace: "default", UID: "gang",
+			Finalizers: []string{brokerv1alpha1.ReservationGroupFinalizer},
+		},
+		Spec: brokerv1alpha1.ReservationGroupSpec{
+			RequesterID: "requester-cluster",
+			Duration:    &metav1.Duration{Duration: 3 * time.Hour},
+			Members:     []brokerv1alpha1.ReservationGroupMember{member("first"), member("second")},
+		},
+	}
+
+	r := newFakeReconciler(t, fakeCluster("cluster-a", "8", "16Gi"), fakeCluster("cluster-b", "8", "16Gi"),
+		booking, group)
+	groups := &ReservationGroupReconciler{Client: r.Client, Scheme: r.Scheme, DecisionEngine: r.DecisionEngine}
+	ctx := context.Background()
+
+	// With the first member locked, the booking leaves no room for the second
+	first := []brokerv1alpha1.MemberPlacement{{
+		Name: "first", ClusterID: "cluster-a", LockedResources: group.Spec.Members[0].RequestedResources,
+	}}
+	if err := groups.lockMember(ctx, group, &group.Spec.Members[0], "cluster-a", nil); err != nil {
+		t.Fatalf("lockMember(first) error = %v", err)
+	}
+	if err := groups.lockMember(ctx, group, &group.Spec.Members[1], "cluster-a", first); !errors.Is(err, errInsufficientResources) {
+		t.Fatalf("lockMember(second) error = %v, want %v", err, errInsufficientResources)
+	}
+	if err := groups.unlockPlacements(ctx, first); err != nil {
+		t.Fatal(err)
+	}
+
+	// The placement search sees the same, and puts the second member elsewhere
+	key := types.NamespacedName{Name: "gang", Namespace: "default"}
+	if _, err := groups.Reconcile(ctx, reconcile.Request{NamespacedName: key}); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	locked := &brokerv1alpha1.ReservationGroup{}
+	if err := r.Get(ctx, key, locked); err != nil {
+		t.Fatal(err)
+	}
+	if locked.Status.Phase != brokerv1alpha1.ReservationPhaseReserved {
+		t.Fatalf("group phase = %s, want Reserved: %s", locked.Status.Phase, locked.Status.Message)
+	}
+	clusters := map[string]bool{}
+	for _, placement := range locked.Status.Placements {
+		clusters[placement.ClusterID] = true
+	}
+	if len(clusters) != 2 {
+		t.Errorf("placements = %+v, want one member on each cluster", locked.Status.Placements)
+	}
+}
@@ -0,0 +1,131 @@
+package identity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// serviceAccountPrefix starts the username of every ServiceAccount token
+const serviceAccountPrefix = "system:serviceaccount:"
+
+var (
+	// ErrUnauthenticated means the request carries no valid credentials
+	ErrUnauthenticated = errors.New("unauthenticated")
+
+	// ErrForbidden means the identity may not act for the cluster it claims
+	ErrForbidden = errors.New("forbidden")
+)
+
+// Identity is an authenticated agent. An agent acts for exactly one cluster:
+// ClusterID is the only ClusterID it may advertise and the only RequesterID it
+// may reserve as.
+type Identity struct {
+	// Name is how the agent authenticated, e.g. the certificate's common name or
+	// the ServiceAccount's username
+	Name string
+
+	// ClusterID is the cluster the agent acts for
+	ClusterID string
+}
+
+// AuthorizeClusterID checks that the agent advertises its own cluster
+func (i *Identity) AuthorizeClusterID(clusterID string) error {
+	if clusterID != i.ClusterID {
+		return fmt.Errorf("%w: %s may only advertise cluster %q, not %q", ErrForbidden, i.Name, i.ClusterID, clusterID)
+	}
+	return nil
+}
+
+// AuthorizeRequesterID checks that the agent reserves as its own cluster
+func (i *Identity) AuthorizeRequesterID(requesterID string) error {
+	if requesterID != i.ClusterID {
+		return fmt.Errorf("%w: %s may only reserve as requester %q, not %q", ErrForbidden, i.Name, i.ClusterID, requesterID)
+	}
+	return nil
+}
+
+// HTTPStatus is the status code answering a failed authentication or authorization
+func HTTPStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrUnauthenticated):
+		return http.StatusUnauthorized
+	case errors.Is(err, ErrForbidden):
+		return http.StatusForbidden
+	}
+	return http.StatusInternalServerError
+}
+
+// +kubebuilder:rbac:groups=authentication.k8s.io,resources=tokenreviews,verbs=create
+
+// Authenticator establishes the identity of agents calling the broker's own
+// endpoints, from a verified client certificate or a ServiceAccount token
+type Authenticator struct {
+	// Client creates TokenReviews to verify ServiceAccount tokens
+	Client client.Client
+
+	// ClientCertificates accepts client certificates verified by the TLS layer.
+	// The certificate's common name is the agent's cluster ID.
+	ClientCertificates bool
+
+	// ServiceAccountNamespace accepts tokens of the ServiceAccounts in this
+	// namespace. The ServiceAccount's name is the agent's cluster ID. Empty
+	// disables ServiceAccount tokens.
+	ServiceAccountNamespace string
+
+	// Audiences the ServiceAccount tokens must be issued for; empty accepts the
+	// API server's default audience
+	Audiences []string
+}
+
+// Enabled reports whether any authentication method is configured. Without one
+// every request is accepted anonymously.
+func (a *Authenticator) Enabled() bool {
+	return a != nil && (a.ClientCertificates || a.ServiceAccountNamespace != "")
+}
+
+// Authenticate returns the identity of the agent sending a request
+func (a *Authenticator) Authenticate(req *http.Request) (*Identity, error) {
+	if a.ClientCertificates && req.TLS != nil && len(req.TLS.VerifiedChains) > 0 {
+		commonName := req.TLS.VerifiedChains[0][0].Subject.CommonName
+		if commonName == "" {
+			return nil, fmt.Errorf("%w: client certificate has no common name", ErrUnauthenticated)
+		}
+		return &Identity{Name: "certificate " + commonName, ClusterID: commonName}, nil
+	}
+
+	token, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+	if a.ServiceAccountNamespace != "" && ok && token != "" {
+		return a.authenticateToken(req.Context(), token)
+	}
+	return nil, fmt.Errorf("%w: no client certificate or bearer token", ErrUnauthenticated)
+}
+
+// authenticateToken verifies a ServiceAccount token with a TokenReview
+func (a *Authenticator) authenticateToken(ctx context.Context, token string) (*Identity, error) {
+	review := &authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{Token: token, Audiences: a.Audiences},
+	}
+	if err := a.Client.Create(ctx, review); err != nil {
+		return nil, fmt.Errorf("failed to review token: %w", err)
+	}
+	if !review.Status.Authenticated {
+		if review.Status.Error != "" {
+			return nil, fmt.Errorf("%w: token rejected: %s", ErrUnauthenticated, review.Status.Error)
+		}
+		return nil, fmt.Errorf("%w: token rejected", ErrUnauthenticated)
+	}
+
+	username := review.Status.User.Username
+	namespace, name, ok := strings.Cut(strings.TrimPrefix(username, serviceAccountPrefix), ":")
+	if !strings.HasPrefix(username, serviceAccountPrefix) || !ok || namespace != a.ServiceAccountNamespace {
+		return nil, fmt.Errorf("%w: %s is not an agent ServiceAccount in namespace %s",
+			ErrForbidden, username, a.ServiceAccountNamespace)
+	}
+	return &Identity{Name: username, ClusterID: name}, nil
+}
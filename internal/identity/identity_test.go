@@ -0,0 +1,209 @@
+package identity
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+// reviewer answers TokenReviews from a table of tokens to usernames. Tokens
+// are only valid for the audience "broker" or for the default audience.
+func reviewer(t *testing.T, users map[string]string) client.Client {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	return fake.NewClientBuilder().WithScheme(scheme).WithInterceptorFuncs(interceptor.Funcs{
+		Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+			review, ok := obj.(*authenticationv1.TokenReview)
+			if !ok {
+				return c.Create(ctx, obj, opts...)
+			}
+			username, known := users[review.Spec.Token]
+			audiences := review.Spec.Audiences
+			switch {
+			case !known:
+				review.Status.Error = "invalid bearer token"
+			case len(audiences) > 0 && !slices.Contains(audiences, "broker"):
+				review.Status.Error = "token audiences are invalid"
+			default:
+				review.Status.Authenticated = true
+				review.Status.User.Username = username
+				review.Status.Audiences = audiences
+			}
+			return nil
+		},
+	}).Build()
+}
+
+func TestAuthenticate(t *testing.T) {
+	users := map[string]string{
+		"agent-token":     "system:serviceaccount:broker-agents:cluster-a",
+		"foreign-token":   "system:serviceaccount:kube-system:cluster-a",
+		"user-token":      "alice",
+		"malformed-token": "system:serviceaccount:broker-agents",
+	}
+	withCertificate := func(commonName string) func(*http.Request) {
+		return func(req *http.Request) {
+			req.TLS = &tls.ConnectionState{
+				VerifiedChains: [][]*x509.Certificate{{{Subject: pkix.Name{CommonName: commonName}}}},
+			}
+		}
+	}
+	withToken := func(token string) func(*http.Request) {
+		return func(req *http.Request) {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+	}
+
+	tests := []struct {
+		name          string
+		authenticator Authenticator
+		credentials   func(*http.Request)
+		wantClusterID string
+		wantErr       error
+	}{
+		{
+			name:          "client certificate",
+			authenticator: Authenticator{ClientCertificates: true},
+			credentials:   withCertificate("cluster-a"),
+			wantClusterID: "cluster-a",
+		},
+		{
+			name:          "client certificate without common name",
+			authenticator: Authenticator{ClientCertificates: true},
+			credentials:   withCertificate(""),
+			wantErr:       ErrUnauthenticated,
+		},
+		{
+			name:          "client certificates not accepted",
+			authenticator: Authenticator{ServiceAccountNamespace: "broker-agents"},
+			credentials:   withCertificate("cluster-a"),
+			wantErr:       ErrUnauthenticated,
+		},
+		{
+			name:          "no credentials",
+			authenticator: Authenticator{ClientCertificates: true, ServiceAccountNamespace: "broker-agents"},
+			wantErr:       ErrUnauthenticated,
+		},
+		{
+			name:          "agent ServiceAccount token",
+			authenticator: Authenticator{ServiceAccountNamespace: "broker-agents"},
+			credentials:   withToken("agent-token"),
+			wantClusterID: "cluster-a",
+		},
+		{
+			name:          "token for the required audience",
+			authenticator: Authenticator{ServiceAccountNamespace: "broker-agents", Audiences: []string{"broker"}},
+			credentials:   withToken("agent-token"),
+			wantClusterID: "cluster-a",
+		},
+		{
+			name:          "token for another audience",
+			authenticator: Authenticator{ServiceAccountNamespace: "broker-agents", Audiences: []string{"other"}},
+			credentials:   withToken("agent-token"),
+			wantErr:       ErrUnauthenticated,
+		},
+		{
+			name:          "unknown token",
+			authenticator: Authenticator{ServiceAccountNamespace: "broker-agents"},
+			credentials:   withToken("stolen-token"),
+			wantErr:       ErrUnauthenticated,
+		},
+		{
+			name:          "tokens not accepted",
+			authenticator: Authenticator{ClientCertificates: true},
+			credentials:   withToken("agent-token"),
+			wantErr:       ErrUnauthenticated,
+		},
+		{
+			name:          "ServiceAccount of another namespace",
+			authenticator: Authenticator{ServiceAccountNamespace: "broker-agents"},
+			credentials:   withToken("foreign-token"),
+			wantErr:       ErrForbidden,
+		},
+		{
+			name:          "user token",
+			authenticator: Authenticator{ServiceAccountNamespace: "broker-agents"},
+			credentials:   withToken("user-token"),
+			wantErr:       ErrForbidden,
+		},
+		{
+			name:          "ServiceAccount username without a name",
+			authenticator: Authenticator{ServiceAccountNamespace: "broker-agents"},
+			credentials:   withToken("malformed-token"),
+			wantErr:       ErrForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authenticator := tt.authenticator
+			authenticator.Client = reviewer(t, users)
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.credentials != nil {
+				tt.credentials(req)
+			}
+
+			agent, err := authenticator.Authenticate(req)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Authenticate() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Authenticate() error = %v", err)
+			}
+			if agent.ClusterID != tt.wantClusterID {
+				t.Errorf("Authenticate() clusterID = %q, want %q", agent.ClusterID, tt.wantClusterID)
+			}
+		})
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	agent := &Identity{Name: "certificate cluster-a", ClusterID: "cluster-a"}
+
+	if err := agent.AuthorizeClusterID("cluster-a"); err != nil {
+		t.Errorf("AuthorizeClusterID(own) = %v, want nil", err)
+	}
+	if err := agent.AuthorizeClusterID("cluster-b"); HTTPStatus(err) != http.StatusForbidden {
+		t.Errorf("AuthorizeClusterID(other) = %v, want a 403", err)
+	}
+	if err := agent.AuthorizeRequesterID("cluster-a"); err != nil {
+		t.Errorf("AuthorizeRequesterID(own) = %v, want nil", err)
+	}
+	if err := agent.AuthorizeRequesterID("cluster-b"); HTTPStatus(err) != http.StatusForbidden {
+		t.Errorf("AuthorizeRequesterID(other) = %v, want a 403", err)
+	}
+}
+
+func TestEnabled(t *testing.T) {
+	var none *Authenticator
+	if none.Enabled() {
+		t.Error("nil Authenticator is enabled")
+	}
+	if (&Authenticator{Audiences: []string{"broker"}}).Enabled() {
+		t.Error("Authenticator without a method is enabled")
+	}
+	if !(&Authenticator{ClientCertificates: true}).Enabled() {
+		t.Error("Authenticator with client certificates is not enabled")
+	}
+	if !(&Authenticator{ServiceAccountNamespace: "broker-agents"}).Enabled() {
+		t.Error("Authenticator with ServiceAccount tokens is not enabled")
+	}
+}
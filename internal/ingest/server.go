@@ -0,0 +1,292 @@
+package ingest
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/broker"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/identity"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/resource"
+)
+
+const (
+	// AdvertisementsPath is where agents POST their advertisements
+	AdvertisementsPath = "/v1alpha1/advertisements"
+
+	// maxPayloadBytes bounds the size of an advertisement
+	maxPayloadBytes = 1 << 20
+
+	// shutdownTimeout is how long in-flight requests may finish on shutdown
+	shutdownTimeout = 5 * time.Second
+)
+
+var logger = log.Log.WithName("advertisement-ingest")
+
+// Server accepts advertisements from resource agents over HTTPS and upserts the
+// matching ClusterAdvertisement, so agents need no credentials for the broker's
+// kube-apiserver. It runs as a manager runnable.
+type Server struct {
+	Client client.Client
+
+	// BindAddress is the address the server listens on, e.g. :8443
+	BindAddress string
+
+	// Namespace is where ClusterAdvertisements for new clusters are created
+	Namespace string
+
+	// TLSConfig serves HTTPS; nil serves plain HTTP
+	TLSConfig *tls.Config
+
+	// Authenticator establishes which cluster an agent acts for, so that it can
+	// only advertise its own cluster. Nil accepts anonymous advertisements.
+	Authenticator *identity.Authenticator
+
+	// Routes register further APIs served on the same endpoint
+	Routes []func(mux *http.ServeMux)
+}
+
+// NeedLeaderElection lets every replica accept advertisements
+func (s *Server) NeedLeaderElection() bool {
+	return false
+}
+
+// Start serves until ctx is cancelled
+func (s *Server) Start(ctx context.Context) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST "+AdvertisementsPath, s.handleAdvertisement)
+	for _, register := range s.Routes {
+		register(mux)
+	}
+
+	server := &http.Server{
+		Addr:              s.BindAddress,
+		Handler:           mux,
+		TLSConfig:         s.TLSConfig,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		logger.Info("Serving advertisement ingestion", "address", s.BindAddress, "secure", s.TLSConfig != nil)
+		var err error
+		if s.TLSConfig != nil {
+			err = server.ListenAndServeTLS("", "")
+		} else {
+			err = server.ListenAndServe()
+		}
+		if !errors.Is(err, http.ErrServerClosed) {
+			serveErr <- err
+		}
+		close(serveErr)
+	}()
+
+	select {
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		return server.Shutdown(shutdownCtx)
+	case err := <-serveErr:
+		return err
+	}
+}
+
+// handleAdvertisement validates an advertisement and stores it. It answers 201
+// with the new ClusterAdvertisement or 200 with the updated one.
+func (s *Server) handleAdvertisement(w http.ResponseWriter, req *http.Request) {
+	var agent *identity.Identity
+	if s.Authenticator.Enabled() {
+		var err error
+		if agent, err = s.Authenticator.Authenticate(req); err != nil {
+			logger.Info("Rejected advertisement", "remoteAddr", req.RemoteAddr, "reason", err.Error())
+			writeError(w, identity.HTTPStatus(err), err.Error())
+			return
+		}
+	}
+
+	spec := &brokerv1alpha1.ClusterAdvertisementSpec{}
+	if err := json.NewDecoder(http.MaxBytesReader(w, req.Body, maxPayloadBytes)).Decode(spec); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("malformed advertisement: %v", err))
+		return
+	}
+	if err := broker.ValidateAdvertisement(spec); err != nil {
+		writeError(w, http.StatusUnprocessableEntity, fmt.Sprintf("invalid advertisement: %v", err))
+		return
+	}
+	if agent != nil {
+		if err := agent.AuthorizeClusterID(spec.ClusterID); err != nil {
+			logger.Info("Rejected spoofed advertisement", "agent", agent.Name, "clusterID", spec.ClusterID)
+			writeError(w, identity.HTTPStatus(err), err.Error())
+			return
+		}
+	}
+
+	clusterAdv, created, err := s.Upsert(req.Context(), spec)
+	switch {
+	case apierrors.IsInvalid(err):
+		writeError(w, http.StatusUnprocessableEntity, fmt.Sprintf("invalid advertisement: %v", err))
+		return
+	case apierrors.IsAlreadyExists(err) || apierrors.IsConflict(err):
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	case err != nil:
+		logger.Error(err, "Failed to store advertisement", "clusterID", spec.ClusterID)
+		writeError(w, http.StatusInternalServerError, "failed to store advertisement")
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	logger.Info("Advertisement received", "clusterID", spec.ClusterID,
+		"clusterAdvertisement", clusterAdv.Namespace+"/"+clusterAdv.Name, "created", created)
+	writeJSON(w, status, clusterAdv)
+}
+
+// Upsert stores an advertisement: the ClusterAdvertisement with the same
+// ClusterID is updated, or one named after the cluster is created. The agent
+// owns what it reports about the cluster, while the broker keeps the Reserved
+// counter and the bookings it maintains and recomputes Available from them.
+// Taints are left to operators, and of the labels the agent only owns those it
+// reported itself. Timestamp is the time of receipt.
+func (s *Server) Upsert(
+	ctx context.Context,
+	spec *brokerv1alpha1.ClusterAdvertisementSpec,
+) (*brokerv1alpha1.ClusterAdvertisement, bool, error) {
+	var clusterAdv *brokerv1alpha1.ClusterAdvertisement
+	created := false
+
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		existing, err := s.findByClusterID(ctx, spec.ClusterID)
+		if err != nil {
+			return err
+		}
+
+		if existing == nil {
+			clusterAdv = &brokerv1alpha1.ClusterAdvertisement{
+				ObjectMeta: metav1.ObjectMeta{Name: spec.ClusterID, Namespace: s.Namespace},
+				Spec:       *spec.DeepCopy(),
+			}
+			clusterAdv.Spec.Labels = nil
+			clusterAdv.Spec.Taints = nil
+			clusterAdv.Spec.Resources.Reserved = nil
+			clusterAdv.Spec.Bookings = nil
+			applyAgentLabels(clusterAdv, spec.Labels)
+			clusterAdv.Spec.Timestamp = metav1.Now()
+			resource.UpdateAvailableResources(&clusterAdv.Spec.Resources)
+			created = true
+			return s.Client.Create(ctx, clusterAdv)
+		}
+
+		clusterAdv = existing
+		applyAdvertisement(clusterAdv, spec)
+		created = false
+		return s.Client.Update(ctx, clusterAdv)
+	})
+	return clusterAdv, created, err
+}
+
+// applyAdvertisement copies what an agent reports into a stored advertisement.
+// Reported taints are ignored: an agent must not be able to lift a taint an
+// operator set on its cluster.
+func applyAdvertisement(
+	clusterAdv *brokerv1alpha1.ClusterAdvertisement,
+	reported *brokerv1alpha1.ClusterAdvertisementSpec,
+) {
+	stored := &clusterAdv.Spec
+	stored.ClusterName = reported.ClusterName
+	stored.EndpointURL = reported.EndpointURL
+	stored.Cost = reported.Cost.DeepCopy()
+	applyAgentLabels(clusterAdv, reported.Labels)
+
+	reserved := stored.Resources.Reserved
+	stored.Resources = *reported.Resources.DeepCopy()
+	stored.Resources.Reserved = reserved
+	resource.UpdateAvailableResources(&stored.Resources)
+
+	stored.Timestamp = metav1.Now()
+}
+
+// applyAgentLabels replaces the labels the agent reported last time with the
+// ones it reports now. Labels an operator set are kept, also when the agent
+// reports the same key, so affinity cannot be steered by the agent.
+func applyAgentLabels(clusterAdv *brokerv1alpha1.ClusterAdvertisement, reported map[string]string) {
+	owned := map[string]bool{}
+	if keys := clusterAdv.Annotations[brokerv1alpha1.ClusterAdvertisementAgentLabelsAnnotation]; keys != "" {
+		for _, key := range strings.Split(keys, ",") {
+			owned[key] = true
+		}
+	}
+
+	labels := map[string]string{}
+	for key, value := range clusterAdv.Spec.Labels {
+		if !owned[key] {
+			labels[key] = value
+		}
+	}
+	var agentKeys []string
+	for key, value := range reported {
+		if _, operator := labels[key]; operator {
+			continue
+		}
+		labels[key] = value
+		agentKeys = append(agentKeys, key)
+	}
+	slices.Sort(agentKeys)
+
+	clusterAdv.Spec.Labels = nil
+	if len(labels) > 0 {
+		clusterAdv.Spec.Labels = labels
+	}
+	if len(agentKeys) == 0 {
+		delete(clusterAdv.Annotations, brokerv1alpha1.ClusterAdvertisementAgentLabelsAnnotation)
+		return
+	}
+	metav1.SetMetaDataAnnotation(&clusterAdv.ObjectMeta,
+		brokerv1alpha1.ClusterAdvertisementAgentLabelsAnnotation, strings.Join(agentKeys, ","))
+}
+
+// findByClusterID returns the ClusterAdvertisement of a cluster in any namespace, or nil
+func (s *Server) findByClusterID(
+	ctx context.Context,
+	clusterID string,
+) (*brokerv1alpha1.ClusterAdvertisement, error) {
+	clusterList := &brokerv1alpha1.ClusterAdvertisementList{}
+	if err := s.Client.List(ctx, clusterList); err != nil {
+		return nil, err
+	}
+	for i := range clusterList.Items {
+		if clusterList.Items[i].Spec.ClusterID == clusterID {
+			return &clusterList.Items[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// writeJSON answers with a JSON body
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		logger.Error(err, "Failed to write response")
+	}
+}
+
+// writeError answers with a JSON error message
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
@@ -0,0 +1,242 @@
+package ingest
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/identity"
+)
+
+// advertisement is a valid advertisement payload for a cluster
+func advertisement(clusterID string) string {
+	return `{"clusterID": "` + clusterID + `", "resources": {` +
+		`"capacity": {"cpu": "8", "memory": "16Gi"}, ` +
+		`"allocatable": {"cpu": "8", "memory": "16Gi"}, ` +
+		`"allocated": {"cpu": "2", "memory": "4Gi"}}}`
+}
+
+// asCluster makes a request carry a verified client certificate for a cluster
+func asCluster(req *http.Request, clusterID string) {
+	req.TLS = &tls.ConnectionState{
+		VerifiedChains: [][]*x509.Certificate{{{Subject: pkix.Name{CommonName: clusterID}}}},
+	}
+}
+
+func TestHandleAdvertisement(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := brokerv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	existing := &brokerv1alpha1.ClusterAdvertisement{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-b", Namespace: "default"},
+		Spec:       brokerv1alpha1.ClusterAdvertisementSpec{ClusterID: "cluster-b"},
+	}
+	certificates := &identity.Authenticator{ClientCertificates: true}
+
+	tests := []struct {
+		name          string
+		authenticator *identity.Authenticator
+		agent         string
+		body          string
+		wantStatus    int
+	}{
+		{
+			name:          "no credentials",
+			authenticator: certificates,
+			body:          advertisement("cluster-a"),
+			wantStatus:    http.StatusUnauthorized,
+		},
+		{
+			name:          "spoofed clusterID",
+			authenticator: certificates,
+			agent:         "cluster-a",
+			body:          advertisement("cluster-b"),
+			wantStatus:    http.StatusForbidden,
+		},
+		{
+			name:          "new cluster",
+			authenticator: certificates,
+			agent:         "cluster-a",
+			body:          advertisement("cluster-a"),
+			wantStatus:    http.StatusCreated,
+		},
+		{
+			name:          "known cluster",
+			authenticator: certificates,
+			agent:         "cluster-b",
+			body:          advertisement("cluster-b"),
+			wantStatus:    http.StatusOK,
+		},
+		{
+			name:          "malformed advertisement",
+			authenticator: certificates,
+			agent:         "cluster-a",
+			body:          `{"clusterID":`,
+			wantStatus:    http.StatusBadRequest,
+		},
+		{
+			name:          "invalid advertisement",
+			authenticator: certificates,
+			agent:         "cluster-a",
+			body:          `{"clusterID": "Not_A_Name"}`,
+			wantStatus:    http.StatusUnprocessableEntity,
+		},
+		{
+			name:       "no authenticator",
+			body:       advertisement("cluster-a"),
+			wantStatus: http.StatusCreated,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := &Server{
+				Client:        fake.NewClientBuilder().WithScheme(scheme).WithObjects(existing.DeepCopy()).Build(),
+				Namespace:     "default",
+				Authenticator: tt.authenticator,
+			}
+			req := httptest.NewRequest(http.MethodPost, AdvertisementsPath, strings.NewReader(tt.body))
+			if tt.agent != "" {
+				asCluster(req, tt.agent)
+			}
+			rec := httptest.NewRecorder()
+
+			server.handleAdvertisement(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandleAdvertisementKeepsReserved(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := brokerv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(&brokerv1alpha1.ClusterAdvertisement{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-b", Namespace: "default"},
+		Spec: brokerv1alpha1.ClusterAdvertisementSpec{
+			ClusterID: "cluster-b",
+			Resources: brokerv1alpha1.ResourceMetrics{
+				Reserved: &brokerv1alpha1.ResourceQuantities{CPU: resource.MustParse("1"), Memory: resource.MustParse("1Gi")},
+			},
+		},
+	}).Build()
+	server := &Server{Client: c, Namespace: "default", Authenticator: &identity.Authenticator{ClientCertificates: true}}
+
+	// The agent reports a Reserved counter it does not own
+	body := strings.Replace(advertisement("cluster-b"), `"allocated"`, `"reserved": {"cpu": "6", "memory": "1Gi"}, "allocated"`, 1)
+	req := httptest.NewRequest(http.MethodPost, AdvertisementsPath, strings.NewReader(body))
+	asCluster(req, "cluster-b")
+	rec := httptest.NewRecorder()
+	server.handleAdvertisement(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+
+	stored := &brokerv1alpha1.ClusterAdvertisement{}
+	if err := c.Get(context.Background(), types.NamespacedName{Name: "cluster-b", Namespace: "default"}, stored); err != nil {
+		t.Fatal(err)
+	}
+	if reserved := stored.Spec.Resources.Reserved.CPU; reserved.Cmp(resource.MustParse("1")) != 0 {
+		t.Errorf("reserved cpu = %s, want 1", reserved.String())
+	}
+	// allocatable 8 - allocated 2 - reserved 1
+	if available := stored.Spec.Resources.Available.CPU; available.Cmp(resource.MustParse("5")) != 0 {
+		t.Errorf("available cpu = %s, want 5", available.String())
+	}
+
+	answered := &brokerv1alpha1.ClusterAdvertisement{}
+	if err := json.Unmarshal(rec.Body.Bytes(), answered); err != nil {
+		t.Fatalf("response is not a ClusterAdvertisement: %v", err)
+	}
+	if answered.Spec.ClusterID != "cluster-b" {
+		t.Errorf("answered clusterID = %q, want cluster-b", answered.Spec.ClusterID)
+	}
+}
+
+func TestHandleAdvertisementOperatorLabelsAndTaints(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := brokerv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(&brokerv1alpha1.ClusterAdvertisement{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "cluster-b",
+			Namespace:   "default",
+			Annotations: map[string]string{brokerv1alpha1.ClusterAdvertisementAgentLabelsAnnotation: "gpu,zone"},
+		},
+		Spec: brokerv1alpha1.ClusterAdvertisementSpec{
+			ClusterID: "cluster-b",
+			// region and tier were set by an operator, gpu and zone by the agent
+			Labels: map[string]string{"region": "eu", "tier": "gold", "gpu": "a100", "zone": "eu-1a"},
+			Taints: []brokerv1alpha1.ClusterTaint{{Key: "dedicated", Value: "team-a", Effect: brokerv1alpha1.TaintEffectNoReserve}},
+		},
+	}).Build()
+	server := &Server{Client: c, Namespace: "default", Authenticator: &identity.Authenticator{ClientCertificates: true}}
+
+	// The agent tries to drop the taint, relabel the region and stops reporting gpu
+	body := strings.Replace(advertisement("cluster-b"), `"resources"`,
+		`"labels": {"region": "us", "zone": "eu-1b", "arch": "arm64"}, "taints": [], "resources"`, 1)
+	req := httptest.NewRequest(http.MethodPost, AdvertisementsPath, strings.NewReader(body))
+	asCluster(req, "cluster-b")
+	rec := httptest.NewRecorder()
+	server.handleAdvertisement(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+
+	stored := &brokerv1alpha1.ClusterAdvertisement{}
+	if err := c.Get(context.Background(), types.NamespacedName{Name: "cluster-b", Namespace: "default"}, stored); err != nil {
+		t.Fatal(err)
+	}
+	wantLabels := map[string]string{"region": "eu", "tier": "gold", "zone": "eu-1b", "arch": "arm64"}
+	if !maps.Equal(stored.Spec.Labels, wantLabels) {
+		t.Errorf("labels = %v, want %v", stored.Spec.Labels, wantLabels)
+	}
+	if owned := stored.Annotations[brokerv1alpha1.ClusterAdvertisementAgentLabelsAnnotation]; owned != "arch,zone" {
+		t.Errorf("agent labels = %q, want arch,zone", owned)
+	}
+	if len(stored.Spec.Taints) != 1 || stored.Spec.Taints[0].Key != "dedicated" {
+		t.Errorf("taints = %+v, want the operator's dedicated taint", stored.Spec.Taints)
+	}
+
+	// A new cluster's agent cannot taint it either, but owns the labels it reports
+	body = strings.Replace(advertisement("cluster-a"), `"resources"`,
+		`"labels": {"region": "eu"}, "taints": [{"key": "x", "effect": "NoReserve"}], "resources"`, 1)
+	req = httptest.NewRequest(http.MethodPost, AdvertisementsPath, strings.NewReader(body))
+	asCluster(req, "cluster-a")
+	rec = httptest.NewRecorder()
+	server.handleAdvertisement(rec, req)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusCreated, rec.Body.String())
+	}
+	created := &brokerv1alpha1.ClusterAdvertisement{}
+	if err := c.Get(context.Background(), types.NamespacedName{Name: "cluster-a", Namespace: "default"}, created); err != nil {
+		t.Fatal(err)
+	}
+	if len(created.Spec.Taints) != 0 {
+		t.Errorf("taints = %+v, want none", created.Spec.Taints)
+	}
+	if owned := created.Annotations[brokerv1alpha1.ClusterAdvertisementAgentLabelsAnnotation]; owned != "region" ||
+		created.Spec.Labels["region"] != "eu" {
+		t.Errorf("labels = %v owned %q, want region=eu owned by the agent", created.Spec.Labels, owned)
+	}
+}
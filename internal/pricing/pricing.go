@@ -0,0 +1,174 @@
+package pricing
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+)
+
+// DefaultBaseCurrency is the currency all prices are converted to when none is configured
+const DefaultBaseCurrency = "USD"
+
+// bytesPerGB is the unit memory prices are expressed in (per GB per hour)
+const bytesPerGB = 1 << 30
+
+// Prices are the parsed hourly unit prices advertised by a cluster
+type Prices struct {
+	// CPUPerCoreHour is the price of one core for one hour
+	CPUPerCoreHour float64
+	// MemoryPerGBHour is the price of one GB of memory for one hour
+	MemoryPerGBHour float64
+	// Currency the prices are expressed in
+	Currency string
+}
+
+// ParsePrices parses the string prices of a CostInfo. A missing CPU or memory
+// price counts as free; a malformed or negative one is an error.
+func ParsePrices(cost *brokerv1alpha1.CostInfo) (Prices, error) {
+	if cost == nil {
+		return Prices{}, fmt.Errorf("no cost information")
+	}
+
+	cpuPrice, err := parsePrice(cost.CPUCost)
+	if err != nil {
+		return Prices{}, fmt.Errorf("invalid cpuCost %q: %w", cost.CPUCost, err)
+	}
+	memoryPrice, err := parsePrice(cost.MemoryCost)
+	if err != nil {
+		return Prices{}, fmt.Errorf("invalid memoryCost %q: %w", cost.MemoryCost, err)
+	}
+
+	return Prices{
+		CPUPerCoreHour:  cpuPrice,
+		MemoryPerGBHour: memoryPrice,
+		Currency:        strings.ToUpper(strings.TrimSpace(cost.Currency)),
+	}, nil
+}
+
+func parsePrice(value string) (float64, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, nil
+	}
+	price, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, err
+	}
+	if price < 0 {
+		return 0, fmt.Errorf("price must not be negative")
+	}
+	return price, nil
+}
+
+// ExchangeTable converts prices between currencies
+type ExchangeTable struct {
+	// Base is the currency all prices are converted to
+	Base string
+	// Rates maps a currency to how many units of Base one unit of it is worth
+	Rates map[string]float64
+}
+
+// NewExchangeTable builds an exchange table from a comma-separated list of
+// CURRENCY=RATE pairs, e.g. "EUR=1.08,GBP=1.27", relative to base
+func NewExchangeTable(base, rates string) (*ExchangeTable, error) {
+	if base == "" {
+		base = DefaultBaseCurrency
+	}
+	table := &ExchangeTable{
+		Base:  strings.ToUpper(base),
+		Rates: map[string]float64{},
+	}
+
+	for _, pair := range strings.Split(rates, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		currency, value, found := strings.Cut(pair, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid exchange rate %q: expected CURRENCY=RATE", pair)
+		}
+		rate, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || rate <= 0 {
+			return nil, fmt.Errorf("invalid exchange rate %q: rate must be a positive number", pair)
+		}
+		table.Rates[strings.ToUpper(strings.TrimSpace(currency))] = rate
+	}
+
+	return table, nil
+}
+
+// Convert converts an amount from currency into the base currency.
+// An empty currency is assumed to already be the base currency.
+func (t *ExchangeTable) Convert(amount float64, currency string) (float64, error) {
+	currency = strings.ToUpper(currency)
+	if currency == "" || currency == t.Base {
+		return amount, nil
+	}
+	rate, ok := t.Rates[currency]
+	if !ok {
+		return 0, fmt.Errorf("no exchange rate from %s to %s", currency, t.Base)
+	}
+	return amount * rate, nil
+}
+
+// HourlyCost returns the price of running the requested resources for one hour
+// on a cluster, in the base currency
+func (t *ExchangeTable) HourlyCost(
+	cost *brokerv1alpha1.CostInfo,
+	requested brokerv1alpha1.RequestedResourceQuantities,
+) (float64, error) {
+	prices, err := ParsePrices(cost)
+	if err != nil {
+		return 0, err
+	}
+
+	cores := requested.CPU.AsApproximateFloat64()
+	gigabytes := requested.Memory.AsApproximateFloat64() / bytesPerGB
+	hourly := cores*prices.CPUPerCoreHour + gigabytes*prices.MemoryPerGBHour
+
+	return t.Convert(hourly, prices.Currency)
+}
+
+// Estimate is the projected cost of a reservation
+type Estimate struct {
+	// Hourly is the cost per hour in the base currency
+	Hourly float64
+	// Total is the cost over the whole reservation; only meaningful when Bounded
+	Total float64
+	// Bounded is false when the reservation has no duration, so no total can be computed
+	Bounded bool
+	// Currency of Hourly and Total
+	Currency string
+}
+
+// Estimate projects the hourly and total cost of holding the requested
+// resources on a cluster for the given duration (zero means open-ended)
+func (t *ExchangeTable) Estimate(
+	cost *brokerv1alpha1.CostInfo,
+	requested brokerv1alpha1.RequestedResourceQuantities,
+	duration time.Duration,
+) (Estimate, error) {
+	hourly, err := t.HourlyCost(cost, requested)
+	if err != nil {
+		return Estimate{}, err
+	}
+
+	estimate := Estimate{
+		Hourly:   hourly,
+		Currency: t.Base,
+	}
+	if duration > 0 {
+		estimate.Total = hourly * duration.Hours()
+		estimate.Bounded = true
+	}
+	return estimate, nil
+}
+
+// FormatAmount renders a monetary amount the way CostInfo prices are written
+func FormatAmount(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', 4, 64)
+}
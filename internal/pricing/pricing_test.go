@@ -0,0 +1,200 @@
+package pricing
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+)
+
+func TestParsePrices(t *testing.T) {
+	tests := []struct {
+		name    string
+		cost    *brokerv1alpha1.CostInfo
+		want    Prices
+		wantErr bool
+	}{
+		{
+			name: "both prices",
+			cost: &brokerv1alpha1.CostInfo{CPUCost: "0.05", MemoryCost: "0.01", Currency: "usd"},
+			want: Prices{CPUPerCoreHour: 0.05, MemoryPerGBHour: 0.01, Currency: "USD"},
+		},
+		{
+			name: "missing prices are free",
+			cost: &brokerv1alpha1.CostInfo{Currency: " eur "},
+			want: Prices{Currency: "EUR"},
+		},
+		{
+			name: "surrounding spaces",
+			cost: &brokerv1alpha1.CostInfo{CPUCost: " 1.5 ", MemoryCost: "2"},
+			want: Prices{CPUPerCoreHour: 1.5, MemoryPerGBHour: 2},
+		},
+		{name: "no cost information", cost: nil, wantErr: true},
+		{name: "malformed cpu price", cost: &brokerv1alpha1.CostInfo{CPUCost: "cheap"}, wantErr: true},
+		{name: "negative memory price", cost: &brokerv1alpha1.CostInfo{MemoryCost: "-0.01"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePrices(tt.cost)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePrices() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("ParsePrices() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewExchangeTable(t *testing.T) {
+	tests := []struct {
+		name     string
+		base     string
+		rates    string
+		wantBase string
+		want     map[string]float64
+		wantErr  bool
+	}{
+		{name: "default base", wantBase: DefaultBaseCurrency, want: map[string]float64{}},
+		{
+			name:     "several rates",
+			base:     "usd",
+			rates:    "EUR=1.08, gbp=1.27,",
+			wantBase: "USD",
+			want:     map[string]float64{"EUR": 1.08, "GBP": 1.27},
+		},
+		{name: "missing rate", rates: "EUR", wantErr: true},
+		{name: "malformed rate", rates: "EUR=abc", wantErr: true},
+		{name: "zero rate", rates: "EUR=0", wantErr: true},
+		{name: "negative rate", rates: "EUR=-1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewExchangeTable(tt.base, tt.rates)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewExchangeTable() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.Base != tt.wantBase {
+				t.Errorf("Base = %q, want %q", got.Base, tt.wantBase)
+			}
+			if len(got.Rates) != len(tt.want) {
+				t.Fatalf("Rates = %v, want %v", got.Rates, tt.want)
+			}
+			for currency, rate := range tt.want {
+				if got.Rates[currency] != rate {
+					t.Errorf("Rates[%s] = %v, want %v", currency, got.Rates[currency], rate)
+				}
+			}
+		})
+	}
+}
+
+func TestConvert(t *testing.T) {
+	table, err := NewExchangeTable("USD", "EUR=1.08")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		amount   float64
+		currency string
+		want     float64
+		wantErr  bool
+	}{
+		{name: "base currency", amount: 2, currency: "USD", want: 2},
+		{name: "empty currency is the base", amount: 2, want: 2},
+		{name: "lower case", amount: 2, currency: "usd", want: 2},
+		{name: "converted", amount: 10, currency: "EUR", want: 10.8},
+		{name: "unknown currency", amount: 1, currency: "JPY", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := table.Convert(tt.amount, tt.currency)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Convert() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !approxEqual(got, tt.want) {
+				t.Errorf("Convert() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEstimate(t *testing.T) {
+	table, err := NewExchangeTable("USD", "EUR=2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	requested := brokerv1alpha1.RequestedResourceQuantities{
+		CPU:    resource.MustParse("4"),
+		Memory: resource.MustParse("8Gi"),
+	}
+
+	tests := []struct {
+		name     string
+		cost     *brokerv1alpha1.CostInfo
+		duration time.Duration
+		want     Estimate
+		wantErr  bool
+	}{
+		{
+			name:     "bounded",
+			cost:     &brokerv1alpha1.CostInfo{CPUCost: "0.5", MemoryCost: "0.25", Currency: "USD"},
+			duration: 90 * time.Minute,
+			// 4 cores * 0.5 + 8 GB * 0.25 = 4 per hour
+			want: Estimate{Hourly: 4, Total: 6, Bounded: true, Currency: "USD"},
+		},
+		{
+			name: "open-ended",
+			cost: &brokerv1alpha1.CostInfo{CPUCost: "0.5", MemoryCost: "0.25"},
+			want: Estimate{Hourly: 4, Currency: "USD"},
+		},
+		{
+			name:     "converted to the base currency",
+			cost:     &brokerv1alpha1.CostInfo{CPUCost: "0.5", Currency: "EUR"},
+			duration: 2 * time.Hour,
+			want:     Estimate{Hourly: 4, Total: 8, Bounded: true, Currency: "USD"},
+		},
+		{
+			name:    "unknown currency",
+			cost:    &brokerv1alpha1.CostInfo{CPUCost: "0.5", Currency: "JPY"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := table.Estimate(tt.cost, requested, tt.duration)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Estimate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !approxEqual(got.Hourly, tt.want.Hourly) || !approxEqual(got.Total, tt.want.Total) ||
+				got.Bounded != tt.want.Bounded || got.Currency != tt.want.Currency {
+				t.Errorf("Estimate() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatAmount(t *testing.T) {
+	if got := FormatAmount(0.14); got != "0.1400" {
+		t.Errorf("FormatAmount(0.14) = %q, want %q", got, "0.1400")
+	}
+}
+
+func approxEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
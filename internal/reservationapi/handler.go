@@ -0,0 +1,403 @@
+package reservationapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/identity"
+)
+
+const (
+	// BasePath is the versioned prefix of every reservation API call
+	BasePath = "/v1alpha1/reservations"
+
+	// maxPayloadBytes bounds the size of a request body
+	maxPayloadBytes = 1 << 20
+
+	// watchPollInterval is how often a watch looks for changes of the reservation
+	watchPollInterval = 1 * time.Second
+
+	// conditionReasonAPI is the condition reason used when a call sets none
+	conditionReasonAPI = "ReservationAPI"
+)
+
+var (
+	logger = log.Log.WithName("reservation-api")
+
+	// errWrongPhase means the call does not apply to the reservation's current phase
+	errWrongPhase = errors.New("wrong phase")
+)
+
+// CreateRequest is the body of a create call
+type CreateRequest struct {
+	// Name of the reservation; generated from the requester ID if empty
+	Name string `json:"name,omitempty"`
+
+	// Spec of the reservation. RequesterID defaults to the caller's cluster ID.
+	Spec brokerv1alpha1.ReservationSpec `json:"spec"`
+}
+
+// ConditionRequest is the optional body of activate and release calls
+type ConditionRequest struct {
+	// Reason recorded on the condition, e.g. PeeringReady
+	Reason string `json:"reason,omitempty"`
+
+	// Message recorded on the condition
+	Message string `json:"message,omitempty"`
+}
+
+// Handler serves the reservation API to remote requesters. Every call maps onto
+// the Reservation objects the ReservationReconciler acts on: create and cancel
+// create and delete them, activate and release set the RequesterActive and
+// RequesterReleased conditions and heartbeat renews requesterHeartbeatTime.
+//
+//	POST   /v1alpha1/reservations                  create
+//	GET    /v1alpha1/reservations/{name}           get, or watch with ?watch=true
+//	DELETE /v1alpha1/reservations/{name}           cancel
+//	POST   /v1alpha1/reservations/{name}/activate  activate a Reserved reservation
+//	POST   /v1alpha1/reservations/{name}/release   release an Active reservation
+//	POST   /v1alpha1/reservations/{name}/heartbeat renew the requester heartbeat
+type Handler struct {
+	Client client.Client
+
+	// Namespace holds the reservations created through the API
+	Namespace string
+
+	// Authenticator binds callers to their own requester ID. Nil lets anyone
+	// act on any reservation in Namespace.
+	Authenticator *identity.Authenticator
+}
+
+// Register adds the API's routes to a mux
+func (h *Handler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("POST "+BasePath, h.create)
+	mux.HandleFunc("GET "+BasePath+"/{name}", h.get)
+	mux.HandleFunc("DELETE "+BasePath+"/{name}", h.cancel)
+	mux.HandleFunc("POST "+BasePath+"/{name}/activate", h.activate)
+	mux.HandleFunc("POST "+BasePath+"/{name}/release", h.release)
+	mux.HandleFunc("POST "+BasePath+"/{name}/heartbeat", h.heartbeat)
+}
+
+// create stores a new reservation and answers 201 with it
+func (h *Handler) create(w http.ResponseWriter, req *http.Request) {
+	agent, ok := h.authenticate(w, req)
+	if !ok {
+		return
+	}
+
+	body := &CreateRequest{}
+	if err := json.NewDecoder(http.MaxBytesReader(w, req.Body, maxPayloadBytes)).Decode(body); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("malformed request: %v", err))
+		return
+	}
+	if agent != nil {
+		if body.Spec.RequesterID == "" {
+			body.Spec.RequesterID = agent.ClusterID
+		}
+		if err := agent.AuthorizeRequesterID(body.Spec.RequesterID); err != nil {
+			writeError(w, identity.HTTPStatus(err), err.Error())
+			return
+		}
+	}
+	if body.Spec.RequesterID == "" {
+		writeError(w, http.StatusUnprocessableEntity, "spec.requesterID is required")
+		return
+	}
+
+	reservation := &brokerv1alpha1.Reservation{
+		ObjectMeta: metav1.ObjectMeta{Name: body.Name, Namespace: h.Namespace},
+		Spec:       body.Spec,
+	}
+	if reservation.Name == "" {
+		reservation.GenerateName = body.Spec.RequesterID + "-"
+	}
+	if err := h.Client.Create(req.Context(), reservation); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	logger.Info("Reservation created", "reservation", reservation.Namespace+"/"+reservation.Name,
+		"requesterID", reservation.Spec.RequesterID)
+	writeJSON(w, http.StatusCreated, reservation)
+}
+
+// get answers with a reservation, or streams it on every change with ?watch=true
+func (h *Handler) get(w http.ResponseWriter, req *http.Request) {
+	reservation, ok := h.lookup(w, req)
+	if !ok {
+		return
+	}
+	if req.URL.Query().Get("watch") == "true" {
+		h.watch(w, req, reservation)
+		return
+	}
+	writeJSON(w, http.StatusOK, reservation)
+}
+
+// watch streams the reservation as newline-delimited JSON whenever it changes,
+// until it reaches a terminal phase, is deleted or the caller disconnects
+func (h *Handler) watch(w http.ResponseWriter, req *http.Request, reservation *brokerv1alpha1.Reservation) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming is not supported")
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+
+	encoder := json.NewEncoder(w)
+	ticker := time.NewTicker(watchPollInterval)
+	defer ticker.Stop()
+
+	sentVersion := ""
+	for {
+		if reservation.ResourceVersion != sentVersion {
+			if err := encoder.Encode(reservation); err != nil {
+				return
+			}
+			flusher.Flush()
+			sentVersion = reservation.ResourceVersion
+		}
+		if isTerminal(reservation.Status.Phase) {
+			return
+		}
+
+		select {
+		case <-req.Context().Done():
+			return
+		case <-ticker.C:
+		}
+
+		current := &brokerv1alpha1.Reservation{}
+		if err := h.Client.Get(req.Context(), client.ObjectKeyFromObject(reservation), current); err != nil {
+			if !apierrors.IsNotFound(err) && req.Context().Err() == nil {
+				logger.Error(err, "Failed to refresh watched reservation", "reservation", reservation.Name)
+			}
+			return
+		}
+		reservation = current
+	}
+}
+
+// cancel deletes a reservation; the reconciler releases what it holds
+func (h *Handler) cancel(w http.ResponseWriter, req *http.Request) {
+	reservation, ok := h.lookup(w, req)
+	if !ok {
+		return
+	}
+	if err := h.Client.Delete(req.Context(), reservation); err != nil && !apierrors.IsNotFound(err) {
+		writeAPIError(w, err)
+		return
+	}
+
+	logger.Info("Reservation cancelled", "reservation", reservation.Namespace+"/"+reservation.Name,
+		"requesterID", reservation.Spec.RequesterID)
+	writeJSON(w, http.StatusAccepted, reservation)
+}
+
+// activate confirms that the requester is using a Reserved reservation
+func (h *Handler) activate(w http.ResponseWriter, req *http.Request) {
+	h.setCondition(w, req, brokerv1alpha1.ReservationConditionRequesterActive,
+		brokerv1alpha1.ReservationPhaseReserved, brokerv1alpha1.ReservationPhaseActive)
+}
+
+// release tells the broker the requester is done with an Active reservation
+func (h *Handler) release(w http.ResponseWriter, req *http.Request) {
+	h.setCondition(w, req, brokerv1alpha1.ReservationConditionRequesterReleased,
+		brokerv1alpha1.ReservationPhaseActive, brokerv1alpha1.ReservationPhaseReleased)
+}
+
+// heartbeat renews the requester heartbeat of an Active reservation
+func (h *Handler) heartbeat(w http.ResponseWriter, req *http.Request) {
+	reservation, ok := h.lookup(w, req)
+	if !ok {
+		return
+	}
+	updated, err := h.updateStatus(req.Context(), reservation, func(status *brokerv1alpha1.ReservationStatus) error {
+		if status.Phase != brokerv1alpha1.ReservationPhaseActive {
+			return fmt.Errorf("%w: heartbeats apply to Active reservations, this one is %s", errWrongPhase, phaseOf(status))
+		}
+		now := metav1.Now()
+		status.RequesterHeartbeatTime = &now
+		return nil
+	})
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, updated)
+}
+
+// setCondition sets a requester condition on a reservation in phase from. In
+// phase done the condition has already taken effect and the call succeeds
+// without changing anything.
+func (h *Handler) setCondition(
+	w http.ResponseWriter,
+	req *http.Request,
+	conditionType string,
+	from, done brokerv1alpha1.ReservationPhase,
+) {
+	reservation, ok := h.lookup(w, req)
+	if !ok {
+		return
+	}
+
+	body := &ConditionRequest{}
+	if req.ContentLength != 0 {
+		if err := json.NewDecoder(http.MaxBytesReader(w, req.Body, maxPayloadBytes)).Decode(body); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("malformed request: %v", err))
+			return
+		}
+	}
+	if body.Reason == "" {
+		body.Reason = conditionReasonAPI
+	}
+
+	if reservation.Status.Phase == done {
+		writeJSON(w, http.StatusOK, reservation)
+		return
+	}
+	updated, err := h.updateStatus(req.Context(), reservation, func(status *brokerv1alpha1.ReservationStatus) error {
+		if status.Phase != from {
+			return fmt.Errorf("%w: %s applies to %s reservations, this one is %s",
+				errWrongPhase, conditionType, from, phaseOf(status))
+		}
+		meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+			Type:    conditionType,
+			Status:  metav1.ConditionTrue,
+			Reason:  body.Reason,
+			Message: body.Message,
+		})
+		return nil
+	})
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	logger.Info("Requester condition set", "reservation", reservation.Namespace+"/"+reservation.Name,
+		"condition", conditionType)
+	writeJSON(w, http.StatusOK, updated)
+}
+
+// updateStatus applies mutate to the latest version of a reservation's status
+func (h *Handler) updateStatus(
+	ctx context.Context,
+	reservation *brokerv1alpha1.Reservation,
+	mutate func(status *brokerv1alpha1.ReservationStatus) error,
+) (*brokerv1alpha1.Reservation, error) {
+	current := &brokerv1alpha1.Reservation{}
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if err := h.Client.Get(ctx, client.ObjectKeyFromObject(reservation), current); err != nil {
+			return err
+		}
+		if err := mutate(&current.Status); err != nil {
+			return err
+		}
+		return h.Client.Status().Update(ctx, current)
+	})
+	return current, err
+}
+
+// authenticate establishes the caller's identity. It answers the request and
+// returns false if that fails; the identity is nil without an Authenticator.
+func (h *Handler) authenticate(w http.ResponseWriter, req *http.Request) (*identity.Identity, bool) {
+	if !h.Authenticator.Enabled() {
+		return nil, true
+	}
+	agent, err := h.Authenticator.Authenticate(req)
+	if err != nil {
+		logger.Info("Rejected reservation API call", "remoteAddr", req.RemoteAddr, "reason", err.Error())
+		writeError(w, identity.HTTPStatus(err), err.Error())
+		return nil, false
+	}
+	return agent, true
+}
+
+// lookup authenticates the caller and returns the reservation named in the
+// path if it belongs to the caller. It answers the request and returns false
+// otherwise.
+func (h *Handler) lookup(w http.ResponseWriter, req *http.Request) (*brokerv1alpha1.Reservation, bool) {
+	agent, ok := h.authenticate(w, req)
+	if !ok {
+		return nil, false
+	}
+
+	reservation := &brokerv1alpha1.Reservation{}
+	key := types.NamespacedName{Name: req.PathValue("name"), Namespace: h.Namespace}
+	if err := h.Client.Get(req.Context(), key, reservation); err != nil {
+		writeAPIError(w, err)
+		return nil, false
+	}
+	if agent != nil {
+		if err := agent.AuthorizeRequesterID(reservation.Spec.RequesterID); err != nil {
+			writeError(w, identity.HTTPStatus(err), err.Error())
+			return nil, false
+		}
+	}
+	return reservation, true
+}
+
+// phaseOf is a reservation's phase, Pending before the reconciler set one
+func phaseOf(status *brokerv1alpha1.ReservationStatus) brokerv1alpha1.ReservationPhase {
+	if status.Phase == "" {
+		return brokerv1alpha1.ReservationPhasePending
+	}
+	return status.Phase
+}
+
+// isTerminal reports whether a reservation will not change phase anymore
+func isTerminal(phase brokerv1alpha1.ReservationPhase) bool {
+	switch phase {
+	case brokerv1alpha1.ReservationPhaseFailed, brokerv1alpha1.ReservationPhaseReleased,
+		brokerv1alpha1.ReservationPhasePreempted:
+		return true
+	}
+	return false
+}
+
+// writeAPIError answers with the status code matching an error of the
+// Kubernetes API or of a call that does not apply
+func writeAPIError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, errWrongPhase):
+		writeError(w, http.StatusConflict, err.Error())
+	case apierrors.IsNotFound(err):
+		writeError(w, http.StatusNotFound, "reservation not found")
+	case apierrors.IsAlreadyExists(err), apierrors.IsConflict(err):
+		writeError(w, http.StatusConflict, err.Error())
+	case apierrors.IsInvalid(err), apierrors.IsBadRequest(err), apierrors.IsForbidden(err):
+		// Forbidden is how the validating webhook rejects a reservation
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+	default:
+		logger.Error(err, "Reservation API call failed")
+		writeError(w, http.StatusInternalServerError, "internal error")
+	}
+}
+
+// writeJSON answers with a JSON body
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		logger.Error(err, "Failed to write response")
+	}
+}
+
+// writeError answers with a JSON error message
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
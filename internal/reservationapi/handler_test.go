@@ -0,0 +1,263 @@
+package reservationapi
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/identity"
+)
+
+// newHandler serves a Reserved and an Active reservation of cluster-a and an
+// Active one of cluster-b, accepting client certificates
+func newHandler(t *testing.T) (*Handler, client.Client) {
+	scheme := runtime.NewScheme()
+	if err := brokerv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	reservation := func(name, requesterID string, phase brokerv1alpha1.ReservationPhase) *brokerv1alpha1.Reservation {
+		return &brokerv1alpha1.Reservation{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: brokerv1alpha1.ReservationSpec{
+				RequesterID:        requesterID,
+				RequestedResources: brokerv1alpha1.RequestedResourceQuantities{CPU: resource.MustParse("1")},
+			},
+			Status: brokerv1alpha1.ReservationStatus{Phase: phase},
+		}
+	}
+	objects := []client.Object{
+		reservation("reserved", "cluster-a", brokerv1alpha1.ReservationPhaseReserved),
+		reservation("active", "cluster-a", brokerv1alpha1.ReservationPhaseActive),
+		reservation("theirs", "cluster-b", brokerv1alpha1.ReservationPhaseActive),
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(objects...).
+		WithStatusSubresource(&brokerv1alpha1.Reservation{}).
+		Build()
+	return &Handler{
+		Client:        c,
+		Namespace:     "default",
+		Authenticator: &identity.Authenticator{ClientCertificates: true},
+	}, c
+}
+
+// serve sends a call to the handler, as agent if set
+func serve(handler *Handler, method, path, agent, body string) *httptest.ResponseRecorder {
+	mux := http.NewServeMux()
+	handler.Register(mux)
+
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if agent != "" {
+		req.TLS = &tls.ConnectionState{
+			VerifiedChains: [][]*x509.Certificate{{{Subject: pkix.Name{CommonName: agent}}}},
+		}
+	}
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestOwnership(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		agent      string
+		wantStatus int
+	}{
+		{name: "get without credentials", method: http.MethodGet, path: BasePath + "/reserved",
+			wantStatus: http.StatusUnauthorized},
+		{name: "get own", method: http.MethodGet, path: BasePath + "/reserved", agent: "cluster-a",
+			wantStatus: http.StatusOK},
+		{name: "get another requester's", method: http.MethodGet, path: BasePath + "/theirs", agent: "cluster-a",
+			wantStatus: http.StatusForbidden},
+		{name: "get missing", method: http.MethodGet, path: BasePath + "/missing", agent: "cluster-a",
+			wantStatus: http.StatusNotFound},
+		{name: "cancel without credentials", method: http.MethodDelete, path: BasePath + "/reserved",
+			wantStatus: http.StatusUnauthorized},
+		{name: "cancel own", method: http.MethodDelete, path: BasePath + "/reserved", agent: "cluster-a",
+			wantStatus: http.StatusAccepted},
+		{name: "cancel another requester's", method: http.MethodDelete, path: BasePath + "/theirs", agent: "cluster-a",
+			wantStatus: http.StatusForbidden},
+		{name: "activate without credentials", method: http.MethodPost, path: BasePath + "/reserved/activate",
+			wantStatus: http.StatusUnauthorized},
+		{name: "activate own", method: http.MethodPost, path: BasePath + "/reserved/activate", agent: "cluster-a",
+			wantStatus: http.StatusOK},
+		{name: "activate another requester's", method: http.MethodPost, path: BasePath + "/theirs/activate",
+			agent: "cluster-a", wantStatus: http.StatusForbidden},
+		{name: "release without credentials", method: http.MethodPost, path: BasePath + "/active/release",
+			wantStatus: http.StatusUnauthorized},
+		{name: "release own", method: http.MethodPost, path: BasePath + "/active/release", agent: "cluster-a",
+			wantStatus: http.StatusOK},
+		{name: "release another requester's", method: http.MethodPost, path: BasePath + "/theirs/release",
+			agent: "cluster-a", wantStatus: http.StatusForbidden},
+		{name: "heartbeat without credentials", method: http.MethodPost, path: BasePath + "/active/heartbeat",
+			wantStatus: http.StatusUnauthorized},
+		{name: "heartbeat own", method: http.MethodPost, path: BasePath + "/active/heartbeat", agent: "cluster-a",
+			wantStatus: http.StatusOK},
+		{name: "heartbeat another requester's", method: http.MethodPost, path: BasePath + "/theirs/heartbeat",
+			agent: "cluster-a", wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, c := newHandler(t)
+			before := &brokerv1alpha1.ReservationList{}
+			if err := c.List(context.Background(), before); err != nil {
+				t.Fatal(err)
+			}
+
+			rec := serve(handler, tt.method, tt.path, tt.agent, "")
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus < http.StatusBadRequest {
+				return
+			}
+
+			// A rejected call changes nothing
+			after := &brokerv1alpha1.ReservationList{}
+			if err := c.List(context.Background(), after); err != nil {
+				t.Fatal(err)
+			}
+			if len(after.Items) != len(before.Items) {
+				t.Fatalf("rejected call left %d reservations, want %d", len(after.Items), len(before.Items))
+			}
+			for i := range after.Items {
+				if after.Items[i].ResourceVersion != before.Items[i].ResourceVersion {
+					t.Errorf("rejected call changed reservation %s", after.Items[i].Name)
+				}
+			}
+		})
+	}
+}
+
+func TestCreate(t *testing.T) {
+	tests := []struct {
+		name            string
+		agent           string
+		body            string
+		wantStatus      int
+		wantRequesterID string
+	}{
+		{
+			name:       "without credentials",
+			body:       `{"name": "new", "spec": {"requestedResources": {"cpu": "1"}}}`,
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:            "requesterID defaults to the caller",
+			agent:           "cluster-a",
+			body:            `{"name": "new", "spec": {"requestedResources": {"cpu": "1"}}}`,
+			wantStatus:      http.StatusCreated,
+			wantRequesterID: "cluster-a",
+		},
+		{
+			name:            "own requesterID",
+			agent:           "cluster-a",
+			body:            `{"name": "new", "spec": {"requesterID": "cluster-a", "requestedResources": {"cpu": "1"}}}`,
+			wantStatus:      http.StatusCreated,
+			wantRequesterID: "cluster-a",
+		},
+		{
+			name:       "spoofed requesterID",
+			agent:      "cluster-a",
+			body:       `{"name": "new", "spec": {"requesterID": "cluster-b", "requestedResources": {"cpu": "1"}}}`,
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "malformed request",
+			agent:      "cluster-a",
+			body:       `{"spec":`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "name taken",
+			agent:      "cluster-a",
+			body:       `{"name": "reserved", "spec": {"requestedResources": {"cpu": "1"}}}`,
+			wantStatus: http.StatusConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, c := newHandler(t)
+
+			rec := serve(handler, http.MethodPost, BasePath, tt.agent, tt.body)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+
+			created := &brokerv1alpha1.Reservation{}
+			err := c.Get(context.Background(), types.NamespacedName{Name: "new", Namespace: "default"}, created)
+			if tt.wantRequesterID == "" {
+				if !apierrors.IsNotFound(err) {
+					t.Errorf("rejected create stored a reservation: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if created.Spec.RequesterID != tt.wantRequesterID {
+				t.Errorf("requesterID = %q, want %q", created.Spec.RequesterID, tt.wantRequesterID)
+			}
+		})
+	}
+}
+
+func TestSetCondition(t *testing.T) {
+	handler, c := newHandler(t)
+
+	rec := serve(handler, http.MethodPost, BasePath+"/reserved/activate", "cluster-a",
+		`{"reason": "PeeringReady", "message": "peering established"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("activate status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	stored := &brokerv1alpha1.Reservation{}
+	if err := c.Get(context.Background(), types.NamespacedName{Name: "reserved", Namespace: "default"}, stored); err != nil {
+		t.Fatal(err)
+	}
+	condition := meta.FindStatusCondition(stored.Status.Conditions, brokerv1alpha1.ReservationConditionRequesterActive)
+	if condition == nil || condition.Status != metav1.ConditionTrue || condition.Reason != "PeeringReady" {
+		t.Errorf("RequesterActive condition = %+v, want True with reason PeeringReady", condition)
+	}
+
+	// Activating an Active reservation has already taken effect
+	if rec := serve(handler, http.MethodPost, BasePath+"/active/activate", "cluster-a", ""); rec.Code != http.StatusOK {
+		t.Errorf("repeated activate status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	// Releasing a Reserved reservation does not apply
+	if rec := serve(handler, http.MethodPost, BasePath+"/reserved/release", "cluster-a", ""); rec.Code != http.StatusConflict {
+		t.Errorf("release of a Reserved reservation status = %d, want %d", rec.Code, http.StatusConflict)
+	}
+	// Heartbeats only apply to Active reservations
+	if rec := serve(handler, http.MethodPost, BasePath+"/reserved/heartbeat", "cluster-a", ""); rec.Code != http.StatusConflict {
+		t.Errorf("heartbeat of a Reserved reservation status = %d, want %d", rec.Code, http.StatusConflict)
+	}
+
+	rec = serve(handler, http.MethodPost, BasePath+"/active/heartbeat", "cluster-a", "")
+	answered := &brokerv1alpha1.Reservation{}
+	if err := json.Unmarshal(rec.Body.Bytes(), answered); err != nil {
+		t.Fatalf("response is not a Reservation: %v", err)
+	}
+	if answered.Status.RequesterHeartbeatTime == nil {
+		t.Error("heartbeat did not set requesterHeartbeatTime")
+	}
+}
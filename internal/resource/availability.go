@@ -1,6 +1,7 @@
 package resource
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 
 	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
@@ -20,41 +21,23 @@ func CalculateAvailable(
 	return available
 }
 
-// UpdateAvailableResources recalculates and updates the Available field in ResourceMetrics
+// UpdateAvailableResources recalculates and updates the Available field in ResourceMetrics.
+// Every resource the cluster advertises as allocatable (CPU, memory, GPU,
+// storage and extended resources) gets an Available entry; a resource missing
+// from Allocated or Reserved counts as zero.
 func UpdateAvailableResources(resources *brokerv1alpha1.ResourceMetrics) {
-	// Calculate CPU
-	reservedCPU := resource.NewQuantity(0, resource.DecimalSI)
+	allocatable := ToList(resources.Allocatable)
+	allocated := ToList(resources.Allocated)
+	reserved := corev1.ResourceList{}
 	if resources.Reserved != nil {
-		reservedCPU = &resources.Reserved.CPU
+		reserved = ToList(*resources.Reserved)
 	}
-	resources.Available.CPU = CalculateAvailable(
-		resources.Allocatable.CPU,
-		resources.Allocated.CPU,
-		reservedCPU,
-	)
 
-	// Calculate Memory
-	reservedMemory := resource.NewQuantity(0, resource.BinarySI)
-	if resources.Reserved != nil {
-		reservedMemory = &resources.Reserved.Memory
+	available := corev1.ResourceList{}
+	for name, allocatableQuantity := range allocatable {
+		reservedQuantity := reserved[name]
+		available[name] = CalculateAvailable(allocatableQuantity, allocated[name], &reservedQuantity)
 	}
-	resources.Available.Memory = CalculateAvailable(
-		resources.Allocatable.Memory,
-		resources.Allocated.Memory,
-		reservedMemory,
-	)
 
-	// Calculate GPU if present
-	if resources.Allocatable.GPU != nil {
-		reservedGPU := resource.NewQuantity(0, resource.DecimalSI)
-		if resources.Reserved != nil && resources.Reserved.GPU != nil {
-			reservedGPU = resources.Reserved.GPU
-		}
-		availableGPU := CalculateAvailable(
-			*resources.Allocatable.GPU,
-			*resources.Allocated.GPU,
-			reservedGPU,
-		)
-		resources.Available.GPU = &availableGPU
-	}
+	resources.Available = FromList(available)
 }
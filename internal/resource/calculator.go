@@ -3,47 +3,56 @@ package resource
 import (
 	"fmt"
 
-	"k8s.io/apimachinery/pkg/api/resource"
+	corev1 "k8s.io/api/core/v1"
 
 	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
 )
 
-// CanReserve checks if a cluster has enough resources for a reservation
+// CanReserve checks if a cluster has enough resources for a reservation.
+// Every requested resource is checked; requesting a resource the cluster
+// does not advertise never fits.
 func CanReserve(
 	clusterAdv *brokerv1alpha1.ClusterAdvertisement,
-	requestedCPU, requestedMemory resource.Quantity,
+	requested brokerv1alpha1.RequestedResourceQuantities,
 ) bool {
-	available := clusterAdv.Spec.Resources.Available
-
-	// Check CPU
-	if available.CPU.Cmp(requestedCPU) < 0 {
-		return false
-	}
+	return Fits(ToList(clusterAdv.Spec.Resources.Available), RequestedList(requested))
+}
 
-	// Check Memory
-	if available.Memory.Cmp(requestedMemory) < 0 {
-		return false
+// Fits reports whether requested fits into available. Requested resources with
+// a zero quantity are ignored; any other resource missing from available does not fit.
+func Fits(available, requested corev1.ResourceList) bool {
+	for name, quantity := range requested {
+		if quantity.Sign() <= 0 {
+			continue
+		}
+		availableQuantity, ok := available[name]
+		if !ok || availableQuantity.Cmp(quantity) < 0 {
+			return false
+		}
 	}
-
 	return true
 }
 
+// Equal reports whether two lists hold the same quantities, treating a missing
+// resource as zero
+func Equal(a, b corev1.ResourceList) bool {
+	return Fits(a, b) && Fits(b, a)
+}
+
 // AddReservation adds reserved resources to a cluster advertisement
 func AddReservation(
 	clusterAdv *brokerv1alpha1.ClusterAdvertisement,
-	cpuToReserve, memoryToReserve resource.Quantity,
+	toReserve brokerv1alpha1.RequestedResourceQuantities,
 ) error {
-	// Initialize Reserved if nil
-	if clusterAdv.Spec.Resources.Reserved == nil {
-		clusterAdv.Spec.Resources.Reserved = &brokerv1alpha1.ResourceQuantities{
-			CPU:    *resource.NewQuantity(0, resource.DecimalSI),
-			Memory: *resource.NewQuantity(0, resource.BinarySI),
-		}
+	reserved := corev1.ResourceList{}
+	if clusterAdv.Spec.Resources.Reserved != nil {
+		reserved = ToList(*clusterAdv.Spec.Resources.Reserved)
 	}
 
 	// Add to reserved
-	clusterAdv.Spec.Resources.Reserved.CPU.Add(cpuToReserve)
-	clusterAdv.Spec.Resources.Reserved.Memory.Add(memoryToReserve)
+	AddTo(reserved, RequestedList(toReserve))
+	updated := FromList(reserved)
+	clusterAdv.Spec.Resources.Reserved = &updated
 
 	// Recalculate available using single source of truth
 	UpdateAvailableResources(&clusterAdv.Spec.Resources)
@@ -54,18 +63,50 @@ func AddReservation(
 // RemoveReservation removes reserved resources from a cluster advertisement
 func RemoveReservation(
 	clusterAdv *brokerv1alpha1.ClusterAdvertisement,
-	cpuToRelease, memoryToRelease resource.Quantity,
+	toRelease brokerv1alpha1.RequestedResourceQuantities,
 ) error {
 	if clusterAdv.Spec.Resources.Reserved == nil {
 		return fmt.Errorf("no reserved resources to release")
 	}
+	reserved := ToList(*clusterAdv.Spec.Resources.Reserved)
 
 	// Subtract from reserved
-	clusterAdv.Spec.Resources.Reserved.CPU.Sub(cpuToRelease)
-	clusterAdv.Spec.Resources.Reserved.Memory.Sub(memoryToRelease)
+	SubFrom(reserved, RequestedList(toRelease))
+	updated := FromList(reserved)
+	clusterAdv.Spec.Resources.Reserved = &updated
 
 	// Recalculate available using single source of truth
 	UpdateAvailableResources(&clusterAdv.Spec.Resources)
 
 	return nil
 }
+
+// AddTo adds every non-zero quantity of delta to total, creating missing entries
+func AddTo(total, delta corev1.ResourceList) {
+	for name, quantity := range delta {
+		if quantity.IsZero() {
+			continue
+		}
+		sum := total[name]
+		sum.Add(quantity)
+		total[name] = sum
+	}
+}
+
+// SubFrom subtracts delta from total. Resources total does not track are left untracked.
+func SubFrom(total, delta corev1.ResourceList) {
+	for name, quantity := range delta {
+		difference, ok := total[name]
+		if !ok {
+			continue
+		}
+		difference.Sub(quantity)
+		total[name] = difference
+	}
+}
+
+// FormatRequested renders requested quantities for logs and status messages,
+// e.g. "cpu=2, memory=4Gi, gpu=1"
+func FormatRequested(requested brokerv1alpha1.RequestedResourceQuantities) string {
+	return FormatList(RequestedList(requested))
+}
@@ -0,0 +1,176 @@
+package resource
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+)
+
+func TestFits(t *testing.T) {
+	available := list(map[corev1.ResourceName]string{
+		ResourceCPU: "4", ResourceMemory: "8Gi", ResourceGPU: "1", "nvidia.com/mig-1g.5gb": "2",
+	})
+
+	tests := []struct {
+		name      string
+		requested corev1.ResourceList
+		want      bool
+	}{
+		{name: "fits", requested: list(map[corev1.ResourceName]string{ResourceCPU: "4", ResourceMemory: "8Gi"}), want: true},
+		{name: "too much cpu", requested: list(map[corev1.ResourceName]string{ResourceCPU: "4500m"})},
+		{name: "gpu fits", requested: list(map[corev1.ResourceName]string{ResourceGPU: "1"}), want: true},
+		{name: "too many gpus", requested: list(map[corev1.ResourceName]string{ResourceGPU: "2"})},
+		{
+			name:      "extended resource fits",
+			requested: list(map[corev1.ResourceName]string{"nvidia.com/mig-1g.5gb": "2"}),
+			want:      true,
+		},
+		{name: "extended resource exceeded", requested: list(map[corev1.ResourceName]string{"nvidia.com/mig-1g.5gb": "3"})},
+		{name: "resource not advertised", requested: list(map[corev1.ResourceName]string{"example.com/fpga": "1"})},
+		{
+			name:      "zero quantity of a resource not advertised",
+			requested: list(map[corev1.ResourceName]string{ResourceCPU: "1", "example.com/fpga": "0"}),
+			want:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Fits(available, tt.requested); got != tt.want {
+				t.Errorf("Fits() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEqual(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b corev1.ResourceList
+		want bool
+	}{
+		{
+			name: "same quantities in different formats",
+			a:    list(map[corev1.ResourceName]string{ResourceCPU: "1", ResourceMemory: "1Gi"}),
+			b:    list(map[corev1.ResourceName]string{ResourceCPU: "1000m", ResourceMemory: "1024Mi"}),
+			want: true,
+		},
+		{
+			name: "missing resource counts as zero",
+			a:    list(map[corev1.ResourceName]string{ResourceCPU: "1", ResourceGPU: "0"}),
+			b:    list(map[corev1.ResourceName]string{ResourceCPU: "1"}),
+			want: true,
+		},
+		{
+			name: "extended resource differs",
+			a:    list(map[corev1.ResourceName]string{ResourceCPU: "1", "example.com/fpga": "1"}),
+			b:    list(map[corev1.ResourceName]string{ResourceCPU: "1"}),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Equal(tt.a, tt.b); got != tt.want {
+				t.Errorf("Equal() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddToSubFrom(t *testing.T) {
+	total := list(map[corev1.ResourceName]string{ResourceCPU: "2", ResourceGPU: "1"})
+
+	AddTo(total, list(map[corev1.ResourceName]string{ResourceCPU: "1", ResourceGPU: "0", "example.com/fpga": "2"}))
+	want := list(map[corev1.ResourceName]string{ResourceCPU: "3", ResourceGPU: "1", "example.com/fpga": "2"})
+	if len(total) != len(want) || !Equal(total, want) {
+		t.Fatalf("AddTo() = %s, want %s", FormatList(total), FormatList(want))
+	}
+
+	// Resources total does not track stay untracked
+	SubFrom(total, list(map[corev1.ResourceName]string{ResourceCPU: "1", "example.com/fpga": "2", ResourceStorage: "1Gi"}))
+	want = list(map[corev1.ResourceName]string{ResourceCPU: "2", ResourceGPU: "1", "example.com/fpga": "0"})
+	if _, ok := total[ResourceStorage]; ok {
+		t.Errorf("SubFrom() added untracked storage: %s", FormatList(total))
+	}
+	if len(total) != len(want) || !Equal(total, want) {
+		t.Errorf("SubFrom() = %s, want %s", FormatList(total), FormatList(want))
+	}
+}
+
+func TestUpdateAvailableResources(t *testing.T) {
+	resources := &brokerv1alpha1.ResourceMetrics{
+		Allocatable: brokerv1alpha1.ResourceQuantities{
+			CPU:      resource.MustParse("16"),
+			Memory:   resource.MustParse("64Gi"),
+			GPU:      ptrTo(resource.MustParse("4")),
+			Extended: list(map[corev1.ResourceName]string{"nvidia.com/mig-1g.5gb": "7"}),
+		},
+		Allocated: brokerv1alpha1.ResourceQuantities{
+			CPU:    resource.MustParse("4"),
+			Memory: resource.MustParse("16Gi"),
+			GPU:    ptrTo(resource.MustParse("1")),
+		},
+		Reserved: &brokerv1alpha1.ResourceQuantities{
+			CPU:      resource.MustParse("2"),
+			Memory:   resource.MustParse("8Gi"),
+			Extended: list(map[corev1.ResourceName]string{"nvidia.com/mig-1g.5gb": "3"}),
+		},
+	}
+
+	UpdateAvailableResources(resources)
+
+	got := ToList(resources.Available)
+	want := list(map[corev1.ResourceName]string{
+		ResourceCPU: "10", ResourceMemory: "40Gi", ResourceGPU: "3", "nvidia.com/mig-1g.5gb": "4",
+	})
+	if len(got) != len(want) || !Equal(got, want) {
+		t.Errorf("Available = %s, want %s", FormatList(got), FormatList(want))
+	}
+}
+
+func TestAddRemoveReservation(t *testing.T) {
+	cluster := &brokerv1alpha1.ClusterAdvertisement{
+		Spec: brokerv1alpha1.ClusterAdvertisementSpec{
+			Resources: brokerv1alpha1.ResourceMetrics{
+				Allocatable: brokerv1alpha1.ResourceQuantities{
+					CPU:      resource.MustParse("8"),
+					Memory:   resource.MustParse("16Gi"),
+					Extended: list(map[corev1.ResourceName]string{"example.com/fpga": "2"}),
+				},
+			},
+		},
+	}
+	UpdateAvailableResources(&cluster.Spec.Resources)
+	request := brokerv1alpha1.RequestedResourceQuantities{
+		CPU:      resource.MustParse("2"),
+		Memory:   resource.MustParse("4Gi"),
+		Extended: list(map[corev1.ResourceName]string{"example.com/fpga": "2"}),
+	}
+
+	if !CanReserve(cluster, request) {
+		t.Fatal("CanReserve() = false on an empty cluster")
+	}
+	if err := AddReservation(cluster, request); err != nil {
+		t.Fatal(err)
+	}
+	if CanReserve(cluster, request) {
+		t.Error("CanReserve() = true once every FPGA is reserved")
+	}
+	want := list(map[corev1.ResourceName]string{ResourceCPU: "6", ResourceMemory: "12Gi", "example.com/fpga": "0"})
+	if got := ToList(cluster.Spec.Resources.Available); !Equal(got, want) {
+		t.Errorf("Available after AddReservation() = %s, want %s", FormatList(got), FormatList(want))
+	}
+
+	if err := RemoveReservation(cluster, request); err != nil {
+		t.Fatal(err)
+	}
+	if got := ToList(*cluster.Spec.Resources.Reserved); !Equal(got, corev1.ResourceList{}) {
+		t.Errorf("Reserved after RemoveReservation() = %s, want nothing", FormatList(got))
+	}
+	if err := RemoveReservation(&brokerv1alpha1.ClusterAdvertisement{}, request); err == nil {
+		t.Error("RemoveReservation() without reserved resources succeeded")
+	}
+}
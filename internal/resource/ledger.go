@@ -0,0 +1,95 @@
+package resource
+
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+)
+
+// HoldsResources reports whether a reservation in the given phase has
+// resources locked on its target cluster
+func HoldsResources(phase brokerv1alpha1.ReservationPhase) bool {
+	return phase == brokerv1alpha1.ReservationPhaseReserved ||
+		phase == brokerv1alpha1.ReservationPhaseActive
+}
+
+// LockedResources returns the quantities a reservation holds on its target cluster.
+// Reservations locked before the quantities were recorded in status fall back to the spec.
+func LockedResources(reservation *brokerv1alpha1.Reservation) brokerv1alpha1.RequestedResourceQuantities {
+	if reservation.Status.LockedResources != nil {
+		return *reservation.Status.LockedResources
+	}
+	return reservation.Spec.RequestedResources
+}
+
+// Holdings returns where a reservation holds resources and how much: one entry
+// per fragment of a split reservation, otherwise its target cluster
+func Holdings(reservation *brokerv1alpha1.Reservation) []brokerv1alpha1.ReservationFragment {
+	if len(reservation.Status.Fragments) > 0 {
+		return reservation.Status.Fragments
+	}
+	return []brokerv1alpha1.ReservationFragment{{
+		ClusterID:       reservation.Spec.TargetClusterID,
+		LockedResources: LockedResources(reservation),
+	}}
+}
+
+// Ledger sums the resources held on a cluster by the given reservations and
+// reservation group members. This is what the cluster's Reserved counter should be.
+func Ledger(
+	clusterID string,
+	reservations []brokerv1alpha1.Reservation,
+	groups []brokerv1alpha1.ReservationGroup,
+) corev1.ResourceList {
+	ledger := corev1.ResourceList{}
+	for i := range reservations {
+		reservation := &reservations[i]
+		if !HoldsResources(reservation.Status.Phase) {
+			continue
+		}
+		for _, holding := range Holdings(reservation) {
+			if holding.ClusterID == clusterID {
+				AddTo(ledger, RequestedList(holding.LockedResources))
+			}
+		}
+	}
+	for i := range groups {
+		group := &groups[i]
+		if !HoldsResources(group.Status.Phase) {
+			continue
+		}
+		for _, placement := range group.Status.Placements {
+			if placement.ClusterID == clusterID {
+				AddTo(ledger, RequestedList(placement.LockedResources))
+			}
+		}
+	}
+	return ledger
+}
+
+// Drift returns stored - ledger for every resource where the stored Reserved
+// counter disagrees with the ledger. An empty result means no drift.
+func Drift(stored *brokerv1alpha1.ResourceQuantities, ledger corev1.ResourceList) corev1.ResourceList {
+	storedList := corev1.ResourceList{}
+	if stored != nil {
+		storedList = ToList(*stored)
+	}
+
+	drift := corev1.ResourceList{}
+	for name, quantity := range storedList {
+		difference := quantity.DeepCopy()
+		difference.Sub(ledger[name])
+		if !difference.IsZero() {
+			drift[name] = difference
+		}
+	}
+	for name, quantity := range ledger {
+		if _, tracked := storedList[name]; tracked || quantity.IsZero() {
+			continue
+		}
+		difference := quantity.DeepCopy()
+		difference.Neg()
+		drift[name] = difference
+	}
+	return drift
+}
@@ -0,0 +1,162 @@
+package resource
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+)
+
+func requested(cpu, memory string) brokerv1alpha1.RequestedResourceQuantities {
+	return brokerv1alpha1.RequestedResourceQuantities{CPU: resource.MustParse(cpu), Memory: resource.MustParse(memory)}
+}
+
+func TestLedger(t *testing.T) {
+	locked := requested("1", "1Gi")
+	reservations := []brokerv1alpha1.Reservation{
+		{
+			Spec:   brokerv1alpha1.ReservationSpec{TargetClusterID: "cluster-a", RequestedResources: requested("2", "2Gi")},
+			Status: brokerv1alpha1.ReservationStatus{Phase: brokerv1alpha1.ReservationPhaseReserved},
+		},
+		{
+			// Resized: what is locked counts, not the spec
+			Spec: brokerv1alpha1.ReservationSpec{TargetClusterID: "cluster-a", RequestedResources: requested("4", "4Gi")},
+			Status: brokerv1alpha1.ReservationStatus{
+				Phase:           brokerv1alpha1.ReservationPhaseActive,
+				LockedResources: &locked,
+			},
+		},
+		{
+			Spec:   brokerv1alpha1.ReservationSpec{TargetClusterID: "cluster-a", RequestedResources: requested("8", "8Gi")},
+			Status: brokerv1alpha1.ReservationStatus{Phase: brokerv1alpha1.ReservationPhaseReleased},
+		},
+		{
+			Spec:   brokerv1alpha1.ReservationSpec{TargetClusterID: "cluster-b", RequestedResources: requested("8", "8Gi")},
+			Status: brokerv1alpha1.ReservationStatus{Phase: brokerv1alpha1.ReservationPhaseReserved},
+		},
+		{
+			Spec: brokerv1alpha1.ReservationSpec{RequestedResources: requested("6", "6Gi")},
+			Status: brokerv1alpha1.ReservationStatus{
+				Phase: brokerv1alpha1.ReservationPhaseReserved,
+				Fragments: []brokerv1alpha1.ReservationFragment{
+					{ClusterID: "cluster-a", LockedResources: requested("3", "3Gi")},
+					{ClusterID: "cluster-b", LockedResources: requested("3", "3Gi")},
+				},
+			},
+		},
+	}
+	groups := []brokerv1alpha1.ReservationGroup{
+		{
+			Status: brokerv1alpha1.ReservationGroupStatus{
+				Phase: brokerv1alpha1.ReservationPhaseReserved,
+				Placements: []brokerv1alpha1.MemberPlacement{
+					{ClusterID: "cluster-a", LockedResources: requested("500m", "512Mi")},
+				},
+			},
+		},
+		{
+			Status: brokerv1alpha1.ReservationGroupStatus{
+				Phase: brokerv1alpha1.ReservationPhaseFailed,
+				Placements: []brokerv1alpha1.MemberPlacement{
+					{ClusterID: "cluster-a", LockedResources: requested("8", "8Gi")},
+				},
+			},
+		},
+	}
+
+	got := Ledger("cluster-a", reservations, groups)
+	want := RequestedList(requested("6500m", "6656Mi"))
+	if !Equal(got, want) {
+		t.Errorf("Ledger() = %s, want %s", FormatList(got), FormatList(want))
+	}
+
+	if empty := Ledger("cluster-c", reservations, groups); !Equal(empty, corev1.ResourceList{}) {
+		t.Errorf("Ledger() of an unused cluster = %s, want nothing", FormatList(empty))
+	}
+}
+
+func TestDrift(t *testing.T) {
+	quantities := func(cpu, memory string) *brokerv1alpha1.ResourceQuantities {
+		return &brokerv1alpha1.ResourceQuantities{CPU: resource.MustParse(cpu), Memory: resource.MustParse(memory)}
+	}
+
+	tests := []struct {
+		name   string
+		stored *brokerv1alpha1.ResourceQuantities
+		ledger corev1.ResourceList
+		want   corev1.ResourceList
+	}{
+		{
+			name:   "in sync",
+			stored: quantities("2", "4Gi"),
+			ledger: RequestedList(requested("2", "4Gi")),
+			want:   corev1.ResourceList{},
+		},
+		{
+			name:   "equal quantities in different formats",
+			stored: quantities("2000m", "4096Mi"),
+			ledger: RequestedList(requested("2", "4Gi")),
+			want:   corev1.ResourceList{},
+		},
+		{
+			name:   "counter above the ledger",
+			stored: quantities("3", "4Gi"),
+			ledger: RequestedList(requested("2", "4Gi")),
+			want:   list(map[corev1.ResourceName]string{ResourceCPU: "1"}),
+		},
+		{
+			name:   "counter below the ledger",
+			stored: quantities("2", "3Gi"),
+			ledger: RequestedList(requested("2", "4Gi")),
+			want:   list(map[corev1.ResourceName]string{ResourceMemory: "-1Gi"}),
+		},
+		{
+			name:   "no counter stored",
+			stored: nil,
+			ledger: RequestedList(requested("2", "0")),
+			want:   list(map[corev1.ResourceName]string{ResourceCPU: "-2"}),
+		},
+		{
+			name:   "no counter and an empty ledger",
+			stored: nil,
+			ledger: corev1.ResourceList{},
+			want:   corev1.ResourceList{},
+		},
+		{
+			name:   "resource missing from the counter",
+			stored: quantities("2", "4Gi"),
+			ledger: list(map[corev1.ResourceName]string{ResourceCPU: "2", ResourceMemory: "4Gi", ResourceGPU: "1"}),
+			want:   list(map[corev1.ResourceName]string{ResourceGPU: "-1"}),
+		},
+		{
+			name: "resource missing from the ledger",
+			stored: &brokerv1alpha1.ResourceQuantities{
+				CPU:    resource.MustParse("2"),
+				Memory: resource.MustParse("4Gi"),
+				GPU:    ptrTo(resource.MustParse("1")),
+			},
+			ledger: RequestedList(requested("2", "4Gi")),
+			want:   list(map[corev1.ResourceName]string{ResourceGPU: "1"}),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Drift(tt.stored, tt.ledger)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Drift() = %s, want %s", FormatList(got), FormatList(tt.want))
+			}
+			for name, quantity := range tt.want {
+				if drift, ok := got[name]; !ok || drift.Cmp(quantity) != 0 {
+					t.Errorf("Drift()[%s] = %s, want %s", name, drift.String(), quantity.String())
+				}
+			}
+		})
+	}
+}
+
+func ptrTo(quantity resource.Quantity) *resource.Quantity {
+	return &quantity
+}
@@ -0,0 +1,139 @@
+package resource
+
+import (
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+)
+
+// Names of the fixed resource dimensions when flattened into a ResourceList.
+// Every other name comes from the Extended map.
+const (
+	ResourceCPU     = corev1.ResourceCPU
+	ResourceMemory  = corev1.ResourceMemory
+	ResourceGPU     = corev1.ResourceName("gpu")
+	ResourceStorage = corev1.ResourceStorage
+)
+
+// IsFixedResource reports whether name is one of the fixed CPU/Memory/GPU/Storage
+// dimensions, which must not be repeated in an Extended map
+func IsFixedResource(name corev1.ResourceName) bool {
+	switch name {
+	case ResourceCPU, ResourceMemory, ResourceGPU, ResourceStorage:
+		return true
+	}
+	return false
+}
+
+// ToList flattens advertised quantities into a single ResourceList containing
+// CPU, memory, the optional GPU and storage, and every extended resource
+func ToList(quantities brokerv1alpha1.ResourceQuantities) corev1.ResourceList {
+	return flatten(quantities.CPU, quantities.Memory, quantities.GPU, quantities.Storage, quantities.Extended)
+}
+
+// RequestedList flattens requested quantities into a single ResourceList
+func RequestedList(requested brokerv1alpha1.RequestedResourceQuantities) corev1.ResourceList {
+	return flatten(requested.CPU, requested.Memory, requested.GPU, requested.Storage, requested.Extended)
+}
+
+func flatten(
+	cpu, memory resource.Quantity,
+	gpu, storage *resource.Quantity,
+	extended corev1.ResourceList,
+) corev1.ResourceList {
+	list := corev1.ResourceList{
+		ResourceCPU:    cpu.DeepCopy(),
+		ResourceMemory: memory.DeepCopy(),
+	}
+	if gpu != nil {
+		list[ResourceGPU] = gpu.DeepCopy()
+	}
+	if storage != nil {
+		list[ResourceStorage] = storage.DeepCopy()
+	}
+	for name, quantity := range extended {
+		if IsFixedResource(name) {
+			continue
+		}
+		list[name] = quantity.DeepCopy()
+	}
+	return list
+}
+
+// FromList is the inverse of ToList: it writes a ResourceList back into the
+// fixed fields and the Extended map of ResourceQuantities
+func FromList(list corev1.ResourceList) brokerv1alpha1.ResourceQuantities {
+	quantities := brokerv1alpha1.ResourceQuantities{
+		CPU:    list.Cpu().DeepCopy(),
+		Memory: list.Memory().DeepCopy(),
+	}
+	for name, quantity := range list {
+		quantity := quantity.DeepCopy()
+		switch name {
+		case ResourceCPU, ResourceMemory:
+		case ResourceGPU:
+			quantities.GPU = &quantity
+		case ResourceStorage:
+			quantities.Storage = &quantity
+		default:
+			if quantities.Extended == nil {
+				quantities.Extended = corev1.ResourceList{}
+			}
+			quantities.Extended[name] = quantity
+		}
+	}
+	return quantities
+}
+
+// RequestedFromList is FromList for requested quantities
+func RequestedFromList(list corev1.ResourceList) brokerv1alpha1.RequestedResourceQuantities {
+	quantities := FromList(list)
+	return brokerv1alpha1.RequestedResourceQuantities{
+		CPU:      quantities.CPU,
+		Memory:   quantities.Memory,
+		GPU:      quantities.GPU,
+		Storage:  quantities.Storage,
+		Extended: quantities.Extended,
+	}
+}
+
+// SortedNames returns the resource names of a list in a stable order:
+// the fixed dimensions first, then extended resources alphabetically
+func SortedNames(list corev1.ResourceList) []corev1.ResourceName {
+	names := make([]corev1.ResourceName, 0, len(list))
+	for _, name := range []corev1.ResourceName{ResourceCPU, ResourceMemory, ResourceGPU, ResourceStorage} {
+		if _, ok := list[name]; ok {
+			names = append(names, name)
+		}
+	}
+	extended := make([]string, 0, len(list))
+	for name := range list {
+		if !IsFixedResource(name) {
+			extended = append(extended, string(name))
+		}
+	}
+	sort.Strings(extended)
+	for _, name := range extended {
+		names = append(names, corev1.ResourceName(name))
+	}
+	return names
+}
+
+// FormatList renders a ResourceList for logs and status messages, e.g.
+// "cpu=2, memory=4Gi, nvidia.com/mig-1g.5gb=1". Zero quantities other than
+// CPU and memory are omitted.
+func FormatList(list corev1.ResourceList) string {
+	parts := make([]string, 0, len(list))
+	for _, name := range SortedNames(list) {
+		quantity := list[name]
+		if quantity.IsZero() && name != ResourceCPU && name != ResourceMemory {
+			continue
+		}
+		parts = append(parts, string(name)+"="+quantity.String())
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,111 @@
+package resource
+
+import (
+	"slices"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+)
+
+func TestToList(t *testing.T) {
+	tests := []struct {
+		name       string
+		quantities brokerv1alpha1.ResourceQuantities
+		want       corev1.ResourceList
+	}{
+		{
+			name:       "cpu and memory only",
+			quantities: brokerv1alpha1.ResourceQuantities{CPU: resource.MustParse("2"), Memory: resource.MustParse("4Gi")},
+			want:       list(map[corev1.ResourceName]string{ResourceCPU: "2", ResourceMemory: "4Gi"}),
+		},
+		{
+			name: "gpu, storage and extended resources",
+			quantities: brokerv1alpha1.ResourceQuantities{
+				CPU:      resource.MustParse("2"),
+				Memory:   resource.MustParse("4Gi"),
+				GPU:      ptrTo(resource.MustParse("1")),
+				Storage:  ptrTo(resource.MustParse("100Gi")),
+				Extended: list(map[corev1.ResourceName]string{"nvidia.com/mig-1g.5gb": "7"}),
+			},
+			want: list(map[corev1.ResourceName]string{
+				ResourceCPU: "2", ResourceMemory: "4Gi", ResourceGPU: "1", ResourceStorage: "100Gi",
+				"nvidia.com/mig-1g.5gb": "7",
+			}),
+		},
+		{
+			name: "fixed names in the extended map are ignored",
+			quantities: brokerv1alpha1.ResourceQuantities{
+				CPU:      resource.MustParse("2"),
+				Memory:   resource.MustParse("4Gi"),
+				Extended: list(map[corev1.ResourceName]string{ResourceCPU: "64", ResourceGPU: "8"}),
+			},
+			want: list(map[corev1.ResourceName]string{ResourceCPU: "2", ResourceMemory: "4Gi"}),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ToList(tt.quantities)
+			if len(got) != len(tt.want) || !Equal(got, tt.want) {
+				t.Fatalf("ToList() = %s, want %s", FormatList(got), FormatList(tt.want))
+			}
+
+			// FromList is the inverse
+			back := FromList(got)
+			if again := ToList(back); len(again) != len(got) || !Equal(again, got) {
+				t.Errorf("ToList(FromList()) = %s, want %s", FormatList(again), FormatList(got))
+			}
+		})
+	}
+}
+
+func TestFromList(t *testing.T) {
+	quantities := FromList(list(map[corev1.ResourceName]string{
+		ResourceCPU: "2", ResourceMemory: "4Gi", ResourceGPU: "1", "example.com/fpga": "2",
+	}))
+
+	if quantities.CPU.Cmp(resource.MustParse("2")) != 0 || quantities.Memory.Cmp(resource.MustParse("4Gi")) != 0 {
+		t.Errorf("FromList() cpu=%s memory=%s, want cpu=2 memory=4Gi",
+			quantities.CPU.String(), quantities.Memory.String())
+	}
+	if quantities.GPU == nil || quantities.GPU.Cmp(resource.MustParse("1")) != 0 {
+		t.Errorf("FromList() gpu = %v, want 1", quantities.GPU)
+	}
+	if quantities.Storage != nil {
+		t.Errorf("FromList() storage = %s, want none", quantities.Storage.String())
+	}
+	fpga, ok := quantities.Extended["example.com/fpga"]
+	if len(quantities.Extended) != 1 || !ok || fpga.Cmp(resource.MustParse("2")) != 0 {
+		t.Errorf("FromList() extended = %v, want example.com/fpga=2", quantities.Extended)
+	}
+
+	requested := RequestedFromList(list(map[corev1.ResourceName]string{ResourceCPU: "1", "example.com/fpga": "1"}))
+	if got := FormatRequested(requested); got != "cpu=1, memory=0, example.com/fpga=1" {
+		t.Errorf("RequestedFromList() = %s, want cpu=1, memory=0, example.com/fpga=1", got)
+	}
+}
+
+func TestSortedNames(t *testing.T) {
+	got := SortedNames(list(map[corev1.ResourceName]string{
+		"nvidia.com/mig-1g.5gb": "1", ResourceStorage: "1", "example.com/fpga": "1",
+		ResourceGPU: "1", ResourceMemory: "1", ResourceCPU: "1",
+	}))
+	want := []corev1.ResourceName{
+		ResourceCPU, ResourceMemory, ResourceGPU, ResourceStorage, "example.com/fpga", "nvidia.com/mig-1g.5gb",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("SortedNames() = %v, want %v", got, want)
+	}
+}
+
+func TestFormatList(t *testing.T) {
+	got := FormatList(list(map[corev1.ResourceName]string{
+		ResourceCPU: "0", ResourceMemory: "4Gi", ResourceGPU: "0", "example.com/fpga": "2",
+	}))
+	if want := "cpu=0, memory=4Gi, example.com/fpga=2"; got != want {
+		t.Errorf("FormatList() = %q, want %q", got, want)
+	}
+}
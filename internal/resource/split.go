@@ -0,0 +1,60 @@
+package resource
+
+import (
+	"math/big"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// Chunks returns how many equal chunks of at least minChunk the requested
+// quantities divide into: the smallest requested/minChunk ratio over the
+// resources minChunk sets to a non-zero value. It returns 0 if minChunk sets
+// none of the requested resources.
+func Chunks(requested, minChunk corev1.ResourceList) int64 {
+	var chunks int64
+	limited := false
+	for name, chunk := range minChunk {
+		if chunk.Sign() <= 0 {
+			continue
+		}
+		quantity, ok := requested[name]
+		if !ok || quantity.Sign() <= 0 {
+			continue
+		}
+		ratio := quantity.MilliValue() / chunk.MilliValue()
+		if !limited || ratio < chunks {
+			chunks = ratio
+			limited = true
+		}
+	}
+	return chunks
+}
+
+// Share returns part/whole of every quantity in list, rounded down. CPU is
+// divided in millicores, every other resource in whole units.
+func Share(list corev1.ResourceList, part, whole int64) corev1.ResourceList {
+	share := corev1.ResourceList{}
+	for name, quantity := range list {
+		if name == ResourceCPU {
+			share[name] = *resource.NewMilliQuantity(scale(quantity.MilliValue(), part, whole), quantity.Format)
+			continue
+		}
+		share[name] = *resource.NewQuantity(scale(quantity.Value(), part, whole), quantity.Format)
+	}
+	return share
+}
+
+// Slice returns the quantities of count chunks starting after the first from
+// chunks of list divided into whole chunks. Consecutive slices add up to list exactly.
+func Slice(list corev1.ResourceList, from, count, whole int64) corev1.ResourceList {
+	slice := Share(list, from+count, whole)
+	SubFrom(slice, Share(list, from, whole))
+	return slice
+}
+
+// scale computes value*part/whole without overflowing
+func scale(value, part, whole int64) int64 {
+	product := new(big.Int).Mul(big.NewInt(value), big.NewInt(part))
+	return product.Quo(product, big.NewInt(whole)).Int64()
+}
@@ -0,0 +1,150 @@
+package resource
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func list(quantities map[corev1.ResourceName]string) corev1.ResourceList {
+	result := corev1.ResourceList{}
+	for name, quantity := range quantities {
+		result[name] = resource.MustParse(quantity)
+	}
+	return result
+}
+
+func TestChunks(t *testing.T) {
+	tests := []struct {
+		name      string
+		requested corev1.ResourceList
+		minChunk  corev1.ResourceList
+		want      int64
+	}{
+		{
+			name:      "single resource",
+			requested: list(map[corev1.ResourceName]string{ResourceCPU: "8"}),
+			minChunk:  list(map[corev1.ResourceName]string{ResourceCPU: "2"}),
+			want:      4,
+		},
+		{
+			name:      "remainder is rounded down",
+			requested: list(map[corev1.ResourceName]string{ResourceCPU: "9"}),
+			minChunk:  list(map[corev1.ResourceName]string{ResourceCPU: "2"}),
+			want:      4,
+		},
+		{
+			name:      "millicores",
+			requested: list(map[corev1.ResourceName]string{ResourceCPU: "1500m"}),
+			minChunk:  list(map[corev1.ResourceName]string{ResourceCPU: "500m"}),
+			want:      3,
+		},
+		{
+			name:      "most limiting resource wins",
+			requested: list(map[corev1.ResourceName]string{ResourceCPU: "8", ResourceMemory: "8Gi"}),
+			minChunk:  list(map[corev1.ResourceName]string{ResourceCPU: "1", ResourceMemory: "4Gi"}),
+			want:      2,
+		},
+		{
+			name:      "resources minChunk leaves out do not limit",
+			requested: list(map[corev1.ResourceName]string{ResourceCPU: "8", ResourceMemory: "1Gi"}),
+			minChunk:  list(map[corev1.ResourceName]string{ResourceCPU: "2"}),
+			want:      4,
+		},
+		{
+			name:      "zero minChunk entries are ignored",
+			requested: list(map[corev1.ResourceName]string{ResourceCPU: "8", ResourceMemory: "1Gi"}),
+			minChunk:  list(map[corev1.ResourceName]string{ResourceCPU: "2", ResourceMemory: "0"}),
+			want:      4,
+		},
+		{
+			name:      "minChunk sets no requested resource",
+			requested: list(map[corev1.ResourceName]string{ResourceCPU: "8"}),
+			minChunk:  list(map[corev1.ResourceName]string{ResourceGPU: "1"}),
+			want:      0,
+		},
+		{
+			name:      "request smaller than a chunk",
+			requested: list(map[corev1.ResourceName]string{ResourceCPU: "1"}),
+			minChunk:  list(map[corev1.ResourceName]string{ResourceCPU: "2"}),
+			want:      0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Chunks(tt.requested, tt.minChunk); got != tt.want {
+				t.Errorf("Chunks() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSlice(t *testing.T) {
+	tests := []struct {
+		name   string
+		list   corev1.ResourceList
+		whole  int64
+		counts []int64
+	}{
+		{
+			name:   "even split",
+			list:   list(map[corev1.ResourceName]string{ResourceCPU: "8", ResourceMemory: "16Gi"}),
+			whole:  4,
+			counts: []int64{1, 1, 1, 1},
+		},
+		{
+			name:   "uneven millicores",
+			list:   list(map[corev1.ResourceName]string{ResourceCPU: "1", ResourceMemory: "1000"}),
+			whole:  3,
+			counts: []int64{1, 1, 1},
+		},
+		{
+			name:   "fragments of several chunks",
+			list:   list(map[corev1.ResourceName]string{ResourceCPU: "7", ResourceMemory: "10Gi", ResourceGPU: "3"}),
+			whole:  7,
+			counts: []int64{3, 2, 2},
+		},
+		{
+			name:   "extended resources",
+			list:   list(map[corev1.ResourceName]string{ResourceCPU: "5", "nvidia.com/mig-1g.5gb": "5"}),
+			whole:  5,
+			counts: []int64{4, 1},
+		},
+		{
+			name:   "single slice is the whole list",
+			list:   list(map[corev1.ResourceName]string{ResourceCPU: "2500m", ResourceMemory: "3Gi"}),
+			whole:  5,
+			counts: []int64{5},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sum := corev1.ResourceList{}
+			from := int64(0)
+			for _, count := range tt.counts {
+				slice := Slice(tt.list, from, count, tt.whole)
+				for name, quantity := range slice {
+					if quantity.Sign() < 0 {
+						t.Errorf("Slice(%d, %d) has negative %s: %s", from, count, name, quantity.String())
+					}
+				}
+				AddTo(sum, slice)
+				from += count
+			}
+			if !Equal(sum, tt.list) {
+				t.Errorf("slices add up to %s, want %s", FormatList(sum), FormatList(tt.list))
+			}
+		})
+	}
+}
+
+func TestShare(t *testing.T) {
+	got := Share(list(map[corev1.ResourceName]string{ResourceCPU: "1", ResourceMemory: "10"}), 1, 3)
+	want := list(map[corev1.ResourceName]string{ResourceCPU: "333m", ResourceMemory: "3"})
+	if !Equal(got, want) {
+		t.Errorf("Share() = %s, want %s", FormatList(got), FormatList(want))
+	}
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/broker"
+)
+
+// log is for logging in this package.
+var clusteradvertisementlog = logf.Log.WithName("clusteradvertisement-resource")
+
+// SetupClusterAdvertisementWebhookWithManager registers the webhook for ClusterAdvertisement in the manager.
+func SetupClusterAdvertisementWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).For(&brokerv1alpha1.ClusterAdvertisement{}).
+		WithValidator(&ClusterAdvertisementCustomValidator{}).
+		Complete()
+}
+
+// NOTE: The 'path' attribute must follow a specific pattern and should not be modified directly here.
+// Modifying the path for an invalid path can cause API server errors; failing to locate the webhook.
+// +kubebuilder:webhook:path=/validate-broker-fluidos-eu-v1alpha1-clusteradvertisement,mutating=false,failurePolicy=fail,sideEffects=None,groups=broker.fluidos.eu,resources=clusteradvertisements,verbs=create;update,versions=v1alpha1,name=vclusteradvertisement-v1alpha1.kb.io,admissionReviewVersions=v1
+
+// ClusterAdvertisementCustomValidator applies the checks of the advertisement
+// ingestion endpoint to ClusterAdvertisements written through the Kubernetes API
+type ClusterAdvertisementCustomValidator struct{}
+
+var _ webhook.CustomValidator = &ClusterAdvertisementCustomValidator{}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type ClusterAdvertisement.
+func (v *ClusterAdvertisementCustomValidator) ValidateCreate(
+	_ context.Context,
+	obj runtime.Object,
+) (admission.Warnings, error) {
+	clusterAdv, ok := obj.(*brokerv1alpha1.ClusterAdvertisement)
+	if !ok {
+		return nil, fmt.Errorf("expected a ClusterAdvertisement object but got %T", obj)
+	}
+	clusteradvertisementlog.V(1).Info("Validation for ClusterAdvertisement upon creation", "name", clusterAdv.GetName())
+
+	return nil, broker.ValidateAdvertisement(&clusterAdv.Spec)
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type ClusterAdvertisement.
+// An advertisement that was already invalid when this webhook was installed
+// may still be updated, so the broker can keep maintaining its Reserved counter.
+func (v *ClusterAdvertisementCustomValidator) ValidateUpdate(
+	_ context.Context,
+	oldObj, newObj runtime.Object,
+) (admission.Warnings, error) {
+	clusterAdv, ok := newObj.(*brokerv1alpha1.ClusterAdvertisement)
+	if !ok {
+		return nil, fmt.Errorf("expected a ClusterAdvertisement object for the newObj but got %T", newObj)
+	}
+	oldClusterAdv, ok := oldObj.(*brokerv1alpha1.ClusterAdvertisement)
+	if !ok {
+		return nil, fmt.Errorf("expected a ClusterAdvertisement object for the oldObj but got %T", oldObj)
+	}
+	clusteradvertisementlog.V(1).Info("Validation for ClusterAdvertisement upon update", "name", clusterAdv.GetName())
+
+	err := broker.ValidateAdvertisement(&clusterAdv.Spec)
+	if err != nil && broker.ValidateAdvertisement(&oldClusterAdv.Spec) != nil {
+		return admission.Warnings{fmt.Sprintf("advertisement is invalid: %v", err)}, nil
+	}
+	return nil, err
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type ClusterAdvertisement.
+func (v *ClusterAdvertisementCustomValidator) ValidateDelete(
+	_ context.Context,
+	_ runtime.Object,
+) (admission.Warnings, error) {
+	return nil, nil
+}
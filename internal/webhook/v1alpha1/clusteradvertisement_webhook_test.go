@@ -0,0 +1,80 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+)
+
+var _ = Describe("ClusterAdvertisement Webhook", func() {
+	var (
+		obj       *brokerv1alpha1.ClusterAdvertisement
+		oldObj    *brokerv1alpha1.ClusterAdvertisement
+		validator ClusterAdvertisementCustomValidator
+	)
+
+	BeforeEach(func() {
+		quantities := brokerv1alpha1.ResourceQuantities{
+			CPU:    resource.MustParse("8"),
+			Memory: resource.MustParse("16Gi"),
+		}
+		obj = &brokerv1alpha1.ClusterAdvertisement{
+			Spec: brokerv1alpha1.ClusterAdvertisementSpec{
+				ClusterID: "cluster-a",
+				Resources: brokerv1alpha1.ResourceMetrics{
+					Capacity:    quantities,
+					Allocatable: quantities,
+					Allocated:   brokerv1alpha1.ResourceQuantities{CPU: resource.MustParse("2"), Memory: resource.MustParse("4Gi")},
+				},
+			},
+		}
+		oldObj = obj.DeepCopy()
+		validator = ClusterAdvertisementCustomValidator{}
+		Expect(validator).NotTo(BeNil(), "Expected validator to be initialized")
+		Expect(oldObj).NotTo(BeNil(), "Expected oldObj to be initialized")
+		Expect(obj).NotTo(BeNil(), "Expected obj to be initialized")
+	})
+
+	Context("When creating or updating ClusterAdvertisement under Validating Webhook", func() {
+		It("Should admit a valid advertisement", func() {
+			Expect(validator.ValidateCreate(ctx, obj)).Error().NotTo(HaveOccurred())
+		})
+
+		It("Should deny creation if allocated exceeds allocatable", func() {
+			obj.Spec.Resources.Allocated.CPU = resource.MustParse("10")
+			Expect(validator.ValidateCreate(ctx, obj)).Error().To(MatchError(ContainSubstring("exceeds allocatable")))
+		})
+
+		It("Should deny an update that makes a valid advertisement invalid", func() {
+			obj.Spec.Resources.Allocatable.Memory = resource.MustParse("-1Gi")
+			Expect(validator.ValidateUpdate(ctx, oldObj, obj)).Error().To(MatchError(ContainSubstring("negative")))
+		})
+
+		It("Should warn about but admit updates of an advertisement that was already invalid", func() {
+			oldObj.Spec.Resources.Allocated.CPU = resource.MustParse("10")
+			obj.Spec.Resources.Allocated.CPU = resource.MustParse("10")
+			warnings, err := validator.ValidateUpdate(ctx, oldObj, obj)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(warnings).To(HaveLen(1))
+		})
+	})
+})
@@ -0,0 +1,205 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/broker"
+	"github.com/mehdiazizian/liqo-resource-broker/internal/resource"
+)
+
+// log is for logging in this package.
+var reservationlog = logf.Log.WithName("reservation-resource")
+
+// SetupReservationWebhookWithManager registers the webhook for Reservation in the manager.
+func SetupReservationWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).For(&brokerv1alpha1.Reservation{}).
+		WithValidator(&ReservationCustomValidator{Client: mgr.GetClient()}).
+		Complete()
+}
+
+// NOTE: The 'path' attribute must follow a specific pattern and should not be modified directly here.
+// Modifying the path for an invalid path can cause API server errors; failing to locate the webhook.
+// +kubebuilder:webhook:path=/validate-broker-fluidos-eu-v1alpha1-reservation,mutating=false,failurePolicy=fail,sideEffects=None,groups=broker.fluidos.eu,resources=reservations,verbs=create;update,versions=v1alpha1,name=vreservation-v1alpha1.kb.io,admissionReviewVersions=v1
+
+// +kubebuilder:rbac:groups=authentication.k8s.io,resources=selfsubjectreviews,verbs=create
+
+// ReservationCustomValidator rejects invalid reservations before they are
+// stored, instead of letting the reconciler mark them Failed. It checks the
+// spec, that an explicit targetClusterID is advertised, and that a reservation
+// which holds or has booked resources keeps the fields its placement depends on.
+type ReservationCustomValidator struct {
+	// Client looks up ClusterAdvertisements and the broker's own user
+	Client client.Client
+
+	// brokerUsername is the user the broker itself writes as, resolved on
+	// first use. The broker moves reservations between clusters on failover.
+	brokerUsername string
+	mu             sync.Mutex
+}
+
+var _ webhook.CustomValidator = &ReservationCustomValidator{}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type Reservation.
+func (v *ReservationCustomValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	reservation, ok := obj.(*brokerv1alpha1.Reservation)
+	if !ok {
+		return nil, fmt.Errorf("expected a Reservation object but got %T", obj)
+	}
+	reservationlog.V(1).Info("Validation for Reservation upon creation", "name", reservation.GetName())
+
+	if err := broker.ValidateReservationSpec(&reservation.Spec); err != nil {
+		return nil, err
+	}
+	return nil, v.validateTargetCluster(ctx, reservation.Spec.TargetClusterID)
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type Reservation.
+func (v *ReservationCustomValidator) ValidateUpdate(
+	ctx context.Context,
+	oldObj, newObj runtime.Object,
+) (admission.Warnings, error) {
+	reservation, ok := newObj.(*brokerv1alpha1.Reservation)
+	if !ok {
+		return nil, fmt.Errorf("expected a Reservation object for the newObj but got %T", newObj)
+	}
+	oldReservation, ok := oldObj.(*brokerv1alpha1.Reservation)
+	if !ok {
+		return nil, fmt.Errorf("expected a Reservation object for the oldObj but got %T", oldObj)
+	}
+	reservationlog.V(1).Info("Validation for Reservation upon update", "name", reservation.GetName())
+
+	// Finalizers, annotations and labels may always change, so that reservations
+	// stored before this webhook existed can still be renewed and deleted
+	if equality.Semantic.DeepEqual(oldReservation.Spec, reservation.Spec) {
+		return nil, nil
+	}
+
+	if err := broker.ValidateReservationSpec(&reservation.Spec); err != nil {
+		return nil, err
+	}
+	if err := v.validateImmutable(ctx, oldReservation, reservation); err != nil {
+		return nil, err
+	}
+	if reservation.Spec.TargetClusterID != oldReservation.Spec.TargetClusterID {
+		return nil, v.validateTargetCluster(ctx, reservation.Spec.TargetClusterID)
+	}
+	return nil, nil
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type Reservation.
+func (v *ReservationCustomValidator) ValidateDelete(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// validateImmutable rejects changes to the fields the placement of a Scheduled,
+// Reserved or Active reservation depends on. requestedResources and duration
+// stay mutable for resizing and renewal, and the broker itself may move the
+// reservation to another cluster.
+func (v *ReservationCustomValidator) validateImmutable(
+	ctx context.Context,
+	oldReservation, reservation *brokerv1alpha1.Reservation,
+) error {
+	phase := oldReservation.Status.Phase
+	if !resource.HoldsResources(phase) && phase != brokerv1alpha1.ReservationPhaseScheduled {
+		return nil
+	}
+
+	if reservation.Spec.RequesterID != oldReservation.Spec.RequesterID {
+		return fmt.Errorf("spec.requesterID cannot be changed once the reservation is %s", phase)
+	}
+	if !equality.Semantic.DeepEqual(reservation.Spec.StartTime, oldReservation.Spec.StartTime) {
+		return fmt.Errorf("spec.startTime cannot be changed once the reservation is %s", phase)
+	}
+	if !equality.Semantic.DeepEqual(reservation.Spec.EndTime, oldReservation.Spec.EndTime) {
+		return fmt.Errorf("spec.endTime cannot be changed once the reservation is %s; "+
+			"renew it through spec.duration or the %s annotation instead", phase, brokerv1alpha1.ReservationRenewAnnotation)
+	}
+	if reservation.Spec.TargetClusterID != oldReservation.Spec.TargetClusterID {
+		isBroker, err := v.isBroker(ctx)
+		if err != nil {
+			return err
+		}
+		if !isBroker {
+			return fmt.Errorf("spec.targetClusterID cannot be changed once the reservation is %s", phase)
+		}
+	}
+	return nil
+}
+
+// validateTargetCluster checks that an explicitly requested cluster is advertised
+func (v *ReservationCustomValidator) validateTargetCluster(ctx context.Context, clusterID string) error {
+	if clusterID == "" {
+		return nil
+	}
+	clusterList := &brokerv1alpha1.ClusterAdvertisementList{}
+	if err := v.Client.List(ctx, clusterList); err != nil {
+		return fmt.Errorf("failed to list cluster advertisements: %w", err)
+	}
+	for i := range clusterList.Items {
+		if clusterList.Items[i].Spec.ClusterID == clusterID {
+			return nil
+		}
+	}
+	return fmt.Errorf("spec.targetClusterID: no ClusterAdvertisement advertises cluster %q", clusterID)
+}
+
+// isBroker reports whether the request under validation was sent by the broker
+func (v *ReservationCustomValidator) isBroker(ctx context.Context) (bool, error) {
+	req, err := admission.RequestFromContext(ctx)
+	if err != nil {
+		return false, err
+	}
+	username, err := v.resolveBrokerUsername(ctx)
+	if err != nil {
+		return false, err
+	}
+	return req.UserInfo.Username == username, nil
+}
+
+// resolveBrokerUsername asks the API server who the broker is
+func (v *ReservationCustomValidator) resolveBrokerUsername(ctx context.Context) (string, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.brokerUsername != "" {
+		return v.brokerUsername, nil
+	}
+
+	review := &authenticationv1.SelfSubjectReview{}
+	if err := v.Client.Create(ctx, review); err != nil {
+		return "", fmt.Errorf("failed to look up the broker's own user: %w", err)
+	}
+	if review.Status.UserInfo.Username == "" {
+		return "", errors.New("failed to look up the broker's own user: empty username")
+	}
+	v.brokerUsername = review.Status.UserInfo.Username
+	return v.brokerUsername, nil
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	brokerv1alpha1 "github.com/mehdiazizian/liqo-resource-broker/api/v1alpha1"
+)
+
+var _ = Describe("Reservation Webhook", func() {
+	var (
+		obj       *brokerv1alpha1.Reservation
+		oldObj    *brokerv1alpha1.Reservation
+		validator *ReservationCustomValidator
+	)
+
+	BeforeEach(func() {
+		obj = &brokerv1alpha1.Reservation{
+			Spec: brokerv1alpha1.ReservationSpec{
+				RequesterID: "requester-a",
+				RequestedResources: brokerv1alpha1.RequestedResourceQuantities{
+					CPU:    resource.MustParse("2"),
+					Memory: resource.MustParse("4Gi"),
+				},
+			},
+		}
+		oldObj = obj.DeepCopy()
+		validator = &ReservationCustomValidator{Client: k8sClient}
+		Expect(validator).NotTo(BeNil(), "Expected validator to be initialized")
+		Expect(oldObj).NotTo(BeNil(), "Expected oldObj to be initialized")
+		Expect(obj).NotTo(BeNil(), "Expected obj to be initialized")
+	})
+
+	Context("When creating or updating Reservation under Validating Webhook", func() {
+		It("Should admit a valid reservation", func() {
+			Expect(validator.ValidateCreate(ctx, obj)).Error().NotTo(HaveOccurred())
+		})
+
+		It("Should deny creation if requesterID is missing", func() {
+			obj.Spec.RequesterID = ""
+			Expect(validator.ValidateCreate(ctx, obj)).Error().To(MatchError(ContainSubstring("requesterID")))
+		})
+
+		It("Should deny creation if a requested quantity is not positive", func() {
+			obj.Spec.RequestedResources.CPU = resource.MustParse("0")
+			Expect(validator.ValidateCreate(ctx, obj)).Error().To(MatchError(ContainSubstring("CPU")))
+		})
+
+		It("Should deny creation if the target cluster is not advertised", func() {
+			obj.Spec.TargetClusterID = "unknown-cluster"
+			Expect(validator.ValidateCreate(ctx, obj)).Error().To(MatchError(ContainSubstring("unknown-cluster")))
+		})
+
+		It("Should allow resizing a reserved reservation", func() {
+			oldObj.Status.Phase = brokerv1alpha1.ReservationPhaseReserved
+			obj.Spec.RequestedResources.CPU = resource.MustParse("4")
+			Expect(validator.ValidateUpdate(ctx, oldObj, obj)).Error().NotTo(HaveOccurred())
+		})
+
+		It("Should deny changing requesterID of a reserved reservation", func() {
+			oldObj.Status.Phase = brokerv1alpha1.ReservationPhaseReserved
+			obj.Spec.RequesterID = "requester-b"
+			Expect(validator.ValidateUpdate(ctx, oldObj, obj)).Error().To(MatchError(ContainSubstring("requesterID")))
+		})
+
+		It("Should deny others moving a reserved reservation to another cluster", func() {
+			oldObj.Status.Phase = brokerv1alpha1.ReservationPhaseReserved
+			oldObj.Spec.TargetClusterID = "cluster-a"
+			obj.Spec.TargetClusterID = "cluster-b"
+			requestCtx := admission.NewContextWithRequest(ctx, admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					UserInfo: authenticationv1.UserInfo{Username: "someone-else"},
+				},
+			})
+			Expect(validator.ValidateUpdate(requestCtx, oldObj, obj)).Error().To(
+				MatchError(ContainSubstring("targetClusterID")))
+		})
+	})
+})